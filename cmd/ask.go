@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/indexer"
+)
+
+var askSources int
+
+var askCmd = &cobra.Command{
+	Use:   "ask <question>",
+	Short: "Answer a question from your bookmarks",
+	Long: `Answer a question from your bookmarks. The most relevant bookmarks are found
+with hybrid search, and their summaries and matching excerpts of their content
+are given to the configured LLM, which answers citing them as [1], [2], ...
+
+The answer streams as it is written, followed by the cited bookmarks. With
+--json, the full answer and every bookmark it was given are printed at the end.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		question := strings.Join(args, " ")
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		store, err := indexer.OpenStore(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		opts := indexer.AskOptions{Sources: askSources}
+		if !jsonOutput {
+			opts.OnText = func(text string) { fmt.Print(text) }
+		}
+		answer, err := indexer.Ask(cfg, store, question, opts)
+		if err != nil {
+			return fmt.Errorf("ask failed: %w", err)
+		}
+
+		if jsonOutput {
+			data, err := json.MarshalIndent(answer, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Println()
+		cited := answer.CitedSources()
+		if len(cited) == 0 {
+			if len(answer.Sources) == 0 {
+				fmt.Fprintln(os.Stderr, "\nNo bookmarks matched the question.")
+			}
+			return nil
+		}
+		fmt.Println("\nSources:")
+		for _, s := range cited {
+			fmt.Printf("[%d] %s\n    %s (%s)\n", s.N, s.Title, s.URL, s.ID)
+		}
+		return nil
+	},
+}
+
+func init() {
+	askCmd.Flags().IntVarP(&askSources, "sources", "n", 8, "Bookmarks to give the LLM")
+	askCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	rootCmd.AddCommand(askCmd)
+}
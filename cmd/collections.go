@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/user/xhub/internal/db"
+)
+
+var collectionsLimit int
+
+var collectionsCmd = &cobra.Command{
+	Use:   "collections",
+	Short: "Browse bookmarks grouped into collections",
+	Long: `List collections with bookmark counts: collections imported from Raindrop,
+kept in step with each sync, and topics found by "xhub topics build".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		collections, err := store.ListCollections("")
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			data, err := json.MarshalIndent(collections, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		if len(collections) == 0 {
+			fmt.Println("No collections yet.")
+			return nil
+		}
+		for _, c := range collections {
+			fmt.Printf("%5d  %s  (%s #%d)\n", c.Count, c.Name, c.Kind, c.ID)
+		}
+		return nil
+	},
+}
+
+var collectionsShowCmd = &cobra.Command{
+	Use:   "show <id-or-name>",
+	Short: "List the bookmarks in a collection",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		collection, err := store.FindCollection("", args[0])
+		if err != nil {
+			return err
+		}
+		bookmarks, err := store.CollectionBookmarks(collection.ID, collectionsLimit)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return outputJSON(db.NewSearchResults(bookmarks))
+		}
+		fmt.Printf("%s (%d bookmarks)\n", collection.Name, collection.Count)
+		if collection.Description != "" {
+			fmt.Println(collection.Description)
+		}
+		fmt.Println()
+		return outputDefault(db.NewSearchResults(bookmarks))
+	},
+}
+
+func init() {
+	collectionsShowCmd.Flags().IntVarP(&collectionsLimit, "limit", "n", 50, "Maximum bookmarks to show")
+	collectionsShowCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	collectionsCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+
+	collectionsCmd.AddCommand(collectionsShowCmd)
+	rootCmd.AddCommand(collectionsCmd)
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/xhub/internal/db"
+)
+
+var (
+	dedupeSimilarity float64
+	dedupeMerge      bool
+	dedupeLogLimit   int
+	dedupeDryRun     bool
+)
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find and merge duplicate bookmarks",
+	Long: `Find bookmarks that are the same page saved from different places: same
+canonical URL (twitter.com and x.com, tracking parameters, www. and trailing
+slashes ignored), same scraped text, or nearly identical embeddings.
+
+Each group lists the bookmark suggested to keep first. With --merge every group
+is merged into it; "xhub dedupe merge" merges bookmarks of your choosing. Merges
+keep notes, tags and sources of all the duplicates and can be undone.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		groups, err := store.FindDuplicates(dedupeSimilarity)
+		if err != nil {
+			return fmt.Errorf("finding duplicates failed: %w", err)
+		}
+		if jsonOutput {
+			data, err := json.MarshalIndent(groups, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		if len(groups) == 0 {
+			fmt.Println("No duplicates found.")
+			return nil
+		}
+
+		for i, g := range groups {
+			fmt.Printf("Group %d (%s):\n", i+1, strings.Join(g.Reasons, ", "))
+			for j, b := range g.Bookmarks {
+				marker := "      "
+				if j == 0 {
+					marker = "keep  "
+				}
+				fmt.Printf("  %s%s %s\n", marker, sourceIcon(b.Source), b.Title)
+				fmt.Printf("        %s (%s)\n", b.URL, b.ID)
+			}
+			if dedupeMerge {
+				record, err := store.MergeBookmarks(g.Bookmarks[0].ID, groupIDs(g)[1:])
+				if err != nil {
+					return fmt.Errorf("merging group %d failed: %w", i+1, err)
+				}
+				fmt.Printf("  Merged (undo with: xhub dedupe undo %d)\n", record.ID)
+			}
+			fmt.Println()
+		}
+		if !dedupeMerge {
+			fmt.Printf("%d groups. Merge them all with --merge, or pick with: xhub dedupe merge <keep> <duplicate>...\n", len(groups))
+		}
+		return nil
+	},
+}
+
+var dedupeMergeCmd = &cobra.Command{
+	Use:   "merge <keep-id-or-url> <duplicate-id-or-url>...",
+	Short: "Merge duplicates into one bookmark",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		var ids []string
+		for _, arg := range args {
+			b, err := store.Get(arg)
+			if err != nil {
+				b, err = store.GetByURL(arg)
+			}
+			if err != nil {
+				return fmt.Errorf("no bookmark with ID or URL %q", arg)
+			}
+			ids = append(ids, b.ID)
+		}
+
+		record, err := store.MergeBookmarks(ids[0], ids[1:])
+		if err != nil {
+			return fmt.Errorf("merge failed: %w", err)
+		}
+		fmt.Printf("Merged %d bookmarks into %s (undo with: xhub dedupe undo %d)\n", len(record.MergedURLs), record.KeptID, record.ID)
+		return nil
+	},
+}
+
+var dedupeLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "List past merges",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		records, err := store.ListMerges(dedupeLogLimit)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			fmt.Println("No merges.")
+			return nil
+		}
+		for _, r := range records {
+			status := ""
+			if r.UndoneAt != nil {
+				status = " (undone)"
+			}
+			fmt.Printf("#%d  %s  into %s%s\n", r.ID, r.MergedAt.Format("2006-01-02 15:04"), r.KeptID, status)
+			for _, u := range r.MergedURLs {
+				fmt.Printf("      %s\n", u)
+			}
+		}
+		return nil
+	},
+}
+
+var dedupeUndoCmd = &cobra.Command{
+	Use:   "undo [merge-id]",
+	Short: "Undo a merge (the latest by default)",
+	Long: `Undo a merge: the merged bookmarks come back and the kept bookmark returns
+to how it was before the merge, losing any edits made to it since.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		var id int64
+		if len(args) == 1 {
+			id, err = strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid merge ID %q", args[0])
+			}
+		} else {
+			records, err := store.ListMerges(100)
+			if err != nil {
+				return err
+			}
+			for _, r := range records {
+				if r.UndoneAt == nil {
+					id = r.ID
+					break
+				}
+			}
+			if id == 0 {
+				return fmt.Errorf("no merge to undo")
+			}
+		}
+
+		record, err := store.UndoMerge(id)
+		if err != nil {
+			return fmt.Errorf("undo failed: %w", err)
+		}
+		fmt.Printf("Undid merge #%d, restoring %d bookmarks\n", record.ID, len(record.MergedURLs))
+		return nil
+	},
+}
+
+var dedupeCanonicalizeCmd = &cobra.Command{
+	Use:   "canonicalize",
+	Short: "Move bookmarks to their canonical URLs",
+	Long: `Rewrite the URL of every bookmark saved before canonicalization, or before
+a change to the urls rules, to its canonical form. The old URL is kept as the
+bookmark's original URL and its ID doesn't change. Bookmarks that end up with
+the same URL are merged like "xhub dedupe merge" does; each merge can be undone.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		changes, err := store.CanonicalizeURLs(dedupeDryRun)
+		if err != nil {
+			return fmt.Errorf("canonicalizing URLs failed: %w", err)
+		}
+		if jsonOutput {
+			data, err := json.MarshalIndent(changes, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		if len(changes) == 0 {
+			fmt.Println("All URLs are canonical.")
+			return nil
+		}
+
+		merged := 0
+		for _, c := range changes {
+			fmt.Printf("%s\n  -> %s", c.URL, c.Canonical)
+			if c.MergedInto != "" {
+				merged++
+				fmt.Printf("  (merged into %s)", c.MergedInto)
+			}
+			fmt.Println()
+		}
+		verb := "Updated"
+		if dedupeDryRun {
+			verb = "Would update"
+		}
+		fmt.Printf("\n%s %d bookmarks, %d of them merged into another\n", verb, len(changes), merged)
+		return nil
+	},
+}
+
+func groupIDs(g db.DuplicateGroup) []string {
+	ids := make([]string, len(g.Bookmarks))
+	for i, b := range g.Bookmarks {
+		ids[i] = b.ID
+	}
+	return ids
+}
+
+func init() {
+	dedupeCmd.Flags().Float64Var(&dedupeSimilarity, "similarity", 0.97, "Minimum embedding cosine similarity for duplicates (0 to skip)")
+	dedupeCmd.Flags().BoolVar(&dedupeMerge, "merge", false, "Merge every group into its suggested bookmark")
+	dedupeCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	dedupeLogCmd.Flags().IntVarP(&dedupeLogLimit, "limit", "n", 20, "Maximum merges to show")
+	dedupeCanonicalizeCmd.Flags().BoolVar(&dedupeDryRun, "dry-run", false, "Show the changes without making them")
+	dedupeCanonicalizeCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+
+	dedupeCmd.AddCommand(dedupeMergeCmd, dedupeLogCmd, dedupeUndoCmd, dedupeCanonicalizeCmd)
+	rootCmd.AddCommand(dedupeCmd)
+}
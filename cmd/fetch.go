@@ -13,6 +13,8 @@ var (
 	verboseFlag   bool
 	forceFlag     bool
 	reprocessFlag bool
+	batchFlag     bool
+	overwriteFlag bool
 	sourceFlag    []string
 )
 
@@ -33,10 +35,12 @@ var fetchCmd = &cobra.Command{
 		}
 
 		return indexer.Fetch(cfg, indexer.FetchOptions{
-			Force:     forceFlag,
-			Reprocess: reprocessFlag,
-			Verbose:   verboseFlag,
-			Sources:   sources,
+			Force:          forceFlag,
+			Reprocess:      reprocessFlag,
+			OverwriteEdits: overwriteFlag,
+			Verbose:        verboseFlag,
+			Batch:          batchFlag || cfg.LLM.Batch,
+			Sources:        sources,
 		})
 	},
 }
@@ -45,6 +49,8 @@ func init() {
 	fetchCmd.Flags().BoolVarP(&verboseFlag, "verbose", "v", false, "Show detailed processing steps")
 	fetchCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Full reimport of all bookmarks from sources")
 	fetchCmd.Flags().BoolVarP(&reprocessFlag, "reprocess", "r", false, "Re-scrape, re-summarize, and re-embed existing items (use with --force)")
+	fetchCmd.Flags().BoolVar(&overwriteFlag, "overwrite-edits", false, "With --reprocess, also regenerate fields edited by hand")
+	fetchCmd.Flags().BoolVarP(&batchFlag, "batch", "b", false, "Submit summaries and embeddings via provider batch APIs (applied on a later run)")
 	fetchCmd.Flags().StringSliceVarP(&sourceFlag, "source", "s", nil, "Filter to specific source(s): github, x, raindrop")
 	rootCmd.AddCommand(fetchCmd)
 }
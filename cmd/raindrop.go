@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/indexer"
+	"github.com/user/xhub/internal/raindrop"
+)
+
+var (
+	raindropDryRun bool
+	raindropForce  bool
+	raindropLimit  int
+	raindropLogLen int
+)
+
+var raindropCmd = &cobra.Command{
+	Use:   "raindrop",
+	Short: "List notes and tags edited in xhub that Raindrop doesn't have yet",
+	Long: `List Raindrop bookmarks whose notes or tags were edited in xhub since they
+were last in step with Raindrop. "xhub raindrop push" writes them back, and so
+does "xhub fetch" when raindrop.write_back is set.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		edits, err := store.RaindropEdits(-1)
+		if err != nil {
+			return err
+		}
+		if len(edits) == 0 {
+			fmt.Println("Raindrop has every edit.")
+			return nil
+		}
+		for _, e := range edits {
+			fmt.Printf("%s  %s (%s)\n", e.BookmarkID, e.Title, editedFields(e))
+		}
+		return nil
+	},
+}
+
+var raindropPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Write notes and tags edited in xhub back to Raindrop",
+	Long: `Write notes and tags edited in xhub back to Raindrop through its API. Each
+raindrop is read first: if Raindrop changed the same field since the last sync,
+the bookmark is skipped as a conflict (use --force to overwrite). Pushes,
+conflicts and failures are recorded in the sync log ("xhub raindrop log").
+
+The API token comes from RAINDROP_TOKEN or raindrop.token (create a test token
+at https://app.raindrop.io/settings/integrations).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		store, err := indexer.OpenStore(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		stats, err := indexer.PushRaindrop(cfg, store, raindrop.PushOptions{DryRun: raindropDryRun, Force: raindropForce, Limit: raindropLimit})
+		if err != nil {
+			return fmt.Errorf("write-back failed: %w", err)
+		}
+		for _, r := range stats.Results {
+			action := r.Action
+			switch {
+			case action == "":
+				action = "in sync"
+			case raindropDryRun && action == db.SyncPushed:
+				action = "would push"
+			}
+			fmt.Printf("%-10s %s", action, r.Edit.Title)
+			if r.Detail != "" {
+				fmt.Printf(": %s", r.Detail)
+			}
+			fmt.Println()
+		}
+		verb := "Pushed"
+		if raindropDryRun {
+			verb = "Would push"
+		}
+		fmt.Printf("%s %d bookmarks (%d conflicts, %d failed)\n", verb, stats.Pushed, stats.Conflicts, stats.Failed)
+		return nil
+	},
+}
+
+var raindropLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the Raindrop write-back log",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		entries, err := store.RaindropSyncLog(raindropLogLen)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			data, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		if len(entries) == 0 {
+			fmt.Println("Nothing written back to Raindrop yet.")
+			return nil
+		}
+		for _, e := range entries {
+			fmt.Printf("%s  %-8s  %s (raindrop %d)", e.CreatedAt.Local().Format("2006-01-02 15:04"), e.Action, e.BookmarkID, e.RaindropID)
+			if e.Detail != "" {
+				fmt.Printf(": %s", e.Detail)
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+// editedFields names the fields of an edit Raindrop doesn't have.
+func editedFields(e db.RaindropEdit) string {
+	var fields []string
+	if e.NoteEdited {
+		fields = append(fields, "note")
+	}
+	if e.TagsEdited {
+		fields = append(fields, "tags")
+	}
+	return strings.Join(fields, ", ")
+}
+
+func init() {
+	raindropPushCmd.Flags().BoolVar(&raindropDryRun, "dry-run", false, "Show what would be written without changing anything")
+	raindropPushCmd.Flags().BoolVar(&raindropForce, "force", false, "Overwrite fields changed in Raindrop since the last sync")
+	raindropPushCmd.Flags().IntVarP(&raindropLimit, "limit", "n", 0, "Maximum bookmarks to push (0 for all)")
+	raindropLogCmd.Flags().IntVarP(&raindropLogLen, "limit", "n", 50, "Maximum entries to show")
+	raindropLogCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+
+	raindropCmd.AddCommand(raindropPushCmd, raindropLogCmd)
+	rootCmd.AddCommand(raindropCmd)
+}
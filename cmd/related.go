@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/indexer"
+)
+
+var relatedLimit int
+
+var relatedCmd = &cobra.Command{
+	Use:   "related <id-or-url>",
+	Short: "Find bookmarks related to a bookmark",
+	Long: `Find bookmarks similar to a saved bookmark: its nearest neighbours by
+embedding, plus bookmarks sharing its tags or site (the same account on
+GitHub and X). With --explain, each result shows which of these matched.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		store, err := indexer.OpenStore(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		b, err := store.Get(args[0])
+		if err != nil {
+			b, err = store.GetByURL(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("no bookmark with ID or URL %q", args[0])
+		}
+
+		results, err := store.Related(b.ID, relatedLimit)
+		if err != nil {
+			return fmt.Errorf("finding related bookmarks failed: %w", err)
+		}
+		if !explainRanking {
+			for i := range results {
+				results[i].Explain = nil
+			}
+		}
+
+		if jsonOutput {
+			return outputJSON(results)
+		}
+		if plaintextOutput {
+			return outputPlaintext(results)
+		}
+		fmt.Printf("Related to %s %s\n\n", sourceIcon(b.Source), b.Title)
+		return outputDefault(results)
+	},
+}
+
+func init() {
+	relatedCmd.Flags().IntVarP(&relatedLimit, "limit", "n", 10, "Maximum bookmarks to show")
+	relatedCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	relatedCmd.Flags().BoolVarP(&plaintextOutput, "plaintext", "p", false, "Output as plaintext")
+	relatedCmd.Flags().BoolVarP(&explainRanking, "explain", "e", false, "Show which signals matched each result")
+	rootCmd.AddCommand(relatedCmd)
+}
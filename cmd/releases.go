@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/indexer"
+	"github.com/user/xhub/internal/releases"
+)
+
+var (
+	releasesDays  int
+	releasesLimit int
+	releasesOut   string
+)
+
+var releasesCmd = &cobra.Command{
+	Use:   "releases",
+	Short: "List recent releases of starred GitHub repositories",
+	Long: `List releases of starred GitHub repositories, newest first. Releases are
+found by "xhub releases poll", and by "xhub fetch" when releases.enabled is set.
+Repositories that don't publish releases are watched for new tags instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		recent, err := store.RecentReleases(time.Now().AddDate(0, 0, -releasesDays), releasesLimit)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			data, err := json.MarshalIndent(recent, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		if len(recent) == 0 {
+			fmt.Printf("No releases in the last %d days.\n", releasesDays)
+			return nil
+		}
+		for _, r := range recent {
+			pre := ""
+			if r.Prerelease {
+				pre = " (pre-release)"
+			}
+			fmt.Printf("%s  %s %s%s\n            %s\n", r.PublishedAt.Local().Format("2006-01-02"), r.Repo, r.Title(), pre, r.URL)
+		}
+		return nil
+	},
+}
+
+var releasesPollCmd = &cobra.Command{
+	Use:   "poll",
+	Short: "Check starred repositories for new releases",
+	Long: `Check starred repositories for new releases or tags, least recently checked
+first, up to releases.max_repos. Requests are conditional on ETags, so unchanged
+repositories don't count against the GitHub rate limit, and polling stops before
+the limit runs out. The releases.feed file is rewritten afterwards.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		store, err := indexer.OpenStore(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		stats, err := indexer.PollReleases(cfg, store)
+		if err != nil {
+			return fmt.Errorf("polling releases failed: %w", err)
+		}
+		for _, r := range stats.New {
+			fmt.Printf("%s %s\n  %s\n", r.Repo, r.Title(), r.URL)
+		}
+		fmt.Printf("Checked %d repositories (%d unchanged, %d failed), found %d new releases\n",
+			stats.Checked, stats.Unchanged, stats.Failed, len(stats.New))
+		if stats.RateLimited != nil {
+			fmt.Printf("Stopped early: %v\n", stats.RateLimited)
+		}
+		return nil
+	},
+}
+
+var releasesFeedCmd = &cobra.Command{
+	Use:   "feed",
+	Short: "Write the latest releases as an Atom feed",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		if releasesOut != "" {
+			return indexer.WriteReleaseFeed(store, releasesOut)
+		}
+		recent, err := indexer.FeedReleases(store)
+		if err != nil {
+			return err
+		}
+		return releases.WriteFeed(os.Stdout, recent)
+	},
+}
+
+func init() {
+	releasesCmd.Flags().IntVar(&releasesDays, "days", 30, "Show releases from the last N days")
+	releasesCmd.Flags().IntVarP(&releasesLimit, "limit", "n", 50, "Maximum releases to show")
+	releasesCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	releasesFeedCmd.Flags().StringVarP(&releasesOut, "output", "o", "", "Write the feed to a file instead of stdout")
+
+	releasesCmd.AddCommand(releasesPollCmd, releasesFeedCmd)
+	rootCmd.AddCommand(releasesCmd)
+}
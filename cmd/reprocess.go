@@ -9,7 +9,8 @@ import (
 )
 
 var (
-	reprocessVerbose bool
+	reprocessVerbose   bool
+	reprocessOverwrite bool
 )
 
 var reprocessCmd = &cobra.Command{
@@ -23,7 +24,10 @@ var reprocessCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		b, err := indexer.ReprocessByIDOrURL(cfg, args[0], reprocessVerbose)
+		b, err := indexer.ReprocessByIDOrURL(cfg, args[0], indexer.ReprocessOptions{
+			Verbose:        reprocessVerbose,
+			OverwriteEdits: reprocessOverwrite,
+		})
 		if err != nil {
 			return fmt.Errorf("reprocess failed: %w", err)
 		}
@@ -39,5 +43,6 @@ var reprocessCmd = &cobra.Command{
 
 func init() {
 	reprocessCmd.Flags().BoolVarP(&reprocessVerbose, "verbose", "v", false, "Show warnings for embedding issues")
+	reprocessCmd.Flags().BoolVar(&reprocessOverwrite, "overwrite-edits", false, "Also regenerate title, summary and keywords edited by hand")
 	rootCmd.AddCommand(reprocessCmd)
 }
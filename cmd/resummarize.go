@@ -1,13 +1,10 @@
 package cmd
 
 import (
-	"database/sql"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/user/xhub/internal/config"
-	"github.com/user/xhub/internal/db"
 	"github.com/user/xhub/internal/indexer"
 )
 
@@ -49,14 +46,14 @@ func Resummarize(cfg *config.Config, limit int, verbose bool, debug bool) error
 		indexer.SetDebugMode(true)
 	}
 
-	store, err := db.NewStore(cfg.DataDir)
+	store, err := indexer.OpenStore(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer store.Close()
 
 	// Get bookmarks with raw content but empty/missing summaries
-	bookmarks, err := getBookmarksNeedingSummary(store, limit)
+	bookmarks, err := store.GetNeedingSummary(limit)
 	if err != nil {
 		return fmt.Errorf("failed to get bookmarks: %w", err)
 	}
@@ -69,6 +66,7 @@ func Resummarize(cfg *config.Config, limit int, verbose bool, debug bool) error
 	fmt.Printf("Processing %d bookmark(s)...\n\n", len(bookmarks))
 
 	summarizer := indexer.NewSummarizer(cfg)
+	validator := indexer.NewValidator(cfg)
 	embedder, err := indexer.NewEmbedder(cfg)
 	if err != nil {
 		fmt.Printf("Warning: embeddings disabled: %v\n", err)
@@ -92,7 +90,7 @@ func Resummarize(cfg *config.Config, limit int, verbose bool, debug bool) error
 			fmt.Printf("  Summarizing...\n")
 		}
 
-		result, err := summarizer.Summarize(b.RawContent)
+		result, err := summarizer.Summarize(&b)
 		if err != nil {
 			fmt.Printf("  Error: summarization failed: %v\n", err)
 			if verbose {
@@ -115,8 +113,15 @@ func Resummarize(cfg *config.Config, limit int, verbose bool, debug bool) error
 			continue
 		}
 
-		b.Summary = result.Summary
-		b.Keywords = result.Keywords
+		if reason := validator.CheckSummary(result); reason != "" {
+			fmt.Printf("  Error: summary rejected: %s\n", reason)
+			if debug {
+				fmt.Printf("  LLM Raw Response:\n%s\n", result.RawResponse)
+			}
+			continue
+		}
+
+		indexer.ApplySummary(store, &b, result, true)
 
 		if verbose {
 			fmt.Printf("  Summary: %s\n", result.Summary)
@@ -155,48 +160,6 @@ func Resummarize(cfg *config.Config, limit int, verbose bool, debug bool) error
 	return nil
 }
 
-// getBookmarksNeedingSummary retrieves bookmarks with raw content but missing summaries
-func getBookmarksNeedingSummary(store *db.Store, limit int) ([]db.Bookmark, error) {
-	query := `
-		SELECT id, source, url, title, summary, keywords, notes, raw_content, created_at, updated_at, scrape_status, hidden
-		FROM bookmarks
-		WHERE raw_content != ''
-		AND (summary = '' OR summary IS NULL)
-		AND hidden = 0
-		ORDER BY updated_at DESC
-	`
-
-	var rows *sql.Rows
-	var err error
-
-	if limit > 0 {
-		query += " LIMIT ?"
-		rows, err = store.DB().Query(query, limit)
-	} else {
-		rows, err = store.DB().Query(query)
-	}
-
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var bookmarks []db.Bookmark
-	for rows.Next() {
-		var b db.Bookmark
-		if err := rows.Scan(
-			&b.ID, &b.Source, &b.URL, &b.Title, &b.Summary, &b.Keywords, &b.Notes,
-			&b.RawContent, &b.CreatedAt, &b.UpdatedAt, &b.ScrapeStatus, &b.Hidden,
-		); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to scan bookmark: %v\n", err)
-			continue
-		}
-		bookmarks = append(bookmarks, b)
-	}
-
-	return bookmarks, rows.Err()
-}
-
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
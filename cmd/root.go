@@ -6,6 +6,8 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/indexer"
 	"github.com/user/xhub/internal/tui"
 )
 
@@ -29,6 +31,20 @@ func Execute() {
 	}
 }
 
+// openStore loads the config and opens the database with it, for commands
+// that need nothing else from the config.
+func openStore() (*db.Store, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := indexer.OpenStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return store, nil
+}
+
 func init() {
 	rootCmd.PersistentFlags().String("data-dir", "", "Data directory (default: ~/.xhub)")
 }
@@ -3,23 +3,48 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/user/xhub/internal/config"
 	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/indexer"
 )
 
+// searchLimit is how many results xhub search prints.
+const searchLimit = 20
+
 var (
 	jsonOutput      bool
 	plaintextOutput bool
+	understandQuery bool
+	explainRanking  bool
+	noRerank        bool
 )
 
 var searchCmd = &cobra.Command{
 	Use:   "search <query>",
 	Short: "Search bookmarks",
-	Long:  "Search indexed bookmarks using hybrid semantic + keyword search.",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Search indexed bookmarks using hybrid semantic + keyword search.
+
+Words match by prefix, "quoted phrases" exactly, -word excludes and OR joins
+terms. Filters: source:github, tag:rust, status:failed, author:karpathy (X
+tweets by the handle and pages linked from them), collection:reading,
+is:starred, has:notes, has:highlights, after:2024-01, before:2025 (each can be
+negated with a leading -).
+
+With --understand, a natural-language query ("rust repos I starred last spring
+about async") is first turned into these filters by the LLM.
+
+With --explain, each result shows how its score was built: its rank and raw
+score in each signal (keyword BM25, page content, vector similarity), what
+that added to the fused score, and any recency or source boosts. Tune ranking
+under search.ranking in the config.
+
+When search.rerank is configured, the top results are reordered by a rerank
+model or the LLM before printing.`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		query := strings.Join(args, " ")
 
@@ -28,17 +53,46 @@ var searchCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		store, err := db.NewStore(cfg.DataDir)
+		store, err := indexer.OpenStore(cfg)
 		if err != nil {
 			return fmt.Errorf("failed to open database: %w", err)
 		}
 		defer store.Close()
 
-		results, err := store.Search(query, 20)
+		if understandQuery || cfg.Search.Understand {
+			interpreted, err := indexer.InterpretQuery(cfg, store, query)
+			if err != nil {
+				return fmt.Errorf("query understanding failed: %w", err)
+			}
+			if interpreted != query {
+				fmt.Fprintf(os.Stderr, "Interpreted as: %s\n", interpreted)
+				query = interpreted
+			}
+		}
+
+		var reranker *indexer.Reranker
+		if !noRerank {
+			reranker = indexer.NewReranker(cfg, store)
+			defer reranker.Close() // Before the store closes
+		}
+
+		results, err := store.Search(query, reranker.Candidates(searchLimit))
 		if err != nil {
 			return fmt.Errorf("search failed: %w", err)
 		}
 
+		results, err = reranker.Rerank(query, results)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		results = results[:min(len(results), searchLimit)]
+
+		if !explainRanking {
+			for i := range results {
+				results[i].Explain = nil
+			}
+		}
+
 		if jsonOutput {
 			return outputJSON(results)
 		}
@@ -49,7 +103,7 @@ var searchCmd = &cobra.Command{
 	},
 }
 
-func outputJSON(results []db.Bookmark) error {
+func outputJSON(results []db.SearchResult) error {
 	data, err := json.MarshalIndent(results, "", "  ")
 	if err != nil {
 		return err
@@ -58,29 +112,78 @@ func outputJSON(results []db.Bookmark) error {
 	return nil
 }
 
-func outputPlaintext(results []db.Bookmark) error {
+func outputPlaintext(results []db.SearchResult) error {
 	for _, r := range results {
 		fmt.Printf("%s\t%s\t%s\n", r.Source, r.Title, r.URL)
 	}
 	return nil
 }
 
-func outputDefault(results []db.Bookmark) error {
+func outputDefault(results []db.SearchResult) error {
 	if len(results) == 0 {
 		fmt.Println("No results found.")
 		return nil
 	}
+	mark := highlighter()
 	for i, r := range results {
 		icon := sourceIcon(r.Source)
-		fmt.Printf("%d. %s %s\n   %s\n", i+1, icon, r.Title, r.URL)
-		if r.Summary != "" {
+		title := r.Title
+		if h, ok := r.Highlights[db.FieldTitle]; ok {
+			title = db.RenderHighlights(h, mark)
+		}
+		if r.Starred {
+			title = "★ " + title
+		}
+		fmt.Printf("%d. %s %s\n   %s\n", i+1, icon, title, r.URL)
+		if h, ok := r.Highlights[db.FieldSummary]; ok {
+			fmt.Printf("   %s\n", db.RenderHighlights(oneLine(h), mark))
+		} else if r.Summary != "" {
 			fmt.Printf("   %s\n", truncate(r.Summary, 100))
 		}
+		if h, ok := r.Highlights[db.FieldNotes]; ok {
+			fmt.Printf("   Notes: %s\n", db.RenderHighlights(oneLine(h), mark))
+		}
+		if h, ok := r.Highlights[db.FieldKeywords]; ok {
+			fmt.Printf("   Tags: %s\n", db.RenderHighlights(h, mark))
+		}
+		if h, ok := r.Highlights[db.FieldAnnotations]; ok {
+			fmt.Printf("   Highlights: %s\n", db.RenderHighlights(oneLine(h), mark))
+		}
+		if h, ok := r.Highlights[db.HighlightContent]; ok {
+			fmt.Printf("   Content: %s\n", db.RenderHighlights(oneLine(h), mark))
+		}
+		for _, l := range r.LinkedFrom {
+			fmt.Printf("   Via %s\n", truncate(l.String(), 100))
+		}
+		if r.Explain != nil {
+			fmt.Printf("   Score %.4f (%s)\n", r.Score, r.Explain.Fusion)
+			for _, s := range r.Explain.Signals {
+				fmt.Printf("     %s\n", s)
+			}
+			for _, b := range r.Explain.Boosts {
+				fmt.Printf("     %s\n", b)
+			}
+			if r.Explain.Rerank != nil {
+				fmt.Printf("     %s\n", r.Explain.Rerank)
+			}
+		}
 		fmt.Println()
 	}
 	return nil
 }
 
+// highlighter marks matched terms in bold yellow on a terminal, and with ** otherwise.
+func highlighter() func(string) string {
+	if fi, err := os.Stdout.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		return func(s string) string { return "\x1b[1;33m" + s + "\x1b[0m" }
+	}
+	return func(s string) string { return "**" + s + "**" }
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
 func sourceIcon(source string) string {
 	switch source {
 	case "x":
@@ -106,5 +209,8 @@ func truncate(s string, maxLen int) string {
 func init() {
 	searchCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
 	searchCmd.Flags().BoolVarP(&plaintextOutput, "plaintext", "p", false, "Output as plaintext")
+	searchCmd.Flags().BoolVarP(&understandQuery, "understand", "u", false, "Turn a natural-language query into filters with the LLM")
+	searchCmd.Flags().BoolVarP(&explainRanking, "explain", "e", false, "Show how each result's score was computed")
+	searchCmd.Flags().BoolVar(&noRerank, "no-rerank", false, "Skip the search.rerank stage")
 	rootCmd.AddCommand(searchCmd)
 }
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/indexer"
+)
+
+var (
+	tagsThreshold   float64
+	tagsDryRun      bool
+	tagsDropUnknown bool
+	tagsAliasOf     string
+	tagsInto        string
+	tagsLimit       int
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Browse and manage tags",
+	Long:  "List tags with counts, rename, merge and delete them, and manage the tag taxonomy.",
+	Args:  cobra.NoArgs,
+	RunE:  runTagsList,
+}
+
+var tagsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tags with bookmark counts",
+	Args:  cobra.NoArgs,
+	RunE:  runTagsList,
+}
+
+func runTagsList(cmd *cobra.Command, args []string) error {
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	tags, err := store.ListTags()
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		fmt.Println("No tags.")
+		return nil
+	}
+	for _, t := range tags {
+		fmt.Printf("%5d  %s\n", t.Count, t.Name)
+	}
+	return nil
+}
+
+var tagsShowCmd = &cobra.Command{
+	Use:   "show <tag>",
+	Short: "List bookmarks with a tag",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		results, err := store.ListByTag(args[0], tagsLimit)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return outputJSON(db.NewSearchResults(results))
+		}
+		return outputDefault(db.NewSearchResults(results))
+	},
+}
+
+var tagsRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a tag on every bookmark",
+	Long:  "Rename a tag on every bookmark. Renaming onto an existing tag merges the two.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		n, err := store.RenameTag(args[0], args[1])
+		if err != nil {
+			return fmt.Errorf("rename failed: %w", err)
+		}
+		fmt.Printf("Renamed %q to %q on %d bookmarks\n", args[0], args[1], n)
+		return nil
+	},
+}
+
+var tagsMergeCmd = &cobra.Command{
+	Use:   "merge <tag>... --into <tag>",
+	Short: "Merge tags into one",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if tagsInto == "" {
+			return fmt.Errorf("--into is required")
+		}
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		n, err := store.MergeTags(args, tagsInto)
+		if err != nil {
+			return fmt.Errorf("merge failed: %w", err)
+		}
+		fmt.Printf("Merged %d tags into %q on %d bookmarks\n", len(args), tagsInto, n)
+		return nil
+	},
+}
+
+var tagsDeleteCmd = &cobra.Command{
+	Use:   "delete <tag>",
+	Short: "Remove a tag from every bookmark",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		n, err := store.DeleteTag(args[0])
+		if err != nil {
+			return fmt.Errorf("delete failed: %w", err)
+		}
+		fmt.Printf("Removed %q from %d bookmarks\n", args[0], n)
+		return nil
+	},
+}
+
+var tagsSuggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Suggest tag merges",
+	Long:  "Embed every tag name and list pairs similar enough to merge, e.g. golang -> go.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		merges, err := indexer.SuggestTagMerges(cfg, tagsThreshold)
+		if err != nil {
+			return fmt.Errorf("suggest failed: %w", err)
+		}
+		if len(merges) == 0 {
+			fmt.Println("No merge suggestions.")
+			return nil
+		}
+		for _, m := range merges {
+			fmt.Printf("%-30s -> %-30s (%.2f)\n", m.From, m.To, m.Similarity)
+		}
+		return nil
+	},
+}
+
+var tagsNormalizeCmd = &cobra.Command{
+	Use:   "normalize",
+	Short: "Map existing keywords onto canonical tags",
+	Long:  "Rewrite keywords to their canonical taxonomy names and queue unknown keywords for review.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		changes, err := indexer.NormalizeKeywords(cfg, indexer.NormalizeOptions{
+			DryRun:      tagsDryRun,
+			DropUnknown: tagsDropUnknown,
+		})
+		if err != nil {
+			return fmt.Errorf("normalize failed: %w", err)
+		}
+		for _, c := range changes {
+			fmt.Printf("%s\n  %s\n  -> %s\n", truncate(c.URL, 80), c.Before, c.After)
+		}
+		if tagsDryRun {
+			fmt.Printf("%d bookmarks would change\n", len(changes))
+		} else {
+			fmt.Printf("Updated %d bookmarks\n", len(changes))
+		}
+		return nil
+	},
+}
+
+var tagsReviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "List proposed tags awaiting review",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		proposals, err := store.ListTagProposals()
+		if err != nil {
+			return err
+		}
+		if len(proposals) == 0 {
+			fmt.Println("No proposed tags.")
+			return nil
+		}
+		for _, p := range proposals {
+			fmt.Printf("%-30s %d bookmarks\n", p.Tag, len(p.BookmarkIDs))
+		}
+		fmt.Println("\nUse 'xhub tags accept <tag> [--alias-of <tag>]' or 'xhub tags reject <tag>'.")
+		return nil
+	},
+}
+
+var tagsAcceptCmd = &cobra.Command{
+	Use:   "accept <tag>",
+	Short: "Add a proposed tag to the taxonomy",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		updated, err := indexer.AcceptTag(cfg, args[0], tagsAliasOf)
+		if err != nil {
+			return fmt.Errorf("accept failed: %w", err)
+		}
+		fmt.Printf("Added %q to %s and tagged %d bookmarks\n", args[0], cfg.TaxonomyPath(), updated)
+		return nil
+	},
+}
+
+var tagsRejectCmd = &cobra.Command{
+	Use:   "reject <tag>",
+	Short: "Drop a proposed tag",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		if err := indexer.RejectTag(cfg, args[0]); err != nil {
+			return fmt.Errorf("reject failed: %w", err)
+		}
+		fmt.Printf("Rejected %q\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	tagsSuggestCmd.Flags().Float64Var(&tagsThreshold, "threshold", 0.85, "Minimum cosine similarity between tag names")
+	tagsNormalizeCmd.Flags().BoolVar(&tagsDryRun, "dry-run", false, "Show changes without writing them")
+	tagsNormalizeCmd.Flags().BoolVar(&tagsDropUnknown, "drop-unknown", false, "Remove keywords outside the taxonomy (hand-edited keywords are kept)")
+	tagsAcceptCmd.Flags().StringVar(&tagsAliasOf, "alias-of", "", "Add as an alias of an existing tag instead of a new tag")
+	tagsMergeCmd.Flags().StringVar(&tagsInto, "into", "", "Tag to merge into")
+	tagsShowCmd.Flags().IntVarP(&tagsLimit, "limit", "n", 50, "Maximum bookmarks to show")
+	tagsShowCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+
+	tagsCmd.AddCommand(tagsListCmd, tagsShowCmd, tagsRenameCmd, tagsMergeCmd, tagsDeleteCmd)
+	tagsCmd.AddCommand(tagsSuggestCmd, tagsNormalizeCmd, tagsReviewCmd, tagsAcceptCmd, tagsRejectCmd)
+	rootCmd.AddCommand(tagsCmd)
+}
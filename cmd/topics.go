@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/indexer"
+)
+
+var (
+	topicsK       int
+	topicsMinSize int
+	topicsLimit   int
+)
+
+var topicsCmd = &cobra.Command{
+	Use:   "topics",
+	Short: "Browse bookmarks grouped by topic",
+	Long: `List topics with bookmark counts. Topics are collections found by clustering
+bookmark embeddings and named by the LLM; run "xhub topics build" to create or
+refresh them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		topics, err := store.ListCollections(db.CollectionTopic)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			data, err := json.MarshalIndent(topics, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		if len(topics) == 0 {
+			fmt.Println(`No topics yet. Run "xhub topics build" to find them.`)
+			return nil
+		}
+		for _, t := range topics {
+			fmt.Printf("%5d  %s  (#%d)\n", t.Count, t.Name, t.ID)
+		}
+		return nil
+	},
+}
+
+var topicsBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Cluster bookmarks into topics and name them",
+	Long: `Cluster the embeddings of all visible bookmarks with k-means, ask the LLM to
+name each cluster from its most central bookmarks, and store the clusters as
+topics, replacing the previous ones.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		store, err := indexer.OpenStore(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		topics, err := indexer.BuildTopics(cfg, store, indexer.TopicOptions{K: topicsK, MinSize: topicsMinSize})
+		if err != nil {
+			return fmt.Errorf("building topics failed: %w", err)
+		}
+		fmt.Printf("Stored %d topics\n", len(topics))
+		return nil
+	},
+}
+
+var topicsShowCmd = &cobra.Command{
+	Use:   "show <id-or-name>",
+	Short: "List the bookmarks in a topic",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		topic, err := store.FindCollection(db.CollectionTopic, args[0])
+		if err != nil {
+			return err
+		}
+		bookmarks, err := store.CollectionBookmarks(topic.ID, topicsLimit)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return outputJSON(db.NewSearchResults(bookmarks))
+		}
+		fmt.Printf("%s (%d bookmarks)\n", topic.Name, topic.Count)
+		if topic.Description != "" {
+			fmt.Println(topic.Description)
+		}
+		fmt.Println()
+		return outputDefault(db.NewSearchResults(bookmarks))
+	},
+}
+
+func init() {
+	topicsBuildCmd.Flags().IntVarP(&topicsK, "clusters", "k", 0, "Number of clusters (default: scaled to the number of bookmarks)")
+	topicsBuildCmd.Flags().IntVar(&topicsMinSize, "min-size", 3, "Leave out clusters with fewer bookmarks")
+	topicsShowCmd.Flags().IntVarP(&topicsLimit, "limit", "n", 50, "Maximum bookmarks to show")
+	topicsShowCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	topicsCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+
+	topicsCmd.AddCommand(topicsBuildCmd, topicsShowCmd)
+	rootCmd.AddCommand(topicsCmd)
+}
@@ -0,0 +1,175 @@
+// Package cluster groups embedding vectors by topic.
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// maxIterations bounds Lloyd iterations when assignments keep changing.
+const maxIterations = 100
+
+// Result is a clustering: which cluster each input vector belongs to, and how
+// close it is to that cluster's center.
+type Result struct {
+	K          int
+	Assignment []int     // Cluster index per vector
+	Similarity []float64 // Cosine similarity of each vector to its centroid
+}
+
+// Members returns the indexes of the vectors in cluster c, most central first.
+func (r *Result) Members(c int) []int {
+	var members []int
+	for i, a := range r.Assignment {
+		if a == c {
+			members = append(members, i)
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return r.Similarity[members[i]] > r.Similarity[members[j]]
+	})
+	return members
+}
+
+// DefaultK picks a number of clusters for n vectors: sqrt(n/2), at least 2 and
+// at most 50.
+func DefaultK(n int) int {
+	k := int(math.Round(math.Sqrt(float64(n) / 2)))
+	return min(max(k, 2), 50)
+}
+
+// KMeans clusters vectors by cosine similarity (spherical k-means with
+// k-means++ seeding). The same seed gives the same clustering.
+func KMeans(vectors [][]float32, k int, seed int64) *Result {
+	n := len(vectors)
+	if k > n {
+		k = n
+	}
+	res := &Result{K: k, Assignment: make([]int, n), Similarity: make([]float64, n)}
+	if k <= 0 {
+		return res
+	}
+
+	points := make([][]float64, n)
+	for i, v := range vectors {
+		points[i] = normalize(v)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	centroids := seedCentroids(points, k, rng)
+
+	for iter := 0; iter < maxIterations; iter++ {
+		changed := false
+		for i, p := range points {
+			best, bestSim := 0, math.Inf(-1)
+			for c, centroid := range centroids {
+				if sim := dot(p, centroid); sim > bestSim {
+					best, bestSim = c, sim
+				}
+			}
+			if iter == 0 || res.Assignment[i] != best {
+				changed = true
+			}
+			res.Assignment[i] = best
+			res.Similarity[i] = bestSim
+		}
+		if !changed {
+			break
+		}
+
+		// Move each centroid to the normalized mean of its members
+		dim := len(points[0])
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for c := range sums {
+			sums[c] = make([]float64, dim)
+		}
+		for i, p := range points {
+			c := res.Assignment[i]
+			counts[c]++
+			for d := range p {
+				sums[c][d] += p[d]
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				// Empty cluster: restart it at the point furthest from its center
+				far := 0
+				for i := range points {
+					if res.Similarity[i] < res.Similarity[far] {
+						far = i
+					}
+				}
+				centroids[c] = append([]float64(nil), points[far]...)
+				res.Similarity[far] = 1
+				continue
+			}
+			centroids[c] = normalize64(sums[c])
+		}
+	}
+	return res
+}
+
+// seedCentroids picks k starting centroids with k-means++: each next one is a
+// point chosen with probability proportional to its squared distance from the
+// nearest centroid so far.
+func seedCentroids(points [][]float64, k int, rng *rand.Rand) [][]float64 {
+	centroids := [][]float64{points[rng.Intn(len(points))]}
+	dist := make([]float64, len(points))
+	for len(centroids) < k {
+		total := 0.0
+		for i, p := range points {
+			nearest := math.Inf(1)
+			for _, c := range centroids {
+				// For unit vectors, squared Euclidean distance is 2 - 2cos
+				if d := 2 - 2*dot(p, c); d < nearest {
+					nearest = d
+				}
+			}
+			dist[i] = math.Max(nearest, 0)
+			total += dist[i]
+		}
+		next := rng.Intn(len(points))
+		if total > 0 {
+			target := rng.Float64() * total
+			for i, d := range dist {
+				target -= d
+				if target <= 0 {
+					next = i
+					break
+				}
+			}
+		}
+		centroids = append(centroids, append([]float64(nil), points[next]...))
+	}
+	return centroids
+}
+
+func normalize(v []float32) []float64 {
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = float64(x)
+	}
+	return normalize64(out)
+}
+
+func normalize64(v []float64) []float64 {
+	norm := math.Sqrt(dot(v, v))
+	if norm == 0 {
+		return v
+	}
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
+
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		if i < len(b) {
+			sum += a[i] * b[i]
+		}
+	}
+	return sum
+}
@@ -0,0 +1,67 @@
+package cluster
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestKMeansSeparatesTopics(t *testing.T) {
+	// Three well-separated directions with noise
+	rng := rand.New(rand.NewSource(1))
+	axes := [][]float32{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 1}}
+	var vectors [][]float32
+	var truth []int
+	for i := 0; i < 30; i++ {
+		c := i % 3
+		v := make([]float32, 4)
+		for d := range v {
+			v[d] = axes[c][d] + float32(rng.NormFloat64()*0.05)
+		}
+		vectors = append(vectors, v)
+		truth = append(truth, c)
+	}
+
+	res := KMeans(vectors, 3, 42)
+	label := map[int]int{} // true topic -> cluster
+	for i, c := range res.Assignment {
+		if l, ok := label[truth[i]]; ok && l != c {
+			t.Fatalf("vector %d of topic %d in cluster %d, expected %d", i, truth[i], c, l)
+		}
+		label[truth[i]] = c
+	}
+	if len(label) != 3 || label[0] == label[1] || label[1] == label[2] || label[0] == label[2] {
+		t.Errorf("expected three distinct clusters, got %v", label)
+	}
+
+	members := res.Members(label[0])
+	if len(members) != 10 {
+		t.Fatalf("expected 10 members, got %d", len(members))
+	}
+	for i := 1; i < len(members); i++ {
+		if res.Similarity[members[i]] > res.Similarity[members[i-1]] {
+			t.Error("expected members sorted by similarity to the centroid")
+		}
+	}
+
+	again := KMeans(vectors, 3, 42)
+	for i := range res.Assignment {
+		if again.Assignment[i] != res.Assignment[i] {
+			t.Fatal("expected the same seed to give the same clustering")
+		}
+	}
+}
+
+func TestKMeansSmallInputs(t *testing.T) {
+	if res := KMeans(nil, 3, 1); res.K != 0 || len(res.Assignment) != 0 {
+		t.Errorf("expected an empty result, got %+v", res)
+	}
+	if res := KMeans([][]float32{{1, 0}, {0, 1}}, 5, 1); res.K != 2 {
+		t.Errorf("expected k capped at the number of vectors, got %d", res.K)
+	}
+	if k := DefaultK(2000); k != 32 {
+		t.Errorf("DefaultK(2000) = %d, want 32", k)
+	}
+	if k := DefaultK(3); k != 2 {
+		t.Errorf("DefaultK(3) = %d, want 2", k)
+	}
+}
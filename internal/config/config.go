@@ -1,17 +1,28 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/viper"
+	"github.com/user/xhub/internal/prompts"
+	"github.com/user/xhub/internal/taxonomy"
+	"github.com/user/xhub/internal/urlnorm"
 )
 
 type Config struct {
-	DataDir    string          `mapstructure:"data_dir"`
-	LLM        LLMConfig       `mapstructure:"llm"`
+	DataDir    string           `mapstructure:"data_dir"`
+	LLM        LLMConfig        `mapstructure:"llm"`
 	Embeddings EmbeddingsConfig `mapstructure:"embeddings"`
-	Sources    SourcesConfig   `mapstructure:"sources"`
+	Sources    SourcesConfig    `mapstructure:"sources"`
+	Validation ValidationConfig `mapstructure:"validation"`
+	Taxonomy   TaxonomyConfig   `mapstructure:"taxonomy"`
+	Search     SearchConfig     `mapstructure:"search"`
+	URLs       URLsConfig       `mapstructure:"urls"`
+	Releases   ReleasesConfig   `mapstructure:"releases"`
+	Raindrop   RaindropConfig   `mapstructure:"raindrop"`
 }
 
 type LLMConfig struct {
@@ -21,18 +32,129 @@ type LLMConfig struct {
 	APIKey        string            `mapstructure:"api_key"`
 	Headers       map[string]string `mapstructure:"headers"`
 	SummaryPrompt string            `mapstructure:"summary_prompt"`
+	Prompts       map[string]string `mapstructure:"prompts"`      // Named text/template prompts
+	PromptRules   []PromptRule      `mapstructure:"prompt_rules"` // First match picks the prompt
+	Batch         bool              `mapstructure:"batch"`        // Use provider batch APIs during fetch
+}
+
+// PromptRule selects a named prompt by source and/or URL regular expression.
+type PromptRule struct {
+	Source     string `mapstructure:"source"`
+	URLPattern string `mapstructure:"url_pattern"`
+	Prompt     string `mapstructure:"prompt"`
+}
+
+// PromptSet parses the configured summary prompts and selection rules.
+func (c LLMConfig) PromptSet() (*prompts.Set, error) {
+	specs := make([]prompts.RuleSpec, len(c.PromptRules))
+	for i, r := range c.PromptRules {
+		// Viper lowercases map keys, so prompt names are matched case-insensitively
+		specs[i] = prompts.RuleSpec{Source: r.Source, URLPattern: r.URLPattern, Prompt: strings.ToLower(r.Prompt)}
+	}
+	return prompts.NewSet(c.SummaryPrompt, c.Prompts, specs)
 }
 
 type EmbeddingsConfig struct {
 	Provider string `mapstructure:"provider"`
 	Model    string `mapstructure:"model"`
+	BaseURL  string `mapstructure:"base_url"`
 	APIKey   string `mapstructure:"api_key"`
 }
 
+// ValidationConfig controls detection of junk scrapes and LLM refusals.
+type ValidationConfig struct {
+	Enabled       bool `mapstructure:"enabled"`
+	LLMClassifier bool `mapstructure:"llm_classifier"` // Ask the LLM about pages the heuristics let through
+}
+
+// TaxonomyConfig points at the controlled tag vocabulary.
+type TaxonomyConfig struct {
+	File string `mapstructure:"file"` // Defaults to taxonomy.yaml in the data dir
+}
+
+// SearchConfig controls query handling.
+type SearchConfig struct {
+	Understand   bool          `mapstructure:"understand"`    // Turn natural-language queries into filters via the LLM
+	ContentIndex bool          `mapstructure:"content_index"` // Full-text index scraped page content (grows the database)
+	Ranking      RankingConfig `mapstructure:"ranking"`
+	Rerank       RerankConfig  `mapstructure:"rerank"`
+}
+
+// RerankConfig enables a second ranking pass over the top search results.
+type RerankConfig struct {
+	Provider string `mapstructure:"provider"`  // "" (off), "api" (Cohere/Jina-compatible endpoint) or "llm"
+	URL      string `mapstructure:"url"`       // Rerank endpoint for provider api, e.g. https://api.jina.ai/v1/rerank
+	Model    string `mapstructure:"model"`     // Rerank model for provider api
+	APIKey   string `mapstructure:"api_key"`   // Or RERANK_API_KEY
+	TopN     int    `mapstructure:"top_n"`     // Candidates reranked, default 50
+	BudgetMS int    `mapstructure:"budget_ms"` // Wait this long before keeping the fused order, default 2000
+}
+
+// RankingConfig tunes how search results are scored. Unset values keep the defaults.
+type RankingConfig struct {
+	Fusion              string             `mapstructure:"fusion"`                 // rrf (default) or score
+	RRFK                float64            `mapstructure:"rrf_k"`                  // Default 60
+	Weights             map[string]float64 `mapstructure:"weights"`                // Signal (fts, content, vector) -> fusion weight
+	Fields              map[string]float64 `mapstructure:"fields"`                 // Column (title, summary, keywords, notes, url, annotations) -> BM25 weight
+	RecencyBoost        float64            `mapstructure:"recency_boost"`          // Extra score for brand-new bookmarks, e.g. 0.2
+	RecencyHalfLifeDays float64            `mapstructure:"recency_half_life_days"` // Default 180
+	Sources             map[string]float64 `mapstructure:"sources"`                // Source -> score multiplier
+}
+
+// URLsConfig adds per-domain rules to URL canonicalization.
+type URLsConfig struct {
+	Rules []URLRule `mapstructure:"rules"`
+}
+
+// URLRule changes how URLs of a domain and its subdomains are canonicalized.
+type URLRule struct {
+	Domain            string   `mapstructure:"domain"`
+	Host              string   `mapstructure:"host"`                // Replace the host, e.g. old.reddit.com -> reddit.com
+	StripParams       []string `mapstructure:"strip_params"`        // Extra query parameters to drop; "prefix*" allowed
+	KeepParams        []string `mapstructure:"keep_params"`         // Drop every parameter not listed
+	KeepFragment      bool     `mapstructure:"keep_fragment"`       // The #fragment selects the page
+	KeepTrailingSlash bool     `mapstructure:"keep_trailing_slash"` // The site treats /a and /a/ differently
+	HTTPS             bool     `mapstructure:"https"`               // Upgrade http URLs; the site is served over https
+}
+
+// Normalizer builds the URL canonicalizer from the configured rules.
+func (c URLsConfig) Normalizer() (*urlnorm.Normalizer, error) {
+	rules := make([]urlnorm.Rule, len(c.Rules))
+	for i, r := range c.Rules {
+		rules[i] = urlnorm.Rule{
+			Domain:            r.Domain,
+			Host:              r.Host,
+			StripParams:       r.StripParams,
+			KeepParams:        r.KeepParams,
+			KeepFragment:      r.KeepFragment,
+			KeepTrailingSlash: r.KeepTrailingSlash,
+			HTTPS:             r.HTTPS,
+		}
+	}
+	return urlnorm.New(rules)
+}
+
+// ReleasesConfig enables tracking releases of starred GitHub repositories.
+type ReleasesConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`   // Poll during fetch
+	Feed     string `mapstructure:"feed"`      // Atom feed file rewritten after each poll
+	MaxRepos int    `mapstructure:"max_repos"` // Repositories checked per poll
+	APIURL   string `mapstructure:"api_url"`
+	Token    string `mapstructure:"token"` // GITHUB_TOKEN, GH_TOKEN and gh's login are tried first
+}
+
+// RaindropConfig enables writing notes and tags edited in xhub back to Raindrop.
+type RaindropConfig struct {
+	WriteBack bool   `mapstructure:"write_back"` // Push edits during fetch
+	APIURL    string `mapstructure:"api_url"`
+	Token     string `mapstructure:"token"` // RAINDROP_TOKEN is tried first
+}
+
 type SourcesConfig struct {
-	X        bool `mapstructure:"x"`
-	Raindrop bool `mapstructure:"raindrop"`
-	GitHub   bool `mapstructure:"github"`
+	X                 bool `mapstructure:"x"`
+	Raindrop          bool `mapstructure:"raindrop"`
+	GitHub            bool `mapstructure:"github"`
+	GitHubRefreshDays int  `mapstructure:"github_refresh_days"` // Refetch repository metadata older than this; 0 disables
 }
 
 func Load() (*Config, error) {
@@ -51,6 +173,9 @@ func Load() (*Config, error) {
 	viper.SetDefault("sources.x", true)
 	viper.SetDefault("sources.raindrop", true)
 	viper.SetDefault("sources.github", true)
+	viper.SetDefault("sources.github_refresh_days", 7)
+	viper.SetDefault("releases.max_repos", 200)
+	viper.SetDefault("validation.enabled", true)
 
 	// Environment variable overrides
 	viper.SetEnvPrefix("XHUB")
@@ -73,6 +198,16 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	if _, err := cfg.LLM.PromptSet(); err != nil {
+		return nil, fmt.Errorf("invalid llm prompt config: %w", err)
+	}
+	if _, err := cfg.URLs.Normalizer(); err != nil {
+		return nil, fmt.Errorf("invalid urls config: %w", err)
+	}
+	if _, err := taxonomy.Load(cfg.TaxonomyPath()); err != nil {
+		return nil, fmt.Errorf("invalid taxonomy: %w", err)
+	}
+
 	// Ensure data directory exists
 	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
 		return nil, err
@@ -85,6 +220,14 @@ func (c *Config) DBPath() string {
 	return filepath.Join(c.DataDir, "xhub.db")
 }
 
+// TaxonomyPath returns the taxonomy file; tagging is unconstrained if it doesn't exist.
+func (c *Config) TaxonomyPath() string {
+	if c.Taxonomy.File != "" {
+		return c.Taxonomy.File
+	}
+	return filepath.Join(c.DataDir, "taxonomy.yaml")
+}
+
 func (c *Config) CacheDir() string {
 	return filepath.Join(c.DataDir, "cache")
 }
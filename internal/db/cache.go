@@ -0,0 +1,24 @@
+package db
+
+import "time"
+
+// GetCached returns a cached LLM result of the given kind, if present and newer
+// than maxAge (0 = no expiry).
+func (s *Store) GetCached(kind, key string, maxAge time.Duration) (string, bool) {
+	var value string
+	var createdAt time.Time
+	err := s.db.QueryRow(`SELECT value, created_at FROM llm_cache WHERE kind = ? AND key = ?`, kind, key).Scan(&value, &createdAt)
+	if err != nil {
+		return "", false
+	}
+	if maxAge > 0 && time.Since(createdAt) > maxAge {
+		return "", false
+	}
+	return value, true
+}
+
+// SetCached stores an LLM result so identical requests don't call the provider again.
+func (s *Store) SetCached(kind, key, value string) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO llm_cache (kind, key, value, created_at) VALUES (?, ?, ?, ?)`, kind, key, value, time.Now())
+	return err
+}
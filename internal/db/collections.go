@@ -0,0 +1,216 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Collection kinds
+const (
+	CollectionTopic    = "topic"    // Generated by clustering embeddings
+	CollectionRaindrop = "raindrop" // Imported from Raindrop collections
+)
+
+// Collection is a named group of bookmarks.
+type Collection struct {
+	ID          int64     `json:"id"`
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Count       int       `json:"count"` // Visible members
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CollectionMembers is a collection to store and its bookmarks, most
+// representative first.
+type CollectionMembers struct {
+	Collection
+	BookmarkIDs []string
+}
+
+func (s *Store) migrateCollections() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS collections (
+		id INTEGER PRIMARY KEY,
+		kind TEXT NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS collection_bookmarks (
+		collection_id INTEGER NOT NULL,
+		bookmark_id TEXT NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (collection_id, bookmark_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_collection_bookmarks_bookmark ON collection_bookmarks(bookmark_id);
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_collections_ad AFTER DELETE ON bookmarks BEGIN
+		DELETE FROM collection_bookmarks WHERE bookmark_id = old.id;
+	END;
+	`)
+	if err != nil {
+		return err
+	}
+	// The collection's ID at its source, for imported collections
+	return s.addColumnIfMissing("collections", "external_id", "TEXT NOT NULL DEFAULT ''")
+}
+
+// ReplaceCollections swaps every collection of a kind for the given ones, in a
+// single transaction so readers never see a partial set.
+func (s *Store) ReplaceCollections(kind string, collections []CollectionMembers) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM collection_bookmarks WHERE collection_id IN (SELECT id FROM collections WHERE kind = ?)`, kind); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM collections WHERE kind = ?`, kind); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, c := range collections {
+		res, err := tx.Exec(`INSERT INTO collections (kind, name, description, created_at) VALUES (?, ?, ?, ?)`, kind, c.Name, c.Description, now)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for pos, bookmarkID := range c.BookmarkIDs {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO collection_bookmarks (collection_id, bookmark_id, position) VALUES (?, ?, ?)`, id, bookmarkID, pos); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// UpsertCollection returns the collection of a kind imported from a source as
+// externalID, creating it or renaming it to name as needed.
+func (s *Store) UpsertCollection(kind, externalID, name string) (int64, error) {
+	var id int64
+	var current string
+	err := s.db.QueryRow(`SELECT id, name FROM collections WHERE kind = ? AND external_id = ?`, kind, externalID).Scan(&id, &current)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := s.db.Exec(`INSERT INTO collections (kind, name, external_id, created_at) VALUES (?, ?, ?, ?)`, kind, name, externalID, time.Now())
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	case err != nil:
+		return 0, err
+	case current != name:
+		_, err = s.db.Exec(`UPDATE collections SET name = ? WHERE id = ?`, name, id)
+	}
+	return id, err
+}
+
+// SetCollectionMembership puts a bookmark in one collection of a kind, taking
+// it out of the others; collectionID 0 takes it out of all of them.
+func (s *Store) SetCollectionMembership(kind, bookmarkID string, collectionID int64) error {
+	_, err := s.db.Exec(`DELETE FROM collection_bookmarks WHERE bookmark_id = ? AND collection_id != ? AND collection_id IN (SELECT id FROM collections WHERE kind = ?)`,
+		bookmarkID, collectionID, kind)
+	if err != nil || collectionID == 0 {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT OR IGNORE INTO collection_bookmarks (collection_id, bookmark_id, position) VALUES (?, ?, 0)`, collectionID, bookmarkID)
+	return err
+}
+
+// ListCollections returns the collections of a kind, or of every kind if kind
+// is empty, with their visible member counts, largest first.
+func (s *Store) ListCollections(kind string) ([]Collection, error) {
+	rows, err := s.db.Query(`
+		SELECT c.id, c.kind, c.name, c.description, c.created_at, COUNT(b.id) AS n
+		FROM collections c
+		LEFT JOIN collection_bookmarks cb ON cb.collection_id = c.id
+		LEFT JOIN bookmarks b ON b.id = cb.bookmark_id AND b.hidden = 0
+		WHERE c.kind = ? OR ? = ''
+		GROUP BY c.id
+		ORDER BY n DESC, c.name
+	`, kind, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collections []Collection
+	for rows.Next() {
+		var c Collection
+		if err := rows.Scan(&c.ID, &c.Kind, &c.Name, &c.Description, &c.CreatedAt, &c.Count); err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+// FindCollection looks up a collection of a kind (or of any kind, if empty) by
+// ID or by name (case-insensitive).
+func (s *Store) FindCollection(kind, idOrName string) (*Collection, error) {
+	collections, err := s.ListCollections(kind)
+	if err != nil {
+		return nil, err
+	}
+	id, idErr := strconv.ParseInt(idOrName, 10, 64)
+	for i, c := range collections {
+		if (idErr == nil && c.ID == id) || strings.EqualFold(c.Name, idOrName) {
+			return &collections[i], nil
+		}
+	}
+	if kind == "" {
+		kind = "collection"
+	}
+	return nil, fmt.Errorf("no %s %q", kind, idOrName)
+}
+
+// CollectionBookmarks returns a collection's visible bookmarks, most
+// representative first.
+func (s *Store) CollectionBookmarks(id int64, limit int) ([]Bookmark, error) {
+	rows, err := s.db.Query(`
+		SELECT `+listColumns+` FROM bookmarks
+		JOIN collection_bookmarks cb ON cb.bookmark_id = bookmarks.id
+		WHERE cb.collection_id = ? AND hidden = 0
+		ORDER BY cb.position, bookmarks.created_at DESC
+		LIMIT ?
+	`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanBookmarks(rows)
+}
+
+// VisibleEmbeddings returns the embeddings of visible bookmarks by ID.
+func (s *Store) VisibleEmbeddings() (map[string][]float32, error) {
+	rows, err := s.db.Query(`
+		SELECT v.id, v.embedding FROM bookmarks_vec v
+		JOIN bookmarks b ON b.id = v.id
+		WHERE b.hidden = 0
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]float32)
+	for rows.Next() {
+		var id string
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, err
+		}
+		result[id] = bytesToFloat32Slice(blob)
+	}
+	return result, rows.Err()
+}
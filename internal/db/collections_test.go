@@ -0,0 +1,69 @@
+package db
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCollections(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	var ids []string
+	for _, u := range []string{"https://a.dev", "https://b.dev", "https://c.dev", "https://d.dev"} {
+		b := &Bookmark{Source: "manual", URL: u, Title: u}
+		store.Upsert(b)
+		store.UpdateEmbedding(b.ID, []float32{1, 0})
+		ids = append(ids, b.ID)
+	}
+
+	topics := []CollectionMembers{
+		{Collection: Collection{Name: "Small", Description: "one"}, BookmarkIDs: ids[3:]},
+		{Collection: Collection{Name: "Big"}, BookmarkIDs: []string{ids[2], ids[0], ids[1]}},
+	}
+	if err := store.ReplaceCollections(CollectionTopic, topics); err != nil {
+		t.Fatalf("ReplaceCollections failed: %v", err)
+	}
+
+	list, err := store.ListCollections(CollectionTopic)
+	if err != nil || len(list) != 2 || list[0].Name != "Big" || list[0].Count != 3 {
+		t.Fatalf("expected Big (3) listed first, got %+v, %v", list, err)
+	}
+
+	big, err := store.FindCollection(CollectionTopic, "big")
+	if err != nil {
+		t.Fatalf("FindCollection by name failed: %v", err)
+	}
+	members, _ := store.CollectionBookmarks(big.ID, 10)
+	if len(members) != 3 || members[0].ID != ids[2] || members[1].ID != ids[0] {
+		t.Errorf("expected members in stored order, got %v", members)
+	}
+
+	// Hidden and deleted bookmarks drop out of counts and listings
+	hidden, _ := store.Get(ids[0])
+	hidden.Hidden = true
+	store.Update(hidden)
+	store.Delete(ids[1])
+	list, _ = store.ListCollections(CollectionTopic)
+	if list[0].Count != 1 || list[1].Count != 1 {
+		t.Errorf("expected both topics down to 1 visible member, got %+v", list)
+	}
+	if emb, _ := store.VisibleEmbeddings(); len(emb) != 2 {
+		t.Errorf("expected 2 visible embeddings, got %d", len(emb))
+	}
+
+	// Rebuilding replaces the previous topics
+	store.ReplaceCollections(CollectionTopic, topics[:1])
+	if list, _ := store.ListCollections(CollectionTopic); len(list) != 1 || list[0].Name != "Small" {
+		t.Errorf("expected only the new topics, got %+v", list)
+	}
+	if _, err := store.FindCollection(CollectionTopic, "Big"); err == nil {
+		t.Error("expected the old topic to be gone")
+	}
+}
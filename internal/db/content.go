@@ -0,0 +1,118 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// HighlightContent is the SearchResult.Highlights key for raw content snippets.
+const HighlightContent = "content"
+
+// SetContentIndex creates or drops the optional FTS index over scraped raw content.
+// It stores no text of its own (it reads bookmarks.raw_content) but its index
+// still grows the database roughly in proportion to the content.
+func (s *Store) SetContentIndex(enabled bool) error {
+	if enabled == s.contentFTS {
+		return nil
+	}
+	if !enabled {
+		_, err := s.db.Exec(`
+			DROP TRIGGER IF EXISTS bookmarks_content_ai;
+			DROP TRIGGER IF EXISTS bookmarks_content_ad;
+			DROP TRIGGER IF EXISTS bookmarks_content_au;
+			DROP TABLE IF EXISTS bookmarks_content_fts;
+		`)
+		if err == nil {
+			s.contentFTS = false
+		}
+		return err
+	}
+
+	_, err := s.db.Exec(`
+	CREATE VIRTUAL TABLE IF NOT EXISTS bookmarks_content_fts USING fts5(
+		raw_content,
+		content='bookmarks',
+		content_rowid='rowid'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_content_ai AFTER INSERT ON bookmarks BEGIN
+		INSERT INTO bookmarks_content_fts(rowid, raw_content) VALUES (new.rowid, new.raw_content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_content_ad AFTER DELETE ON bookmarks BEGIN
+		INSERT INTO bookmarks_content_fts(bookmarks_content_fts, rowid, raw_content) VALUES ('delete', old.rowid, old.raw_content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_content_au AFTER UPDATE OF raw_content ON bookmarks BEGIN
+		INSERT INTO bookmarks_content_fts(bookmarks_content_fts, rowid, raw_content) VALUES ('delete', old.rowid, old.raw_content);
+		INSERT INTO bookmarks_content_fts(rowid, raw_content) VALUES (new.rowid, new.raw_content);
+	END;
+
+	INSERT INTO bookmarks_content_fts(bookmarks_content_fts) VALUES ('rebuild');
+	`)
+	if err != nil {
+		return err
+	}
+	s.contentFTS = true
+	return nil
+}
+
+// ContentIndexed reports whether raw content is full-text indexed.
+func (s *Store) ContentIndexed() bool {
+	return s.contentFTS
+}
+
+func (s *Store) detectContentIndex() error {
+	var name string
+	err := s.db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'bookmarks_content_fts'`).Scan(&name)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	s.contentFTS = err == nil
+	return nil
+}
+
+// contentSearch ranks bookmarks by BM25 over raw content, with a snippet of the match.
+func (s *Store) contentSearch(q *Query, limit int) ([]scoredResult, error) {
+	if !s.contentFTS {
+		return nil, nil
+	}
+	where, filterArgs := q.filterSQL()
+	sqlQuery := `
+		SELECT b.id, bm25(bookmarks_content_fts) as score,
+			snippet(bookmarks_content_fts, 0, ?, ?, '…', 16)
+		FROM bookmarks_content_fts
+		JOIN bookmarks b ON bookmarks_content_fts.rowid = b.rowid
+		WHERE bookmarks_content_fts MATCH ?
+		AND b.hidden = 0` + where + `
+		ORDER BY score
+		LIMIT ?
+	`
+	args := []interface{}{HighlightOpen, HighlightClose, q.MatchExpr()}
+	args = append(args, filterArgs...)
+	args = append(args, limit)
+
+	rows, err := s.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []scoredResult
+	rank := 1
+	for rows.Next() {
+		var id string
+		var score float64
+		var snippet sql.NullString
+		if err := rows.Scan(&id, &score, &snippet); err != nil {
+			return nil, err
+		}
+		r := scoredResult{ID: id, Score: -score, Rank: rank}
+		if strings.Contains(snippet.String, HighlightOpen) {
+			r.Highlights = map[string]string{HighlightContent: snippet.String}
+		}
+		results = append(results, r)
+		rank++
+	}
+	return results, rows.Err()
+}
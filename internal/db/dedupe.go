@@ -0,0 +1,847 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/user/xhub/internal/taxonomy"
+)
+
+// Why bookmarks were found to be duplicates
+const (
+	DuplicateURL       = "url"       // Same canonical URL
+	DuplicateContent   = "content"   // Same scraped text
+	DuplicateEmbedding = "embedding" // Nearly identical embeddings
+)
+
+// minDedupeContent is the shortest scraped text compared by hash; shorter
+// text (error pages, "Loading...") is too generic to mean the same page.
+const minDedupeContent = 200
+
+// DuplicateGroup is a set of bookmarks that look like the same page.
+type DuplicateGroup struct {
+	Bookmarks []Bookmark `json:"bookmarks"` // The one suggested to keep first
+	Reasons   []string   `json:"reasons"`   // DuplicateURL, DuplicateContent, DuplicateEmbedding
+}
+
+// MergeRecord is an entry in the merge log.
+type MergeRecord struct {
+	ID         int64      `json:"id"`
+	KeptID     string     `json:"kept_id"`
+	MergedURLs []string   `json:"merged_urls"`
+	MergedAt   time.Time  `json:"merged_at"`
+	UndoneAt   *time.Time `json:"undone_at,omitempty"`
+}
+
+// mergeSnapshot is everything a merge changed, stored so it can be undone.
+type mergeSnapshot struct {
+	Kept        Bookmark             `json:"kept"`
+	Merged      []Bookmark           `json:"merged"`
+	Embeddings  map[string][]float32 `json:"embeddings,omitempty"`
+	URLs        []alternateURL       `json:"urls,omitempty"`        // bookmark_urls rows of all of them
+	Collections []collectionMember   `json:"collections,omitempty"` // Memberships of all of them
+	Starred     map[string]bool      `json:"starred,omitempty"`
+	Metadata    map[string][]dbRow   `json:"metadata"` // Table in metadataTables -> rows of all of them
+	Links       []bookmarkLink       `json:"links"`    // bookmark_links rows from or to any of them
+	Children    map[string]string    `json:"children"` // Linked page ID -> the one of them it was saved from
+}
+
+// metadataTables hold what sources know about a bookmark. A merge moves their
+// rows to the kept bookmark and undo moves them back.
+var metadataTables = []string{"tweet_metadata", "github_metadata", "raindrop_items", "releases", "annotations"}
+
+// dbRow is a table row by column name, with times stored as SQLite text.
+type dbRow map[string]interface{}
+
+type alternateURL struct {
+	URL        string `json:"url"`
+	BookmarkID string `json:"bookmark_id"`
+	Source     string `json:"source"`
+}
+
+type collectionMember struct {
+	CollectionID int64  `json:"collection_id"`
+	BookmarkID   string `json:"bookmark_id"`
+	Position     int    `json:"position"`
+}
+
+type bookmarkLink struct {
+	BookmarkID string `json:"bookmark_id"`
+	LinkedID   string `json:"linked_id"`
+	Position   int    `json:"position"`
+}
+
+func (s *Store) migrateDedupe() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS bookmark_urls (
+		url TEXT PRIMARY KEY,
+		bookmark_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_bookmark_urls_bookmark ON bookmark_urls(bookmark_id);
+
+	CREATE TABLE IF NOT EXISTS merge_log (
+		id INTEGER PRIMARY KEY,
+		kept_id TEXT NOT NULL,
+		merged_urls TEXT NOT NULL,
+		snapshot TEXT NOT NULL,
+		merged_at TIMESTAMP NOT NULL,
+		undone_at TIMESTAMP
+	);
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_urls_ad AFTER DELETE ON bookmarks BEGIN
+		DELETE FROM bookmark_urls WHERE bookmark_id = old.id;
+	END;
+	`)
+	return err
+}
+
+// aliasOf returns the bookmark any of urls was merged into, or "".
+func (s *Store) aliasOf(urls ...string) string {
+	args := make([]interface{}, len(urls))
+	for i, u := range urls {
+		args[i] = u
+	}
+	var id string
+	s.db.QueryRow(`SELECT bookmark_id FROM bookmark_urls WHERE url IN (`+placeholders(len(urls))+`) LIMIT 1`, args...).Scan(&id)
+	return id
+}
+
+// BookmarkSources returns every source a bookmark arrived from: its own and
+// those of the duplicates merged into it.
+func (s *Store) BookmarkSources(id string) ([]string, error) {
+	return s.queryStrings(`
+		SELECT source FROM bookmarks WHERE id = ?
+		UNION
+		SELECT source FROM bookmark_urls WHERE bookmark_id = ?
+	`, id, id)
+}
+
+// FindDuplicates groups visible bookmarks that share a canonical URL or
+// scraped text, or whose embeddings have at least minSimilarity cosine
+// similarity (0 skips the embedding comparison).
+func (s *Store) FindDuplicates(minSimilarity float64) ([]DuplicateGroup, error) {
+	rows, err := s.db.Query(`SELECT id, url, COALESCE(raw_content, '') FROM bookmarks WHERE hidden = 0 ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	index := make(map[string]int)
+	byURL := make(map[string]int)
+	byContent := make(map[[32]byte]int)
+
+	// Union-find over bookmark indexes, remembering why each pair was joined
+	var parent []int
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return parent[i]
+	}
+	type edge struct {
+		a      int
+		reason string
+	}
+	var edges []edge
+	union := func(a, b int, reason string) {
+		edges = append(edges, edge{a, reason})
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	for rows.Next() {
+		var id, url, content string
+		if err := rows.Scan(&id, &url, &content); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		i := len(ids)
+		ids = append(ids, id)
+		index[id] = i
+		parent = append(parent, i)
+
+		// http and https URLs of a page are duplicates too
+		key := s.urls.Key(url)
+		if j, ok := byURL[key]; ok {
+			union(j, i, DuplicateURL)
+		} else {
+			byURL[key] = i
+		}
+
+		normalized := strings.Join(strings.Fields(strings.ToLower(content)), " ")
+		if len(normalized) >= minDedupeContent {
+			hash := sha256.Sum256([]byte(normalized))
+			if j, ok := byContent[hash]; ok {
+				union(j, i, DuplicateContent)
+			} else {
+				byContent[hash] = i
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if minSimilarity > 0 {
+		embeddings, err := s.VisibleEmbeddings()
+		if err != nil {
+			return nil, err
+		}
+		var vecIdx []int
+		var vecs [][]float32
+		for id, emb := range embeddings {
+			if i, ok := index[id]; ok {
+				vecIdx = append(vecIdx, i)
+				vecs = append(vecs, unitVector(emb))
+			}
+		}
+		for a := range vecs {
+			for b := a + 1; b < len(vecs); b++ {
+				if float64(dotFloat32(vecs[a], vecs[b])) >= minSimilarity {
+					union(vecIdx[a], vecIdx[b], DuplicateEmbedding)
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	reasons := make(map[int]map[string]bool)
+	for i := range ids {
+		members[find(i)] = append(members[find(i)], i)
+	}
+	for _, e := range edges {
+		root := find(e.a)
+		if reasons[root] == nil {
+			reasons[root] = make(map[string]bool)
+		}
+		reasons[root][e.reason] = true
+	}
+
+	var groups []DuplicateGroup
+	for root, idxs := range members {
+		if len(idxs) < 2 {
+			continue
+		}
+		g := DuplicateGroup{}
+		for _, i := range idxs {
+			b, err := s.Get(ids[i])
+			if err != nil {
+				return nil, err
+			}
+			b.RawContent = ""
+			g.Bookmarks = append(g.Bookmarks, *b)
+		}
+		sortKeeperFirst(g.Bookmarks)
+		for _, r := range []string{DuplicateURL, DuplicateContent, DuplicateEmbedding} {
+			if reasons[root][r] {
+				g.Reasons = append(g.Reasons, r)
+			}
+		}
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Bookmarks) != len(groups[j].Bookmarks) {
+			return len(groups[i].Bookmarks) > len(groups[j].Bookmarks)
+		}
+		return groups[i].Bookmarks[0].ID < groups[j].Bookmarks[0].ID
+	})
+	return groups, nil
+}
+
+// sortKeeperFirst orders duplicates by keeperScore, then oldest first.
+func sortKeeperFirst(bookmarks []Bookmark) {
+	sort.SliceStable(bookmarks, func(i, j int) bool {
+		ki, kj := keeperScore(&bookmarks[i]), keeperScore(&bookmarks[j])
+		if ki != kj {
+			return ki > kj
+		}
+		return bookmarks[i].CreatedAt.Before(bookmarks[j].CreatedAt)
+	})
+}
+
+// keeperScore prefers keeping the bookmark with the most work in it: edits
+// by hand, notes, a summary, scraped content.
+func keeperScore(b *Bookmark) int {
+	score := 0
+	if b.HasUserEdits() {
+		score += 8
+	}
+	if b.Notes != "" {
+		score += 4
+	}
+	if b.Summary != "" {
+		score += 2
+	}
+	if b.ScrapeStatus == "success" {
+		score++
+	}
+	return score
+}
+
+func unitVector(v []float32) []float32 {
+	norm := float32(math.Sqrt(float64(dotFloat32(v, v))))
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+func dotFloat32(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		if i < len(b) {
+			sum += a[i] * b[i]
+		}
+	}
+	return sum
+}
+
+// MergeBookmarks merges duplicates into the bookmark keepID: notes are
+// concatenated, tags combined, empty fields filled in, and the duplicates'
+// URLs and sources recorded on the kept bookmark so fetching them again
+// doesn't bring them back. The duplicates are deleted. The merge is logged
+// with everything needed to undo it.
+func (s *Store) MergeBookmarks(keepID string, dupIDs []string) (*MergeRecord, error) {
+	return s.mergeBookmarks(keepID, dupIDs, "")
+}
+
+// mergeBookmarks is MergeBookmarks that also moves the kept bookmark to
+// keepURL, which may be the URL of one of the duplicates, unless it's "".
+func (s *Store) mergeBookmarks(keepID string, dupIDs []string, keepURL string) (*MergeRecord, error) {
+	kept, err := s.Get(keepID)
+	if err != nil {
+		return nil, fmt.Errorf("bookmark %s: %w", keepID, err)
+	}
+	snap := mergeSnapshot{Kept: *kept, Embeddings: make(map[string][]float32)}
+	for _, id := range dupIDs {
+		if id == keepID {
+			continue
+		}
+		b, err := s.Get(id)
+		if err != nil {
+			return nil, fmt.Errorf("bookmark %s: %w", id, err)
+		}
+		snap.Merged = append(snap.Merged, *b)
+	}
+	if len(snap.Merged) == 0 {
+		return nil, fmt.Errorf("nothing to merge into %s", keepID)
+	}
+
+	allIDs := []string{keepID}
+	for _, b := range snap.Merged {
+		allIDs = append(allIDs, b.ID)
+	}
+	if err := s.snapshotRelations(&snap, allIDs); err != nil {
+		return nil, err
+	}
+
+	merged := mergeFields(*kept, snap.Merged)
+	if keepURL != "" && keepURL != merged.URL {
+		if merged.OriginalURL == "" {
+			merged.OriginalURL = merged.URL
+		}
+		merged.URL = keepURL
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+	record := &MergeRecord{KeptID: keepID, MergedAt: time.Now()}
+	for _, b := range snap.Merged {
+		record.MergedURLs = append(record.MergedURLs, b.URL)
+	}
+	urls, _ := json.Marshal(record.MergedURLs)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, b := range snap.Merged {
+		if b.URL != merged.URL {
+			if _, err := tx.Exec(`INSERT OR REPLACE INTO bookmark_urls (url, bookmark_id, source) VALUES (?, ?, ?)`, b.URL, keepID, b.Source); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.Exec(`UPDATE bookmark_urls SET bookmark_id = ? WHERE bookmark_id = ?`, keepID, b.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO collection_bookmarks (collection_id, bookmark_id, position) SELECT collection_id, ?, position FROM collection_bookmarks WHERE bookmark_id = ?`, keepID, b.ID); err != nil {
+			return nil, err
+		}
+		// Links, linked pages and source metadata move to the kept bookmark
+		if _, err := tx.Exec(`UPDATE bookmarks SET parent_id = ? WHERE parent_id = ?`, keepID, b.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO bookmark_links (bookmark_id, linked_id, position) SELECT ?, linked_id, position FROM bookmark_links WHERE bookmark_id = ? AND linked_id != ?`, keepID, b.ID, keepID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO bookmark_links (bookmark_id, linked_id, position) SELECT bookmark_id, ?, position FROM bookmark_links WHERE linked_id = ? AND bookmark_id != ?`, keepID, b.ID, keepID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE OR IGNORE tweet_metadata SET bookmark_id = ? WHERE bookmark_id = ?`, keepID, b.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE OR IGNORE github_metadata SET bookmark_id = ? WHERE bookmark_id = ?`, keepID, b.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE OR IGNORE releases SET bookmark_id = ? WHERE bookmark_id = ?`, keepID, b.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE OR IGNORE raindrop_items SET bookmark_id = ? WHERE bookmark_id = ?`, keepID, b.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE annotations SET bookmark_id = ? WHERE bookmark_id = ?`, keepID, b.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE bookmarks SET starred = 1 WHERE id = ? AND EXISTS (SELECT 1 FROM bookmarks WHERE id = ? AND starred = 1)`, keepID, b.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM bookmarks_vec WHERE id = ?`, b.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM bookmarks WHERE id = ?`, b.ID); err != nil {
+			return nil, err
+		}
+	}
+	// After the deletes, which may free the URL the kept bookmark moves to
+	if err := updateBookmarkTx(tx, &merged); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(refreshAnnotations, keepID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM bookmark_urls WHERE url = ?`, merged.URL); err != nil {
+		return nil, err
+	}
+	res, err := tx.Exec(`INSERT INTO merge_log (kept_id, merged_urls, snapshot, merged_at) VALUES (?, ?, ?, ?)`, keepID, string(urls), string(data), record.MergedAt)
+	if err != nil {
+		return nil, err
+	}
+	if record.ID, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return record, s.syncTags(keepID)
+}
+
+// snapshotRelations records the embeddings, alternate URLs, collection
+// memberships, links and linked pages a merge of ids touches.
+func (s *Store) snapshotRelations(snap *mergeSnapshot, ids []string) error {
+	for _, b := range snap.Merged {
+		var blob []byte
+		err := s.db.QueryRow(`SELECT embedding FROM bookmarks_vec WHERE id = ?`, b.ID).Scan(&blob)
+		if err == nil {
+			snap.Embeddings[b.ID] = bytesToFloat32Slice(blob)
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.Query(`SELECT url, bookmark_id, source FROM bookmark_urls WHERE bookmark_id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var u alternateURL
+		if err := rows.Scan(&u.URL, &u.BookmarkID, &u.Source); err != nil {
+			rows.Close()
+			return err
+		}
+		snap.URLs = append(snap.URLs, u)
+	}
+	rows.Close()
+
+	rows, err = s.db.Query(`SELECT collection_id, bookmark_id, position FROM collection_bookmarks WHERE bookmark_id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var m collectionMember
+		if err := rows.Scan(&m.CollectionID, &m.BookmarkID, &m.Position); err != nil {
+			rows.Close()
+			return err
+		}
+		snap.Collections = append(snap.Collections, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Empty rather than nil, so undo can tell them from snapshots taken before
+	// links were recorded
+	snap.Links = []bookmarkLink{}
+	rows, err = s.db.Query(`SELECT bookmark_id, linked_id, position FROM bookmark_links WHERE bookmark_id IN (`+placeholders(len(ids))+`) OR linked_id IN (`+placeholders(len(ids))+`)`, append(args, args...)...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var l bookmarkLink
+		if err := rows.Scan(&l.BookmarkID, &l.LinkedID, &l.Position); err != nil {
+			rows.Close()
+			return err
+		}
+		snap.Links = append(snap.Links, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	snap.Children = make(map[string]string)
+	rows, err = s.db.Query(`SELECT id, parent_id FROM bookmarks WHERE parent_id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var child, parent string
+		if err := rows.Scan(&child, &parent); err != nil {
+			rows.Close()
+			return err
+		}
+		snap.Children[child] = parent
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	snap.Starred = make(map[string]bool)
+	for _, b := range append([]Bookmark{snap.Kept}, snap.Merged...) {
+		if b.Starred {
+			snap.Starred[b.ID] = true
+		}
+	}
+
+	snap.Metadata = make(map[string][]dbRow)
+	for _, table := range metadataTables {
+		rows, err := s.queryRows(`SELECT * FROM `+table+` WHERE bookmark_id IN (`+placeholders(len(ids))+`)`, args...)
+		if err != nil {
+			return err
+		}
+		snap.Metadata[table] = rows
+	}
+	return nil
+}
+
+// queryRows returns every row of a query by column name.
+func (s *Store) queryRows(query string, args ...interface{}) ([]dbRow, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []dbRow{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(dbRow, len(columns))
+		for i, c := range columns {
+			switch v := values[i].(type) {
+			case time.Time:
+				// As the driver writes times, so the row goes back unchanged
+				row[c] = v.Format(sqlite3.SQLiteTimestampFormats[0])
+			case []byte:
+				row[c] = string(v)
+			default:
+				row[c] = v
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// insertRow writes a row queryRows read back into table.
+func insertRow(tx *sql.Tx, table string, row dbRow) error {
+	columns := make([]string, 0, len(row))
+	for c := range row {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+	args := make([]interface{}, len(columns))
+	for i, c := range columns {
+		args[i] = row[c]
+	}
+	_, err := tx.Exec(`INSERT OR REPLACE INTO `+table+` (`+strings.Join(columns, ", ")+`) VALUES (`+placeholders(len(columns))+`)`, args...)
+	return err
+}
+
+// mergeFields combines duplicates into the kept bookmark.
+func mergeFields(kept Bookmark, dups []Bookmark) Bookmark {
+	merged := kept
+	merged.Provenance = make(Provenance, len(kept.Provenance))
+	for field, origin := range kept.Provenance {
+		merged.Provenance[field] = origin
+	}
+
+	var notes []string
+	if n := strings.TrimSpace(kept.Notes); n != "" {
+		notes = append(notes, n)
+	}
+	tags := taxonomy.Split(kept.Keywords)
+	seenTag := make(map[string]bool)
+	for _, t := range tags {
+		seenTag[taxonomy.Key(t)] = true
+	}
+
+	for _, d := range dups {
+		if n := strings.TrimSpace(d.Notes); n != "" && !containsString(notes, n) {
+			notes = append(notes, n)
+			if d.IsUserEdited(FieldNotes) {
+				merged.SetOrigin(FieldNotes, OriginUser)
+			}
+		}
+		for _, t := range taxonomy.Split(d.Keywords) {
+			if !seenTag[taxonomy.Key(t)] {
+				seenTag[taxonomy.Key(t)] = true
+				tags = append(tags, t)
+				if d.IsUserEdited(FieldKeywords) {
+					merged.SetOrigin(FieldKeywords, OriginUser)
+				}
+			}
+		}
+		if merged.Title == "" || merged.Title == merged.URL {
+			merged.Title = d.Title
+		}
+		if merged.Summary == "" && d.Summary != "" {
+			merged.Summary = d.Summary
+			merged.SetOrigin(FieldSummary, d.Provenance[FieldSummary])
+		}
+		if merged.RawContent == "" && d.RawContent != "" {
+			merged.RawContent = d.RawContent
+			merged.ScrapedAt = d.ScrapedAt
+			merged.ScrapeStatus = d.ScrapeStatus
+		}
+		if d.CreatedAt.Before(merged.CreatedAt) {
+			merged.CreatedAt = d.CreatedAt
+		}
+		merged.Hidden = merged.Hidden && d.Hidden
+	}
+	merged.Notes = strings.Join(notes, "\n\n")
+	merged.Keywords = strings.Join(tags, ", ")
+	return merged
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// updateBookmarkTx writes every stored field of a bookmark back within a transaction.
+func updateBookmarkTx(tx *sql.Tx, b *Bookmark) error {
+	var scrapedAt interface{}
+	if !b.ScrapedAt.IsZero() {
+		scrapedAt = b.ScrapedAt
+	}
+	_, err := tx.Exec(`UPDATE bookmarks SET url = ?, original_url = ?, title = ?, summary = ?, keywords = ?, notes = ?, raw_content = ?, created_at = ?, updated_at = ?, scraped_at = ?, scrape_status = ?, status_reason = ?, hidden = ?, provenance = ? WHERE id = ?`,
+		b.URL, b.OriginalURL, b.Title, b.Summary, b.Keywords, b.Notes, b.RawContent, b.CreatedAt, time.Now(), scrapedAt, b.ScrapeStatus, b.StatusReason, b.Hidden, encodeProvenance(b.Provenance), b.ID)
+	return err
+}
+
+// ListMerges returns the merge log, newest first.
+func (s *Store) ListMerges(limit int) ([]MergeRecord, error) {
+	rows, err := s.db.Query(`SELECT id, kept_id, merged_urls, merged_at, undone_at FROM merge_log ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []MergeRecord
+	for rows.Next() {
+		var r MergeRecord
+		var urls string
+		var undone sql.NullTime
+		if err := rows.Scan(&r.ID, &r.KeptID, &urls, &r.MergedAt, &undone); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(urls), &r.MergedURLs)
+		if undone.Valid {
+			r.UndoneAt = &undone.Time
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// UndoMerge restores the bookmarks a merge deleted, with their source
+// metadata, and the kept bookmark as it was before the merge. Edits made to
+// the kept bookmark since are lost. Later merges into the kept bookmark must be
+// undone first.
+func (s *Store) UndoMerge(id int64) (*MergeRecord, error) {
+	var data string
+	var undone sql.NullTime
+	record := &MergeRecord{ID: id}
+	err := s.db.QueryRow(`SELECT kept_id, snapshot, merged_at, undone_at FROM merge_log WHERE id = ?`, id).Scan(&record.KeptID, &data, &record.MergedAt, &undone)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no merge #%d", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if undone.Valid {
+		return nil, fmt.Errorf("merge #%d was already undone", id)
+	}
+	var later int64
+	err = s.db.QueryRow(`SELECT id FROM merge_log WHERE kept_id = ? AND id > ? AND undone_at IS NULL ORDER BY id DESC LIMIT 1`, record.KeptID, id).Scan(&later)
+	if err == nil {
+		return nil, fmt.Errorf("merge #%d into the same bookmark came later; undo it first", later)
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+	if _, err := s.Get(record.KeptID); err != nil {
+		return nil, fmt.Errorf("bookmark %s was merged into another or deleted since merge #%d", record.KeptID, id)
+	}
+	var snap mergeSnapshot
+	if err := json.Unmarshal([]byte(data), &snap); err != nil {
+		return nil, fmt.Errorf("merge #%d: %w", id, err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	kept := snap.Kept
+	if err := updateBookmarkTx(tx, &kept); err != nil {
+		return nil, err
+	}
+	// Snapshots from before starred was recorded keep it on the bookmark
+	if _, err := tx.Exec(`UPDATE bookmarks SET starred = ? WHERE id = ?`, snap.Starred[kept.ID] || kept.Starred, kept.ID); err != nil {
+		return nil, err
+	}
+	restored := []string{kept.ID}
+	for _, b := range snap.Merged {
+		record.MergedURLs = append(record.MergedURLs, b.URL)
+		restored = append(restored, b.ID)
+		var scrapedAt interface{}
+		if !b.ScrapedAt.IsZero() {
+			scrapedAt = b.ScrapedAt
+		}
+		// Snapshots from before starred was recorded keep it on the bookmark
+		starred := snap.Starred[b.ID] || b.Starred
+		_, err := tx.Exec(`INSERT INTO bookmarks (id, source, url, original_url, title, summary, keywords, notes, raw_content, created_at, updated_at, scraped_at, scrape_status, status_reason, hidden, provenance, parent_id, starred) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			b.ID, b.Source, b.URL, b.OriginalURL, b.Title, b.Summary, b.Keywords, b.Notes, b.RawContent, b.CreatedAt, b.UpdatedAt, scrapedAt, b.ScrapeStatus, b.StatusReason, b.Hidden, encodeProvenance(b.Provenance), b.ParentID, starred)
+		if err != nil {
+			return nil, fmt.Errorf("restoring %s: %w", b.URL, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM bookmark_urls WHERE url = ?`, b.URL); err != nil {
+			return nil, err
+		}
+		if emb, ok := snap.Embeddings[b.ID]; ok {
+			if _, err := tx.Exec(`INSERT OR REPLACE INTO bookmarks_vec (id, embedding) VALUES (?, ?)`, b.ID, float32SliceToBytes(emb)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Put alternate URLs and collection memberships back as they were
+	if _, err := tx.Exec(`DELETE FROM collection_bookmarks WHERE bookmark_id = ?`, kept.ID); err != nil {
+		return nil, err
+	}
+	for _, m := range snap.Collections {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO collection_bookmarks (collection_id, bookmark_id, position) SELECT ?, ?, ? WHERE EXISTS (SELECT 1 FROM collections WHERE id = ?)`, m.CollectionID, m.BookmarkID, m.Position, m.CollectionID); err != nil {
+			return nil, err
+		}
+	}
+	for _, u := range snap.URLs {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO bookmark_urls (url, bookmark_id, source) VALUES (?, ?, ?)`, u.URL, u.BookmarkID, u.Source); err != nil {
+			return nil, err
+		}
+	}
+
+	// Links and linked pages too, unless the merge was logged before they were
+	// recorded. Linked pages another bookmark took over since stay with it.
+	if snap.Links != nil {
+		if _, err := tx.Exec(`DELETE FROM bookmark_links WHERE bookmark_id = ? OR linked_id = ?`, kept.ID, kept.ID); err != nil {
+			return nil, err
+		}
+		for _, l := range snap.Links {
+			if _, err := tx.Exec(`INSERT OR REPLACE INTO bookmark_links (bookmark_id, linked_id, position) SELECT ?, ?, ? WHERE EXISTS (SELECT 1 FROM bookmarks WHERE id = ?) AND EXISTS (SELECT 1 FROM bookmarks WHERE id = ?)`, l.BookmarkID, l.LinkedID, l.Position, l.BookmarkID, l.LinkedID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for child, parent := range snap.Children {
+		if _, err := tx.Exec(`UPDATE bookmarks SET parent_id = ? WHERE id = ? AND parent_id = ?`, parent, child, kept.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Source metadata goes back to the bookmarks it came with. Merges logged
+	// before metadata was recorded leave it on the kept bookmark.
+	args := make([]interface{}, len(restored))
+	for i, id := range restored {
+		args[i] = id
+	}
+	for _, table := range metadataTables {
+		rows, ok := snap.Metadata[table]
+		if !ok {
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE bookmark_id IN (`+placeholders(len(restored))+`)`, args...); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if err := insertRow(tx, table, row); err != nil {
+				return nil, fmt.Errorf("restoring %s: %w", table, err)
+			}
+		}
+	}
+	for _, id := range restored {
+		if _, err := tx.Exec(refreshAnnotations, id); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE merge_log SET undone_at = ? WHERE id = ?`, now, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	record.UndoneAt = &now
+	return record, s.syncTags(restored...)
+}
@@ -0,0 +1,277 @@
+package db
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	article := strings.Repeat("A long article about building search engines. ", 10)
+	bookmarks := []*Bookmark{
+		insertLegacyBookmark(t, store, "x", "https://twitter.com/a/status/1?s=20", "Tweet"),
+		{Source: "raindrop", URL: "https://x.com/a/status/1", Title: "Saved tweet", Notes: "great thread"},
+		{Source: "raindrop", URL: "https://blog.dev/search/?utm_source=rss", Title: "Search", RawContent: article},
+		{Source: "manual", URL: "https://mirror.dev/search-engines", Title: "Mirror", RawContent: "  " + strings.ToUpper(article)},
+		{Source: "github", URL: "https://github.com/a/one", Title: "One"},
+		{Source: "github", URL: "https://github.com/a/two", Title: "Two"},
+		{Source: "manual", URL: "https://unrelated.dev", Title: "Unrelated"},
+	}
+	for _, b := range bookmarks[1:] {
+		store.Upsert(b)
+	}
+	store.UpdateEmbedding(bookmarks[4].ID, []float32{1, 0, 0.01})
+	store.UpdateEmbedding(bookmarks[5].ID, []float32{1, 0, 0})
+	store.UpdateEmbedding(bookmarks[6].ID, []float32{0, 1, 0})
+
+	groups, err := store.FindDuplicates(0.99)
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %+v", groups)
+	}
+	byReason := map[string]DuplicateGroup{}
+	for _, g := range groups {
+		if len(g.Reasons) != 1 || len(g.Bookmarks) != 2 {
+			t.Fatalf("expected pairs with one reason each, got %+v", g)
+		}
+		byReason[g.Reasons[0]] = g
+	}
+	if g := byReason[DuplicateURL]; g.Bookmarks[0].ID != bookmarks[1].ID {
+		t.Errorf("expected the bookmark with notes suggested as keeper, got %q", g.Bookmarks[0].Title)
+	}
+	if _, ok := byReason[DuplicateContent]; !ok {
+		t.Error("expected a group for identical content")
+	}
+	if _, ok := byReason[DuplicateEmbedding]; !ok {
+		t.Error("expected a group for near-identical embeddings")
+	}
+
+	if groups, _ := store.FindDuplicates(0); len(groups) != 2 {
+		t.Errorf("expected no embedding groups with similarity 0, got %d groups", len(groups))
+	}
+}
+
+func TestMergeAndUndo(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	keep := &Bookmark{Source: "raindrop", URL: "https://blog.dev/post", Title: "Post", Keywords: "go, search", Notes: "read later"}
+	dup := &Bookmark{Source: "x", URL: "https://x.com/a/status/1", Title: "Tweet", Summary: "A post", Keywords: "Search, bm25", Notes: "from a thread"}
+	store.Upsert(keep)
+	store.Upsert(dup)
+	store.UpdateEmbedding(dup.ID, []float32{1, 2})
+	store.ReplaceCollections(CollectionTopic, []CollectionMembers{{Collection: Collection{Name: "Search"}, BookmarkIDs: []string{dup.ID}}})
+	store.SetStarred(keep.ID, true)
+
+	record, err := store.MergeBookmarks(keep.ID, []string{dup.ID})
+	if err != nil {
+		t.Fatalf("MergeBookmarks failed: %v", err)
+	}
+	// As logged before snapshots recorded starred
+	store.db.Exec(`UPDATE merge_log SET snapshot = json_remove(snapshot, '$.starred') WHERE id = ?`, record.ID)
+
+	merged, _ := store.Get(keep.ID)
+	if merged.Notes != "read later\n\nfrom a thread" || merged.Keywords != "go, search, bm25" || merged.Summary != "A post" {
+		t.Errorf("unexpected merged fields: notes %q, keywords %q, summary %q", merged.Notes, merged.Keywords, merged.Summary)
+	}
+	if _, err := store.Get(dup.ID); err == nil {
+		t.Error("expected the duplicate to be deleted")
+	}
+	if sources, _ := store.BookmarkSources(keep.ID); !slices.Contains(sources, "x") || !slices.Contains(sources, "raindrop") {
+		t.Errorf("expected both sources, got %v", sources)
+	}
+	if results, _ := store.Search("source:x", 10); len(results) != 1 || results[0].ID != keep.ID {
+		t.Errorf("expected source:x to find the merged bookmark, got %d results", len(results))
+	}
+	if topics, _ := store.ListCollections(CollectionTopic); topics[0].Count != 1 {
+		t.Error("expected the kept bookmark to take the duplicate's place in its topic")
+	}
+
+	// Fetching the duplicate's URL again doesn't bring it back
+	again := &Bookmark{Source: "x", URL: dup.URL, Title: "Tweet"}
+	if isNew, err := store.UpsertReturningNew(again); err != nil || isNew || again.ID != keep.ID {
+		t.Errorf("expected the merged URL to map to the kept bookmark, got new=%v id=%s err=%v", isNew, again.ID, err)
+	}
+	if b, err := store.GetByURL(dup.URL); err != nil || b.ID != keep.ID {
+		t.Errorf("expected GetByURL to find the kept bookmark, got %v", err)
+	}
+
+	if _, err := store.UndoMerge(record.ID); err != nil {
+		t.Fatalf("UndoMerge failed: %v", err)
+	}
+	restored, err := store.Get(dup.ID)
+	if err != nil || restored.Notes != "from a thread" {
+		t.Fatalf("expected the duplicate restored, got %v", err)
+	}
+	if kept, _ := store.Get(keep.ID); kept.Notes != "read later" || kept.Keywords != "go, search" || !kept.Starred {
+		t.Errorf("expected the kept bookmark as before the merge, got %q / %q (starred: %v)", kept.Notes, kept.Keywords, kept.Starred)
+	}
+	if emb, _ := store.VisibleEmbeddings(); len(emb[dup.ID]) != 2 {
+		t.Error("expected the duplicate's embedding restored")
+	}
+	if b, _ := store.GetByURL(dup.URL); b.ID != dup.ID {
+		t.Error("expected the duplicate's URL to be its own again")
+	}
+	if results, _ := store.Search("tag:bm25", 10); len(results) != 1 || results[0].ID != dup.ID {
+		t.Errorf("expected tags resynced after undo, got %d results", len(results))
+	}
+	if topics, _ := store.ListCollections(CollectionTopic); topics[0].Count != 1 {
+		t.Error("expected topic membership restored")
+	} else if members, _ := store.CollectionBookmarks(topics[0].ID, 10); members[0].ID != dup.ID {
+		t.Error("expected the duplicate back in its topic")
+	}
+	if _, err := store.UndoMerge(record.ID); err == nil {
+		t.Error("expected undoing twice to fail")
+	}
+	if merges, _ := store.ListMerges(10); len(merges) != 1 || merges[0].UndoneAt == nil {
+		t.Errorf("expected one undone merge in the log, got %+v", merges)
+	}
+}
+
+func TestUndoMergeRestoresMetadata(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	synced := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	keep := &Bookmark{Source: "manual", URL: "https://blog.dev/post", Title: "Post", Notes: "mine"}
+	dup := &Bookmark{Source: "raindrop", URL: "https://blog.dev/amp/post", Title: "Post", Notes: "from raindrop", Raindrop: &RaindropMetadata{
+		RaindropID: 9, Important: true, LastUpdate: synced, Note: "from raindrop",
+		Highlights: []Annotation{{ExternalID: "h1", Text: "a highlighted passage"}},
+	}}
+	other := &Bookmark{Source: "x", URL: "https://x.com/a/status/2", Title: "Tweet", Notes: "from a thread"}
+	store.Upsert(keep)
+	store.Upsert(dup)
+	store.Upsert(other)
+
+	first, err := store.MergeBookmarks(keep.ID, []string{dup.ID})
+	if err != nil {
+		t.Fatalf("MergeBookmarks failed: %v", err)
+	}
+	if meta, _ := store.GetRaindropMetadata(keep.ID); meta == nil || meta.RaindropID != 9 {
+		t.Fatalf("expected the raindrop moved to the kept bookmark, got %+v", meta)
+	}
+	second, err := store.MergeBookmarks(keep.ID, []string{other.ID})
+	if err != nil {
+		t.Fatalf("MergeBookmarks failed: %v", err)
+	}
+
+	// Undoing the first merge would drop what the second brought in
+	if _, err := store.UndoMerge(first.ID); err == nil || !strings.Contains(err.Error(), "undo it first") {
+		t.Errorf("expected the earlier merge refused while a later one stands, got %v", err)
+	}
+	if _, err := store.UndoMerge(second.ID); err != nil {
+		t.Fatalf("UndoMerge failed: %v", err)
+	}
+	if _, err := store.UndoMerge(first.ID); err != nil {
+		t.Fatalf("UndoMerge failed: %v", err)
+	}
+
+	restored, err := store.Get(dup.ID)
+	if err != nil || !restored.Starred {
+		t.Fatalf("expected the starred raindrop restored, got %+v (%v)", restored, err)
+	}
+	meta, err := store.GetRaindropMetadata(dup.ID)
+	if err != nil || meta == nil || meta.RaindropID != 9 || !meta.LastUpdate.Equal(synced) {
+		t.Fatalf("expected the raindrop metadata restored, got %+v (%v)", meta, err)
+	}
+	if annotations, _ := store.Annotations(dup.ID); len(annotations) != 1 || annotations[0].Text != "a highlighted passage" {
+		t.Errorf("expected the highlight restored, got %+v", annotations)
+	}
+	if kept, _ := store.Get(keep.ID); kept.Starred || kept.Notes != "mine" {
+		t.Errorf("expected the kept bookmark as before the merges, got %+v", kept)
+	}
+	if meta, _ := store.GetRaindropMetadata(keep.ID); meta != nil {
+		t.Errorf("expected no raindrop left on the kept bookmark, got %+v", meta)
+	}
+	if results, _ := store.Search("highlighted", 10); len(results) != 1 || results[0].ID != dup.ID {
+		t.Errorf("expected the highlight indexed on the restored bookmark, got %d results", len(results))
+	}
+	if edits, _ := store.RaindropEdits(-1); len(edits) != 0 {
+		t.Errorf("expected the restored raindrop in step with Raindrop, got %+v", edits)
+	}
+}
+
+func TestUndoMergeRestoresLinks(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	keep := &Bookmark{Source: "x", URL: "https://x.com/a/status/1", Title: "Tweet"}
+	dup := &Bookmark{Source: "x", URL: "https://x.com/a/status/2", Title: "Tweet again"}
+	store.Upsert(keep)
+	store.Upsert(dup)
+	page := &Bookmark{Source: "x", URL: "https://blog.dev/post", Title: "Post", ParentID: dup.ID}
+	quote := &Bookmark{Source: "x", URL: "https://x.com/b/status/3", Title: "Quote"}
+	store.Upsert(page)
+	store.Upsert(quote)
+	store.SetLinks(dup.ID, []string{page.ID})
+	store.SetLinks(quote.ID, []string{dup.ID})
+
+	record, err := store.MergeBookmarks(keep.ID, []string{dup.ID})
+	if err != nil {
+		t.Fatalf("MergeBookmarks failed: %v", err)
+	}
+	if b, _ := store.Get(page.ID); b.ParentID != keep.ID {
+		t.Errorf("expected the linked page moved to the kept bookmark, got parent %q", b.ParentID)
+	}
+	if pages, _ := store.LinkedPages(keep.ID); len(pages) != 1 || pages[0].ID != page.ID {
+		t.Errorf("expected the kept bookmark to link to the page, got %+v", pages)
+	}
+
+	if _, err := store.UndoMerge(record.ID); err != nil {
+		t.Fatalf("UndoMerge failed: %v", err)
+	}
+	if b, _ := store.Get(page.ID); b.ParentID != dup.ID {
+		t.Errorf("expected the linked page back with the restored bookmark, got parent %q", b.ParentID)
+	}
+	if pages, _ := store.LinkedPages(dup.ID); len(pages) != 1 || pages[0].ID != page.ID {
+		t.Errorf("expected the restored bookmark's links back, got %+v", pages)
+	}
+	if pages, _ := store.LinkedPages(keep.ID); len(pages) != 0 {
+		t.Errorf("expected no links left on the kept bookmark, got %+v", pages)
+	}
+	if from, _ := store.LinkedFrom([]string{dup.ID, keep.ID}); len(from[dup.ID]) != 1 || len(from[keep.ID]) != 0 {
+		t.Errorf("expected the quote to link to the restored bookmark again, got %+v", from)
+	}
+}
+
+// insertLegacyBookmark stores a bookmark under url as given, the way it was
+// saved before URLs were canonicalized on the way in.
+func insertLegacyBookmark(t *testing.T, store *Store, source, url, title string) *Bookmark {
+	t.Helper()
+	b := &Bookmark{ID: generateID(url), Source: source, URL: url, Title: title}
+	if _, err := store.db.Exec(`INSERT INTO bookmarks (id, source, url, title) VALUES (?, ?, ?, ?)`, b.ID, b.Source, b.URL, b.Title); err != nil {
+		t.Fatalf("insert %s: %v", url, err)
+	}
+	return b
+}
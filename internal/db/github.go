@@ -0,0 +1,212 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AbandonedAfter is how long a repository can go without a push before it
+// counts as abandoned.
+const AbandonedAfter = 365 * 24 * time.Hour
+
+// RepoMetadata is what the GitHub source knows about a starred repository.
+type RepoMetadata struct {
+	BookmarkID    string    `json:"-"`
+	FullName      string    `json:"full_name"`
+	Language      string    `json:"language,omitempty"`
+	Topics        []string  `json:"topics,omitempty"`
+	Stars         int       `json:"stars"`
+	License       string    `json:"license,omitempty"` // SPDX ID, e.g. MIT
+	Archived      bool      `json:"archived"`
+	PushedAt      time.Time `json:"pushed_at,omitempty"`
+	ReadmeExcerpt string    `json:"readme_excerpt,omitempty"`
+	CheckedAt     time.Time `json:"checked_at,omitempty"` // Last fetched from the repository itself; zero if only seen in the star list
+}
+
+// Abandoned reports whether the repository hasn't been pushed to in AbandonedAfter.
+func (r *RepoMetadata) Abandoned() bool {
+	return !r.PushedAt.IsZero() && time.Since(r.PushedAt) > AbandonedAfter
+}
+
+// String summarizes the repository on one line, e.g.
+// "Go · ★ 1.2k · MIT · pushed 2024-05-01 · archived".
+func (r *RepoMetadata) String() string {
+	var parts []string
+	if r.Language != "" {
+		parts = append(parts, r.Language)
+	}
+	parts = append(parts, "★ "+compactCount(r.Stars))
+	if r.License != "" {
+		parts = append(parts, r.License)
+	}
+	if !r.PushedAt.IsZero() {
+		parts = append(parts, "pushed "+r.PushedAt.Format("2006-01-02"))
+	}
+	switch {
+	case r.Archived:
+		parts = append(parts, "archived")
+	case r.Abandoned():
+		parts = append(parts, "abandoned")
+	}
+	return strings.Join(parts, " · ")
+}
+
+func compactCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	}
+	return fmt.Sprint(n)
+}
+
+func (s *Store) migrateGitHub() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS github_metadata (
+		bookmark_id TEXT PRIMARY KEY,
+		full_name TEXT NOT NULL,
+		language TEXT DEFAULT '' COLLATE NOCASE,
+		topics TEXT DEFAULT '[]',
+		stars INTEGER DEFAULT 0,
+		license TEXT DEFAULT '',
+		archived INTEGER DEFAULT 0,
+		pushed_at TIMESTAMP,
+		readme_excerpt TEXT DEFAULT '',
+		checked_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_github_metadata_language ON github_metadata(language);
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_github_ad AFTER DELETE ON bookmarks BEGIN
+		DELETE FROM github_metadata WHERE bookmark_id = old.id;
+	END;
+	`)
+	return err
+}
+
+// SetRepoMetadata stores what the GitHub source knows about the repository
+// bookmarked as id. Metadata from the star list (zero CheckedAt) keeps the
+// README excerpt and check time of the last full fetch.
+func (s *Store) SetRepoMetadata(id string, r *RepoMetadata) error {
+	topics, err := json.Marshal(r.Topics)
+	if err != nil {
+		return err
+	}
+	var pushedAt, checkedAt interface{}
+	if !r.PushedAt.IsZero() {
+		pushedAt = r.PushedAt
+	}
+	if !r.CheckedAt.IsZero() {
+		checkedAt = r.CheckedAt
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO github_metadata (bookmark_id, full_name, language, topics, stars, license, archived, pushed_at, readme_excerpt, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(bookmark_id) DO UPDATE SET
+			full_name = excluded.full_name,
+			language = excluded.language,
+			topics = excluded.topics,
+			stars = excluded.stars,
+			license = excluded.license,
+			archived = excluded.archived,
+			pushed_at = COALESCE(excluded.pushed_at, github_metadata.pushed_at),
+			readme_excerpt = CASE WHEN excluded.checked_at IS NULL THEN github_metadata.readme_excerpt ELSE excluded.readme_excerpt END,
+			checked_at = COALESCE(excluded.checked_at, github_metadata.checked_at)`,
+		id, r.FullName, r.Language, string(topics), r.Stars, r.License, r.Archived, pushedAt, r.ReadmeExcerpt, checkedAt)
+	return err
+}
+
+const repoColumns = `bookmark_id, full_name, language, topics, stars, license, archived, pushed_at, readme_excerpt, checked_at`
+
+func scanRepo(row interface{ Scan(...interface{}) error }) (*RepoMetadata, error) {
+	var r RepoMetadata
+	var topics string
+	var pushedAt, checkedAt sql.NullTime
+	if err := row.Scan(&r.BookmarkID, &r.FullName, &r.Language, &topics, &r.Stars, &r.License, &r.Archived, &pushedAt, &r.ReadmeExcerpt, &checkedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(topics), &r.Topics)
+	r.PushedAt = pushedAt.Time
+	r.CheckedAt = checkedAt.Time
+	return &r, nil
+}
+
+// GetRepoMetadata returns the repository metadata of bookmark id, or nil if there is none.
+func (s *Store) GetRepoMetadata(id string) (*RepoMetadata, error) {
+	r, err := scanRepo(s.db.QueryRow(`SELECT `+repoColumns+` FROM github_metadata WHERE bookmark_id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+// StaleRepos returns up to limit GitHub bookmarks whose repository wasn't
+// fetched since before, least recently fetched first. Bookmarks without
+// metadata come first, with only BookmarkID and FullName set.
+func (s *Store) StaleRepos(before time.Time, limit int) ([]RepoMetadata, error) {
+	rows, err := s.db.Query(`
+		SELECT b.id, b.url, g.bookmark_id IS NOT NULL FROM bookmarks b
+		LEFT JOIN github_metadata g ON g.bookmark_id = b.id
+		WHERE b.source = 'github' AND (g.checked_at IS NULL OR g.checked_at < ?)
+		ORDER BY g.bookmark_id IS NOT NULL, g.checked_at
+		LIMIT ?`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	type stale struct {
+		id, url string
+		known   bool
+	}
+	var found []stale
+	for rows.Next() {
+		var st stale
+		if err := rows.Scan(&st.id, &st.url, &st.known); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		found = append(found, st)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	repos := make([]RepoMetadata, 0, len(found))
+	for _, st := range found {
+		if st.known {
+			r, err := s.GetRepoMetadata(st.id)
+			if err != nil {
+				return nil, err
+			}
+			repos = append(repos, *r)
+			continue
+		}
+		name, ok := repoFullName(st.url)
+		if !ok {
+			continue
+		}
+		repos = append(repos, RepoMetadata{BookmarkID: st.id, FullName: name})
+	}
+	return repos, nil
+}
+
+// repoFullName returns "owner/name" from a github.com repository URL.
+func repoFullName(url string) (string, bool) {
+	path := url
+	for _, prefix := range []string{"https://", "http://", "www."} {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	path, ok := strings.CutPrefix(path, "github.com/")
+	if !ok {
+		return "", false
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0] + "/" + parts[1], true
+}
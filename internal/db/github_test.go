@@ -0,0 +1,89 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRepoMetadata(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	pushed := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	tea := &Bookmark{Source: "github", URL: "https://github.com/charmbracelet/bubbletea", Title: "charmbracelet/bubbletea", Repo: &RepoMetadata{
+		FullName: "charmbracelet/bubbletea", Language: "Go", Topics: []string{"tui", "elm"}, Stars: 27500, License: "MIT", PushedAt: time.Now(),
+	}}
+	old := &Bookmark{Source: "github", URL: "https://github.com/a/oldlib", Title: "a/oldlib", Repo: &RepoMetadata{
+		FullName: "a/oldlib", Language: "Rust", Stars: 12, Archived: true, PushedAt: pushed,
+	}}
+	legacy := &Bookmark{Source: "github", URL: "https://github.com/b/legacy", Title: "b/legacy"}
+	for _, b := range []*Bookmark{tea, old, legacy} {
+		if err := store.Upsert(b); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+
+	// A full fetch keeps its README excerpt through later star list updates
+	full := *old.Repo
+	full.ReadmeExcerpt = "An old library"
+	full.CheckedAt = time.Now()
+	if err := store.SetRepoMetadata(old.ID, &full); err != nil {
+		t.Fatalf("SetRepoMetadata failed: %v", err)
+	}
+	old.Repo.Stars = 13
+	store.Upsert(old)
+	meta, err := store.GetRepoMetadata(old.ID)
+	if err != nil || meta == nil {
+		t.Fatalf("GetRepoMetadata failed: %v", err)
+	}
+	if meta.Stars != 13 || meta.ReadmeExcerpt != "An old library" || meta.CheckedAt.IsZero() || !meta.PushedAt.Equal(pushed) {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+	if got := meta.String(); got != "Rust · ★ 13 · pushed 2020-03-01 · archived" {
+		t.Errorf("String = %q", got)
+	}
+
+	stale, err := store.StaleRepos(time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("StaleRepos failed: %v", err)
+	}
+	if len(stale) != 2 || stale[0].BookmarkID != legacy.ID || stale[0].FullName != "b/legacy" || stale[1].BookmarkID != tea.ID {
+		t.Errorf("expected the unfetched repositories, legacy first, got %+v", stale)
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"lang:go", []string{tea.ID}},
+		{"-lang:GO source:github", []string{old.ID, legacy.ID}},
+		{"archived:true", []string{old.ID}},
+		{"-archived:true", []string{tea.ID}},
+	}
+	for _, tc := range cases {
+		results, err := store.Search(tc.query, 10)
+		if err != nil {
+			t.Fatalf("Search %q failed: %v", tc.query, err)
+		}
+		got := make(map[string]bool)
+		for _, r := range results {
+			got[r.ID] = true
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%q: got %v, want %v", tc.query, got, tc.want)
+			continue
+		}
+		for _, id := range tc.want {
+			if !got[id] {
+				t.Errorf("%q: missing %s in %v", tc.query, id, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+// LinkedFrom is a bookmark, usually a tweet, that links to another bookmark.
+type LinkedFrom struct {
+	ID        string    `json:"id"`
+	Source    string    `json:"source"`
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Author is the "@handle" of a linking tweet, or the site of another bookmark.
+func (l LinkedFrom) Author() string {
+	site := siteKey(l.URL)
+	if account, ok := strings.CutPrefix(site, "x.com/"); ok {
+		return "@" + account
+	}
+	return site
+}
+
+// String describes the linking bookmark, e.g. "@handle: tweet text".
+func (l LinkedFrom) String() string {
+	return l.Author() + ": " + strings.Join(strings.Fields(l.Title), " ")
+}
+
+func (s *Store) migrateLinks() error {
+	if err := s.addColumnIfMissing("bookmarks", "parent_id", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`
+	CREATE INDEX IF NOT EXISTS idx_bookmarks_parent ON bookmarks(parent_id);
+
+	CREATE TABLE IF NOT EXISTS bookmark_links (
+		bookmark_id TEXT NOT NULL,
+		linked_id TEXT NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (bookmark_id, linked_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_bookmark_links_linked ON bookmark_links(linked_id);
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_links_ad AFTER DELETE ON bookmarks BEGIN
+		DELETE FROM bookmark_links WHERE bookmark_id = old.id OR linked_id = old.id;
+	END;
+	`)
+	return err
+}
+
+// SetLinks records the bookmarks that the bookmark id links to, in order,
+// replacing the links recorded before.
+func (s *Store) SetLinks(id string, linkedIDs []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM bookmark_links WHERE bookmark_id = ?`, id); err != nil {
+		return err
+	}
+	for i, linked := range linkedIDs {
+		if linked == id {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO bookmark_links (bookmark_id, linked_id, position) VALUES (?, ?, ?)`, id, linked, i); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LinkedPages returns the bookmarks that the bookmark id links to, in order.
+func (s *Store) LinkedPages(id string) ([]Bookmark, error) {
+	rows, err := s.db.Query(`
+		SELECT `+listColumns+` FROM bookmarks
+		JOIN bookmark_links l ON l.linked_id = bookmarks.id
+		WHERE l.bookmark_id = ?
+		ORDER BY l.position
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return scanBookmarks(rows)
+}
+
+// LinkedFrom returns, for each of ids, the visible bookmarks linking to it,
+// oldest first.
+func (s *Store) LinkedFrom(ids []string) (map[string][]LinkedFrom, error) {
+	linked := make(map[string][]LinkedFrom)
+	if len(ids) == 0 {
+		return linked, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.Query(`
+		SELECT l.linked_id, b.id, b.source, b.url, b.title, b.created_at
+		FROM bookmark_links l
+		JOIN bookmarks b ON b.id = l.bookmark_id
+		WHERE l.linked_id IN (`+placeholders(len(ids))+`) AND b.hidden = 0
+		ORDER BY b.created_at
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var from LinkedFrom
+		if err := rows.Scan(&id, &from.ID, &from.Source, &from.URL, &from.Title, &from.CreatedAt); err != nil {
+			return nil, err
+		}
+		linked[id] = append(linked[id], from)
+	}
+	return linked, rows.Err()
+}
+
+// attachLinkedFrom fills in the bookmarks linking to each result.
+func (s *Store) attachLinkedFrom(results []SearchResult) {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	linked, err := s.LinkedFrom(ids)
+	if err != nil {
+		return
+	}
+	for i := range results {
+		results[i].LinkedFrom = linked[results[i].ID]
+	}
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLinksAndLinkedFrom(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	tweet := &Bookmark{Source: "x", URL: "https://x.com/Karpathy/status/1", Title: "Great  write-up\non tokenizers"}
+	store.Upsert(tweet)
+	article := &Bookmark{Source: "x", URL: "https://blog.dev/tokenizers", Title: "Tokenizers explained", ParentID: tweet.ID}
+	store.Upsert(article)
+	other := &Bookmark{Source: "raindrop", URL: "https://docs.dev/bpe", Title: "BPE tokenizers"}
+	store.Upsert(other)
+	if err := store.SetLinks(tweet.ID, []string{article.ID, other.ID, tweet.ID}); err != nil {
+		t.Fatalf("SetLinks failed: %v", err)
+	}
+
+	results, err := store.Search("tokenizers explained", 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) == 0 || results[0].ID != article.ID {
+		t.Fatalf("expected the article first, got %+v", results)
+	}
+	if len(results[0].LinkedFrom) != 1 || results[0].LinkedFrom[0].String() != "@karpathy: Great write-up on tokenizers" {
+		t.Errorf("expected the tweet as context, got %+v", results[0].LinkedFrom)
+	}
+
+	related, err := store.Related(tweet.ID, 10)
+	if err != nil {
+		t.Fatalf("Related failed: %v", err)
+	}
+	if len(related) != 2 || related[0].ID != article.ID || related[0].Explain.Signals[0].Signal != SignalLinked {
+		t.Errorf("expected the linked pages in order, got %+v", related)
+	}
+
+	// Force-fetching X doesn't treat the article as an orphan
+	orphans, _ := store.GetOrphanedBySource("x", []string{tweet.URL})
+	if len(orphans) != 0 {
+		t.Errorf("expected no orphans, got %+v", orphans)
+	}
+
+	// A page another tweet links to as well outlives the tweet it was saved for
+	shared := &Bookmark{Source: "x", URL: "https://blog.dev/bpe", Title: "BPE from scratch", ParentID: tweet.ID}
+	store.Upsert(shared)
+	retweet := &Bookmark{Source: "x", URL: "https://x.com/someone/status/2", Title: "Also worth reading"}
+	store.Upsert(retweet)
+	store.SetLinks(tweet.ID, []string{article.ID, other.ID, shared.ID})
+	store.SetLinks(retweet.ID, []string{shared.ID})
+
+	// Deleting the tweet deletes the page saved for it, not the one saved on its own
+	if err := store.Delete(tweet.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get(article.ID); err == nil {
+		t.Error("expected the child page deleted with the tweet")
+	}
+	if _, err := store.Get(other.ID); err != nil {
+		t.Error("expected the independently saved page kept")
+	}
+	if b, err := store.Get(shared.ID); err != nil || b.ParentID != retweet.ID {
+		t.Errorf("expected the shared page kept for the other tweet, got %+v (%v)", b, err)
+	}
+	if linked, _ := store.LinkedFrom([]string{other.ID}); len(linked[other.ID]) != 0 {
+		t.Error("expected the links of the deleted tweet removed")
+	}
+}
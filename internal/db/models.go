@@ -1,24 +1,178 @@
 package db
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Bookmark struct {
-	ID           string    `json:"id"`
-	Source       string    `json:"source"` // x, raindrop, github, manual
-	URL          string    `json:"url"`
-	Title        string    `json:"title"`
-	Summary      string    `json:"summary,omitempty"`
-	Keywords     string    `json:"keywords,omitempty"`
-	Notes        string    `json:"notes,omitempty"`
-	RawContent   string    `json:"raw_content,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	ScrapedAt    time.Time `json:"scraped_at,omitempty"`
-	ScrapeStatus string    `json:"scrape_status"` // success, pending, failed
-	Hidden       bool      `json:"hidden"`
+	ID           string            `json:"id"`
+	Source       string            `json:"source"` // x, raindrop, github, manual
+	URL          string            `json:"url"`
+	OriginalURL  string            `json:"original_url,omitempty"` // As saved, when it differs from the canonical URL
+	Title        string            `json:"title"`
+	Summary      string            `json:"summary,omitempty"`
+	Keywords     string            `json:"keywords,omitempty"`
+	Notes        string            `json:"notes,omitempty"`
+	RawContent   string            `json:"raw_content,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	ScrapedAt    time.Time         `json:"scraped_at,omitempty"`
+	ScrapeStatus string            `json:"scrape_status"`           // success, pending, failed, batched, rejected
+	StatusReason string            `json:"status_reason,omitempty"` // Why the item was rejected
+	Hidden       bool              `json:"hidden"`
+	Starred      bool              `json:"starred,omitempty"` // Favorited at the source or starred in xhub
+	Provenance   Provenance        `json:"provenance,omitempty"`
+	ParentID     string            `json:"parent_id,omitempty"` // The bookmark (a tweet) this page was saved from as a link
+	Links        []string          `json:"-"`                   // URLs the source found in the bookmark, saved as children
+	Tweet        *TweetMetadata    `json:"-"`                   // Set by the X source, stored in tweet_metadata
+	Repo         *RepoMetadata     `json:"-"`                   // Set by the GitHub source, stored in github_metadata
+	Raindrop     *RaindropMetadata `json:"-"`                   // Set by the Raindrop source, stored in raindrop_items
 }
 
+// Editable bookmark fields tracked by Provenance
+const (
+	FieldTitle    = "title"
+	FieldSummary  = "summary"
+	FieldKeywords = "keywords"
+	FieldNotes    = "notes"
+)
+
+// Field origins
+const (
+	OriginSource = "source" // Imported from the bookmark source or scraped page
+	OriginLLM    = "llm"    // Generated by the summarizer
+	OriginUser   = "user"   // Edited by hand
+)
+
+// Provenance maps a field name to the origin of its current value.
+type Provenance map[string]string
+
+// IsUserEdited reports whether the field was last written by the user.
+func (b *Bookmark) IsUserEdited(field string) bool {
+	return b.Provenance[field] == OriginUser
+}
+
+// SetOrigin records where the field's current value came from.
+func (b *Bookmark) SetOrigin(field, origin string) {
+	if b.Provenance == nil {
+		b.Provenance = make(Provenance)
+	}
+	b.Provenance[field] = origin
+}
+
+// HasUserEdits reports whether any field was edited by hand.
+func (b *Bookmark) HasUserEdits() bool {
+	for _, origin := range b.Provenance {
+		if origin == OriginUser {
+			return true
+		}
+	}
+	return false
+}
+
+// Markers around matched terms in SearchResult.Highlights
+const (
+	HighlightOpen  = "<mark>"
+	HighlightClose = "</mark>"
+)
+
 type SearchResult struct {
 	Bookmark
-	Score float64 `json:"score"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"` // Field -> matched text (snippets for summary and notes)
+	Explain    *Explanation      `json:"explain,omitempty"`
+	LinkedFrom []LinkedFrom      `json:"linked_from,omitempty"` // Bookmarks (tweets) linking to this one
+}
+
+// Ranking signals fused into a search score
+const (
+	SignalFTS     = "fts"     // BM25 over title, summary, keywords, notes and url
+	SignalContent = "content" // BM25 over scraped raw content
+	SignalVector  = "vector"  // Cosine similarity of embeddings
+)
+
+// Explanation breaks a search result's score down by ranking signal and boost.
+type Explanation struct {
+	Fusion  string        `json:"fusion"` // FusionRRF or FusionScore
+	Signals []SignalScore `json:"signals"`
+	Boosts  []Boost       `json:"boosts,omitempty"` // Applied to the fused score, in order
+	Rerank  *RerankScore  `json:"rerank,omitempty"` // Set when a reranker reordered the top results
+}
+
+// RerankScore is a reranker's relevance score for a result.
+type RerankScore struct {
+	Score     float64 `json:"score"`
+	FusedRank int     `json:"fused_rank"` // Position before reranking
+}
+
+func (r RerankScore) String() string {
+	return fmt.Sprintf("rerank %.3f (fused #%d)", r.Score, r.FusedRank)
+}
+
+// SignalScore is one ranking signal's contribution to a result's score.
+type SignalScore struct {
+	Signal       string  `json:"signal"`
+	Rank         int     `json:"rank"`                 // 1-based position in this signal's results
+	Raw          float64 `json:"raw"`                  // BM25 score (higher is better), cosine similarity or shared tag count
+	Normalized   float64 `json:"normalized,omitempty"` // Raw scaled to [0, 1] among the signal's results (score fusion)
+	Weight       float64 `json:"weight"`               // Fusion weight of the signal
+	Contribution float64 `json:"contribution"`         // Amount added to the fused score
+}
+
+func (s SignalScore) String() string {
+	var measure string
+	switch s.Signal {
+	case SignalVector:
+		measure = fmt.Sprintf("cosine %.3f", s.Raw)
+	case SignalTags:
+		measure = fmt.Sprintf("%.0f shared", s.Raw)
+	case SignalDomain:
+		measure = "same site"
+	case SignalLinked:
+		measure = "linked"
+	default:
+		measure = fmt.Sprintf("bm25 %.3f", s.Raw)
+	}
+	return fmt.Sprintf("%s #%d (%s, weight %.2f) +%.4f", s.Signal, s.Rank, measure, s.Weight, s.Contribution)
+}
+
+// Boost multiplies a result's fused score, e.g. for recency or source.
+type Boost struct {
+	Name   string  `json:"name"` // "recency" or "source:<name>"
+	Factor float64 `json:"factor"`
+}
+
+func (b Boost) String() string {
+	return fmt.Sprintf("%s ×%.3f", b.Name, b.Factor)
+}
+
+// RenderHighlights replaces highlight markers in text, passing each match through mark.
+func RenderHighlights(text string, mark func(string) string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(text, HighlightOpen)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(text[start:], HighlightClose)
+		if end < 0 {
+			break
+		}
+		b.WriteString(text[:start])
+		b.WriteString(mark(text[start+len(HighlightOpen) : start+end]))
+		text = text[start+end+len(HighlightClose):]
+	}
+	b.WriteString(text)
+	return b.String()
+}
+
+// NewSearchResults wraps bookmarks listed without a text query.
+func NewSearchResults(bookmarks []Bookmark) []SearchResult {
+	results := make([]SearchResult, len(bookmarks))
+	for i, b := range bookmarks {
+		results[i] = SearchResult{Bookmark: b}
+	}
+	return results
 }
@@ -0,0 +1,447 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed search query: free text for FTS and vector search, plus filters.
+//
+// Syntax: bare words match by prefix, "quoted phrases" match exactly, a leading
+// "-" negates, and OR joins adjacent terms. Filters are source:, tag:, status:,
+// author: (an X handle), lang: and archived: (GitHub repositories), collection:
+// (by name), is:starred, has: (notes, summary, tags, highlights, embedding),
+// after: and before: (YYYY, YYYY-MM or YYYY-MM-DD). Repeated source:, status:,
+// author:, lang: or collection: filters match any of the values; repeated tag:
+// filters must all match.
+type Query struct {
+	Groups   [][]Term // Terms AND-ed together; terms within a group are OR-ed
+	Excluded []Term
+
+	Sources, NotSources     []string
+	Tags, NotTags           []string
+	Statuses, NotStatuses   []string
+	Authors, NotAuthors     []string // X handles without the @
+	Languages, NotLanguages []string
+	Archived                string // "true" or "false" to only match repositories that are or aren't archived
+	Collections             []string
+	NotCollections          []string
+	Starred                 string // "true" or "false" to only match bookmarks that are or aren't starred
+	Has, NotHas             []string
+	After, Before           string // YYYY-MM-DD; After is inclusive, Before exclusive
+}
+
+// Term is a word or quoted phrase.
+type Term struct {
+	Text   string
+	Phrase bool
+}
+
+// QueryError reports a malformed filter in a search query.
+type QueryError struct {
+	Token  string
+	Reason string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Token, e.Reason)
+}
+
+// hasFields maps has: values to SQL conditions on bookmarks b.
+var hasFields = map[string]string{
+	"notes":      `COALESCE(b.notes, '') != ''`,
+	"summary":    `COALESCE(b.summary, '') != ''`,
+	"tags":       `COALESCE(b.keywords, '') != ''`,
+	"keywords":   `COALESCE(b.keywords, '') != ''`,
+	"highlights": `COALESCE(b.annotations, '') != ''`,
+	"embedding":  `EXISTS (SELECT 1 FROM bookmarks_vec v WHERE v.id = b.id)`,
+}
+
+type queryToken struct {
+	raw     string // As typed, without the leading "-"
+	negated bool
+	field   string // Lowercased, "" for text
+	value   string
+	quoted  bool
+}
+
+// ParseQuery parses the search syntax described on Query.
+func ParseQuery(input string) (*Query, error) {
+	q := &Query{}
+	pendingOr := false
+	for _, tok := range tokenizeQuery(input) {
+		if !tok.quoted && !tok.negated && tok.field == "" && tok.value == "OR" {
+			pendingOr = len(q.Groups) > 0
+			continue
+		}
+
+		if tok.field != "" {
+			handled, err := q.applyFilter(tok)
+			if err != nil {
+				return nil, err
+			}
+			if handled {
+				pendingOr = false
+				continue
+			}
+			// Unknown field (e.g. "https://..."): search it as text
+			tok.value = tok.raw
+		}
+
+		if !hasWordChars(tok.value) {
+			continue
+		}
+		term := Term{Text: tok.value, Phrase: tok.quoted}
+		switch {
+		case tok.negated:
+			q.Excluded = append(q.Excluded, term)
+		case pendingOr:
+			last := len(q.Groups) - 1
+			q.Groups[last] = append(q.Groups[last], term)
+		default:
+			q.Groups = append(q.Groups, []Term{term})
+		}
+		pendingOr = false
+	}
+	return q, nil
+}
+
+func (q *Query) applyFilter(tok queryToken) (bool, error) {
+	value := tok.value
+	if value == "" {
+		switch tok.field {
+		case "source", "tag", "status", "author", "lang", "archived", "collection", "is", "has", "after", "before":
+			return false, &QueryError{Token: tok.raw, Reason: "missing value"}
+		}
+		return false, nil
+	}
+	add := func(pos, neg *[]string, v string) {
+		if tok.negated {
+			*neg = append(*neg, v)
+		} else {
+			*pos = append(*pos, v)
+		}
+	}
+
+	switch tok.field {
+	case "source":
+		add(&q.Sources, &q.NotSources, strings.ToLower(value))
+	case "tag":
+		add(&q.Tags, &q.NotTags, value)
+	case "status":
+		add(&q.Statuses, &q.NotStatuses, strings.ToLower(value))
+	case "author":
+		add(&q.Authors, &q.NotAuthors, strings.ToLower(strings.TrimPrefix(value, "@")))
+	case "lang":
+		add(&q.Languages, &q.NotLanguages, strings.ToLower(value))
+	case "archived":
+		archived, ok := map[string]bool{"true": true, "yes": true, "false": false, "no": false}[strings.ToLower(value)]
+		if !ok {
+			return false, &QueryError{Token: tok.raw, Reason: "expected archived:true or archived:false"}
+		}
+		q.Archived = fmt.Sprint(archived != tok.negated)
+	case "collection":
+		add(&q.Collections, &q.NotCollections, value)
+	case "is":
+		if strings.ToLower(value) != "starred" {
+			return false, &QueryError{Token: tok.raw, Reason: "expected is:starred"}
+		}
+		q.Starred = fmt.Sprint(!tok.negated)
+	case "has":
+		value = strings.ToLower(value)
+		if _, ok := hasFields[value]; !ok {
+			return false, &QueryError{Token: tok.raw, Reason: "expected has:notes, has:summary, has:tags, has:highlights or has:embedding"}
+		}
+		add(&q.Has, &q.NotHas, value)
+	case "after", "before":
+		if tok.negated {
+			return false, &QueryError{Token: tok.raw, Reason: "date filters can't be negated"}
+		}
+		start, end, err := parseDateRange(value)
+		if err != nil {
+			return false, &QueryError{Token: tok.raw, Reason: err.Error()}
+		}
+		if tok.field == "after" {
+			q.After = start
+		} else {
+			q.Before = end
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// parseDateRange returns the first day of the period and the first day after it.
+// "after:2024-01" then means from January 2024, "before:2024-01" until the end of it.
+func parseDateRange(value string) (string, string, error) {
+	const day = "2006-01-02"
+	for _, layout := range []struct {
+		format string
+		next   func(time.Time) time.Time
+	}{
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+		{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+		{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+	} {
+		if t, err := time.Parse(layout.format, value); err == nil {
+			return t.Format(day), layout.next(t).Format(day), nil
+		}
+	}
+	return "", "", fmt.Errorf("expected a date like 2024, 2024-01 or 2024-01-31")
+}
+
+func tokenizeQuery(input string) []queryToken {
+	var tokens []queryToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		tok := queryToken{}
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			tok.negated = true
+			i++
+		}
+
+		start := i
+		var value strings.Builder
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if runes[i] == '"' {
+				// Quoted run, possibly after "field:"
+				tok.quoted = true
+				i++
+				for i < len(runes) && runes[i] != '"' {
+					value.WriteRune(runes[i])
+					i++
+				}
+				i++ // Closing quote (or past the end)
+				continue
+			}
+			if runes[i] == ':' && tok.field == "" && !tok.quoted && value.Len() > 0 {
+				tok.field = strings.ToLower(value.String())
+				value.Reset()
+				i++
+				continue
+			}
+			value.WriteRune(runes[i])
+			i++
+		}
+		tok.raw = string(runes[start:min(i, len(runes))])
+		tok.value = value.String()
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+func hasWordChars(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasText reports whether the query has terms to rank by.
+func (q *Query) HasText() bool {
+	return len(q.Groups) > 0
+}
+
+// Text returns the positive terms as plain text, for embedding.
+func (q *Query) Text() string {
+	var parts []string
+	for _, group := range q.Groups {
+		for _, t := range group {
+			parts = append(parts, t.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ftsTerm quotes a term so FTS5 operators and punctuation are matched literally.
+// Words match by prefix ("matt" finds "matthew"), phrases exactly.
+func ftsTerm(t Term) string {
+	s := `"` + strings.ReplaceAll(t.Text, `"`, `""`) + `"`
+	if !t.Phrase {
+		s += "*"
+	}
+	return s
+}
+
+func ftsOr(terms []Term) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = ftsTerm(t)
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, " OR ") + ")"
+}
+
+// MatchExpr compiles the text terms to an FTS5 MATCH expression ("" without text terms).
+func (q *Query) MatchExpr() string {
+	if !q.HasText() {
+		return ""
+	}
+	groups := make([]string, len(q.Groups))
+	for i, g := range q.Groups {
+		groups[i] = ftsOr(g)
+	}
+	expr := strings.Join(groups, " AND ")
+	if len(q.Excluded) > 0 {
+		expr += " NOT " + ftsOr(q.Excluded)
+	}
+	return expr
+}
+
+// filterSQL compiles the filters to " AND ..." conditions on bookmarks aliased b.
+func (q *Query) filterSQL() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	in := func(column string, values []string, negated bool) {
+		if len(values) == 0 {
+			return
+		}
+		op := "IN"
+		if negated {
+			op = "NOT IN"
+		}
+		conds = append(conds, fmt.Sprintf("%s %s (%s)", column, op, placeholders(len(values))))
+		for _, v := range values {
+			args = append(args, v)
+		}
+	}
+	// A bookmark is from every source its merged duplicates came from
+	sourced := func(values []string, negated bool) {
+		if len(values) == 0 {
+			return
+		}
+		cond := fmt.Sprintf("(b.source IN (%[1]s) OR EXISTS (SELECT 1 FROM bookmark_urls u WHERE u.bookmark_id = b.id AND u.source IN (%[1]s)))", placeholders(len(values)))
+		if negated {
+			cond = "NOT " + cond
+		}
+		conds = append(conds, cond)
+		for _, v := range append(values, values...) {
+			args = append(args, v)
+		}
+	}
+	sourced(q.Sources, false)
+	sourced(q.NotSources, true)
+	in("b.scrape_status", q.Statuses, false)
+	in("b.scrape_status", q.NotStatuses, true)
+
+	// A tweet by the author, a page linked from one, or a tweet saved before
+	// metadata was kept, known by its URL
+	authored := func(values []string, negated bool) {
+		if len(values) == 0 {
+			return
+		}
+		cond := fmt.Sprintf(`(EXISTS (SELECT 1 FROM tweet_metadata tm WHERE tm.author_handle IN (%s) AND (tm.bookmark_id IN (b.id, b.parent_id) OR tm.bookmark_id IN (SELECT l.bookmark_id FROM bookmark_links l WHERE l.linked_id = b.id)))`, placeholders(len(values)))
+		for _, v := range values {
+			args = append(args, v)
+		}
+		for _, v := range values {
+			cond += ` OR (b.source = 'x' AND LOWER(b.url) LIKE ? ESCAPE '\')`
+			args = append(args, "https://x.com/"+likeEscaper.Replace(v)+"/status/%")
+		}
+		cond += ")"
+		if negated {
+			cond = "NOT " + cond
+		}
+		conds = append(conds, cond)
+	}
+	authored(q.Authors, false)
+	authored(q.NotAuthors, true)
+
+	const repo = `EXISTS (SELECT 1 FROM github_metadata g WHERE g.bookmark_id = b.id AND `
+	if len(q.Languages) > 0 {
+		conds = append(conds, repo+fmt.Sprintf("g.language IN (%s))", placeholders(len(q.Languages))))
+		for _, v := range q.Languages {
+			args = append(args, v)
+		}
+	}
+	if len(q.NotLanguages) > 0 {
+		conds = append(conds, "NOT "+repo+fmt.Sprintf("g.language IN (%s))", placeholders(len(q.NotLanguages))))
+		for _, v := range q.NotLanguages {
+			args = append(args, v)
+		}
+	}
+	if q.Archived != "" {
+		conds = append(conds, repo+"g.archived = ?)")
+		args = append(args, q.Archived == "true")
+	}
+
+	const collected = `EXISTS (SELECT 1 FROM collection_bookmarks cb JOIN collections c ON c.id = cb.collection_id WHERE cb.bookmark_id = b.id AND c.name COLLATE NOCASE IN (%s))`
+	if len(q.Collections) > 0 {
+		conds = append(conds, fmt.Sprintf(collected, placeholders(len(q.Collections))))
+		for _, v := range q.Collections {
+			args = append(args, v)
+		}
+	}
+	if len(q.NotCollections) > 0 {
+		conds = append(conds, "NOT "+fmt.Sprintf(collected, placeholders(len(q.NotCollections))))
+		for _, v := range q.NotCollections {
+			args = append(args, v)
+		}
+	}
+	if q.Starred != "" {
+		conds = append(conds, "b.starred = ?")
+		args = append(args, q.Starred == "true")
+	}
+
+	const tagged = `EXISTS (SELECT 1 FROM bookmark_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.bookmark_id = b.id AND t.name = ?)`
+	for _, tag := range q.Tags {
+		conds = append(conds, tagged)
+		args = append(args, tag)
+	}
+	for _, tag := range q.NotTags {
+		conds = append(conds, "NOT "+tagged)
+		args = append(args, tag)
+	}
+
+	for _, h := range q.Has {
+		conds = append(conds, hasFields[h])
+	}
+	for _, h := range q.NotHas {
+		conds = append(conds, "NOT "+hasFields[h])
+	}
+
+	if q.After != "" {
+		conds = append(conds, `julianday(b.created_at) >= julianday(?)`)
+		args = append(args, q.After)
+	}
+	if q.Before != "" {
+		conds = append(conds, `julianday(b.created_at) < julianday(?)`)
+		args = append(args, q.Before)
+	}
+
+	// Exclusions alone can't form a MATCH expression
+	if !q.HasText() && len(q.Excluded) > 0 {
+		conds = append(conds, `b.rowid NOT IN (SELECT rowid FROM bookmarks_fts WHERE bookmarks_fts MATCH ?)`)
+		args = append(args, ftsOr(q.Excluded))
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(conds, " AND "), args
+}
+
+// HasFilters reports whether the query restricts results beyond its text terms.
+func (q *Query) HasFilters() bool {
+	where, _ := q.filterSQL()
+	return where != ""
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
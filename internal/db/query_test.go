@@ -0,0 +1,286 @@
+package db
+
+import (
+	"errors"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	cases := []struct {
+		input string
+		match string
+		check func(q *Query) bool
+	}{
+		{"matt pocock", `"matt"* AND "pocock"*`, nil},
+		{`"vector database" -tutorial`, `"vector database" NOT "tutorial"*`, nil},
+		{"rust OR go tui", `("rust"* OR "go"*) AND "tui"*`, nil},
+		{"c++ AND-OR node.js", `"c++"* AND "AND-OR"* AND "node.js"*`, nil},
+		{"https://github.com/foo", `"https://github.com/foo"*`, nil},
+		{"source:GitHub -source:x tag:rust -tag:web", "", func(q *Query) bool {
+			return reflect.DeepEqual(q.Sources, []string{"github"}) && reflect.DeepEqual(q.NotSources, []string{"x"}) &&
+				reflect.DeepEqual(q.Tags, []string{"rust"}) && reflect.DeepEqual(q.NotTags, []string{"web"})
+		}},
+		{`tag:"machine learning" status:failed has:notes -has:summary`, "", func(q *Query) bool {
+			return reflect.DeepEqual(q.Tags, []string{"machine learning"}) && reflect.DeepEqual(q.Statuses, []string{"failed"}) &&
+				reflect.DeepEqual(q.Has, []string{"notes"}) && reflect.DeepEqual(q.NotHas, []string{"summary"})
+		}},
+		{"author:@Karpathy -author:foo_bar", "", func(q *Query) bool {
+			return reflect.DeepEqual(q.Authors, []string{"karpathy"}) && reflect.DeepEqual(q.NotAuthors, []string{"foo_bar"})
+		}},
+		{"lang:Go -lang:rust -archived:yes", "", func(q *Query) bool {
+			return reflect.DeepEqual(q.Languages, []string{"go"}) && reflect.DeepEqual(q.NotLanguages, []string{"rust"}) && q.Archived == "false"
+		}},
+		{"collection:Reading -collection:Archive is:starred has:highlights", "", func(q *Query) bool {
+			return reflect.DeepEqual(q.Collections, []string{"Reading"}) && reflect.DeepEqual(q.NotCollections, []string{"Archive"}) && q.Starred == "true" && reflect.DeepEqual(q.Has, []string{"highlights"})
+		}},
+		{"after:2024-01 before:2024-03", "", func(q *Query) bool {
+			return q.After == "2024-01-01" && q.Before == "2024-04-01"
+		}},
+		{"- * OR", "", func(q *Query) bool { return !q.HasText() && !q.HasFilters() }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			q, err := ParseQuery(tc.input)
+			if err != nil {
+				t.Fatalf("ParseQuery failed: %v", err)
+			}
+			if got := q.MatchExpr(); got != tc.match {
+				t.Errorf("MatchExpr = %s, want %s", got, tc.match)
+			}
+			if tc.check != nil && !tc.check(q) {
+				t.Errorf("unexpected query: %+v", q)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, input := range []string{"after:yesterday", "has:stars", "source:", "author:", "archived:maybe", "is:read", "collection:", "-before:2024"} {
+		_, err := ParseQuery(input)
+		var qe *QueryError
+		if !errors.As(err, &qe) {
+			t.Errorf("%q: expected *QueryError, got %v", input, err)
+		}
+	}
+}
+
+func TestSearchWithFilters(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	for _, b := range []*Bookmark{
+		{Source: "github", URL: "https://github.com/a/tokio", Title: "tokio async runtime", Keywords: "rust, async", CreatedAt: day("2024-04-10"), ScrapeStatus: "success"},
+		{Source: "github", URL: "https://github.com/a/bubbletea", Title: "bubbletea tui", Keywords: "go, tui", CreatedAt: day("2023-06-01"), ScrapeStatus: "failed"},
+		{Source: "x", URL: "https://x.com/a/status/1", Title: "async rust thread", Keywords: "rust", Notes: "read later", CreatedAt: day("2024-05-01"), ScrapeStatus: "success"},
+	} {
+		if err := store.Upsert(b); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"async", []string{"https://github.com/a/tokio", "https://x.com/a/status/1"}},
+		{"async source:github", []string{"https://github.com/a/tokio"}},
+		{"async -thread", []string{"https://github.com/a/tokio"}},
+		{`"async runtime" OR tui`, []string{"https://github.com/a/bubbletea", "https://github.com/a/tokio"}},
+		{"tag:rust has:notes", []string{"https://x.com/a/status/1"}},
+		{"tag:RUST after:2024-05", []string{"https://x.com/a/status/1"}},
+		{"before:2024 status:failed", []string{"https://github.com/a/bubbletea"}},
+		{"-rust", []string{"https://github.com/a/bubbletea"}},
+		{`tokio"`, []string{"https://github.com/a/tokio"}}, // Unbalanced quote no longer breaks FTS
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			results, err := store.Search(tc.query, 10)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			got := make(map[string]bool)
+			for _, r := range results {
+				got[r.URL] = true
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for _, url := range tc.want {
+				if !got[url] {
+					t.Errorf("missing %s in %v", url, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	store.Upsert(&Bookmark{
+		Source:   "github",
+		URL:      "https://github.com/a/tokio",
+		Title:    "Tokio runtime",
+		Summary:  "An asynchronous runtime for writing reliable network applications without compromising speed.",
+		Keywords: "rust, async",
+		Notes:    "compare with smol",
+	})
+
+	results, err := store.Search("async", 10)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d (%v)", len(results), err)
+	}
+	h := results[0].Highlights
+	if h[FieldKeywords] != "rust, <mark>async</mark>" {
+		t.Errorf("keywords highlight = %q", h[FieldKeywords])
+	}
+	if h[FieldSummary] != "An <mark>asynchronous</mark> runtime for writing reliable network applications without compromising speed." {
+		t.Errorf("summary snippet = %q", h[FieldSummary])
+	}
+	if _, ok := h[FieldTitle]; ok {
+		t.Errorf("title has no match, got %q", h[FieldTitle])
+	}
+	if _, ok := h[FieldNotes]; ok {
+		t.Errorf("notes have no match, got %q", h[FieldNotes])
+	}
+	if results[0].Score <= 0 {
+		t.Errorf("expected a positive score, got %f", results[0].Score)
+	}
+
+	got := RenderHighlights(h[FieldKeywords], func(s string) string { return "[" + s + "]" })
+	if got != "rust, [async]" {
+		t.Errorf("RenderHighlights = %q", got)
+	}
+}
+
+func TestContentIndex(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	store.Upsert(&Bookmark{Source: "x", URL: "https://example.com/a", Title: "Scheduler notes", RawContent: "A deep dive into work stealing in the tokio scheduler."})
+	store.Upsert(&Bookmark{Source: "x", URL: "https://example.com/b", Title: "Tokio internals", RawContent: "Nothing relevant here."})
+
+	// Without the index, raw content isn't searched
+	results, _ := store.Search("stealing", 10)
+	if len(results) != 0 {
+		t.Fatalf("Expected no results without content index, got %d", len(results))
+	}
+
+	// Enabling indexes existing rows
+	if err := store.SetContentIndex(true); err != nil {
+		t.Fatalf("SetContentIndex failed: %v", err)
+	}
+	results, err = store.Search("stealing", 10)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected 1 content result, got %d (%v)", len(results), err)
+	}
+	if results[0].Highlights[HighlightContent] != "A deep dive into work <mark>stealing</mark> in the tokio scheduler." {
+		t.Errorf("content snippet = %q", results[0].Highlights[HighlightContent])
+	}
+
+	// Metadata matches outrank content matches
+	results, _ = store.Search("tokio", 10)
+	if len(results) != 2 || results[0].URL != "https://example.com/b" {
+		t.Fatalf("Expected the title match first, got %+v", results)
+	}
+
+	// Triggers keep the index in sync
+	b, _ := store.GetByURL("https://example.com/b")
+	b.RawContent = "Work stealing everywhere."
+	store.Update(b)
+	results, _ = store.Search("stealing", 10)
+	if len(results) != 2 {
+		t.Errorf("Expected 2 results after update, got %d", len(results))
+	}
+
+	// The index survives reopening and can be dropped
+	store.Close()
+	store, _ = NewStore(tmpDir)
+	defer store.Close()
+	if !store.ContentIndexed() {
+		t.Error("Expected content index to be detected on reopen")
+	}
+	if err := store.SetContentIndex(false); err != nil {
+		t.Fatalf("SetContentIndex(false) failed: %v", err)
+	}
+	results, _ = store.Search("stealing", 10)
+	if len(results) != 0 {
+		t.Errorf("Expected no results after dropping content index, got %d", len(results))
+	}
+}
+
+func TestSearchExplain(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	a := &Bookmark{Source: "x", URL: "https://example.com/a", Title: "Tokio runtime"}
+	b := &Bookmark{Source: "x", URL: "https://example.com/b", Title: "Async executors"}
+	store.Upsert(a)
+	store.Upsert(b)
+	store.UpdateEmbedding(a.ID, []float32{1, 0})
+	store.UpdateEmbedding(b.ID, []float32{0, 1})
+
+	results, err := store.HybridSearchWithEmbedding("tokio", []float32{0, 1}, 10)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d (%v)", len(results), err)
+	}
+
+	for _, r := range results {
+		if r.Explain == nil {
+			t.Fatalf("Expected an explanation for %s", r.URL)
+		}
+		total := 0.0
+		for _, s := range r.Explain.Signals {
+			total += s.Contribution
+		}
+		if math.Abs(total-r.Score) > 1e-9 {
+			t.Errorf("%s: contributions sum to %f, score is %f", r.URL, total, r.Score)
+		}
+	}
+
+	top := results[0]
+	if top.URL != "https://example.com/a" || len(top.Explain.Signals) != 2 {
+		t.Fatalf("Expected the FTS + vector match first, got %s with %+v", top.URL, top.Explain)
+	}
+	fts, vec := top.Explain.Signals[0], top.Explain.Signals[1]
+	if fts.Signal != SignalFTS || fts.Rank != 1 || fts.Raw <= 0 {
+		t.Errorf("unexpected fts signal %+v", fts)
+	}
+	if vec.Signal != SignalVector || vec.Rank != 2 || vec.Raw != 0 {
+		t.Errorf("unexpected vector signal %+v", vec)
+	}
+}
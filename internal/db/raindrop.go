@@ -0,0 +1,396 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/xhub/internal/taxonomy"
+)
+
+// FieldAnnotations is the bookmarks_fts column holding a bookmark's
+// highlights, and their SearchResult.Highlights key.
+const FieldAnnotations = "annotations"
+
+// Annotation is a passage highlighted in a bookmarked page, with an optional note.
+type Annotation struct {
+	ID         int64     `json:"-"`
+	BookmarkID string    `json:"-"`
+	Source     string    `json:"source"`                // Where the highlight was made, e.g. raindrop
+	ExternalID string    `json:"external_id,omitempty"` // The highlight's ID at the source
+	Text       string    `json:"text"`
+	Note       string    `json:"note,omitempty"`
+	Color      string    `json:"color,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RaindropMetadata is what the Raindrop source knows about a bookmark beyond
+// its fields.
+type RaindropMetadata struct {
+	RaindropID     int64
+	CollectionID   int64 // 0 or less for Unsorted and Trash, which aren't imported as collections
+	CollectionName string
+	Cover          string
+	Important      bool // Raindrop's favorite flag, mapped to Bookmark.Starred
+	LastUpdate     time.Time
+	Highlights     []Annotation
+	Note           string   // The note in Raindrop, which may differ from the bookmark's edited notes
+	Tags           []string // The tags in Raindrop, likewise
+}
+
+// Raindrop sync log actions
+const (
+	SyncPushed   = "pushed"   // Edits were written to Raindrop
+	SyncConflict = "conflict" // Raindrop changed the same field since the last sync; nothing was written
+	SyncFailed   = "failed"
+)
+
+// RaindropEdit is a Raindrop bookmark whose notes or tags were edited in xhub
+// and differ from what xhub and Raindrop last agreed on.
+type RaindropEdit struct {
+	BookmarkID string
+	RaindropID int64
+	Title      string
+	Note       string // The bookmark's notes
+	Tags       []string
+	NoteEdited bool
+	TagsEdited bool
+	SyncedNote string    // The note both sides had at the last sync
+	SyncedTags []string  // The tags both sides had at the last sync
+	LastUpdate time.Time // Raindrop's lastUpdate at the last sync; zero if unknown
+}
+
+// RaindropSyncEntry is a line of the Raindrop sync log.
+type RaindropSyncEntry struct {
+	ID         int64     `json:"id"`
+	BookmarkID string    `json:"bookmark_id"`
+	RaindropID int64     `json:"raindrop_id"`
+	Action     string    `json:"action"` // SyncPushed, SyncConflict or SyncFailed
+	Detail     string    `json:"detail,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SameTags reports whether two tag lists hold the same tags, in any order.
+func SameTags(a, b []string) bool {
+	key := func(tags []string) []string {
+		keys := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t = strings.TrimSpace(t); t != "" {
+				keys = append(keys, taxonomy.Key(t))
+			}
+		}
+		slices.Sort(keys)
+		return slices.Compact(keys)
+	}
+	return slices.Equal(key(a), key(b))
+}
+
+func (s *Store) migrateRaindrop() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS raindrop_items (
+		bookmark_id TEXT PRIMARY KEY,
+		raindrop_id INTEGER NOT NULL UNIQUE,
+		collection_id INTEGER DEFAULT 0,
+		cover TEXT DEFAULT '',
+		last_update TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		bookmark_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		external_id TEXT DEFAULT '',
+		text TEXT NOT NULL,
+		note TEXT DEFAULT '',
+		color TEXT DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_annotations_bookmark ON annotations(bookmark_id);
+
+	CREATE TABLE IF NOT EXISTS raindrop_sync_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		bookmark_id TEXT NOT NULL,
+		raindrop_id INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		detail TEXT DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_raindrop_ad AFTER DELETE ON bookmarks BEGIN
+		DELETE FROM raindrop_items WHERE bookmark_id = old.id;
+		DELETE FROM annotations WHERE bookmark_id = old.id;
+	END;
+	`)
+	if err != nil {
+		return err
+	}
+	// The note and tags xhub and Raindrop last agreed on, for write-back
+	if err := s.addColumnIfMissing("raindrop_items", "synced_note", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("raindrop_items", "synced_tags", "TEXT DEFAULT '[]'"); err != nil {
+		return err
+	}
+	// Searchable copies of the annotations table, indexed in bookmarks_fts
+	if err := s.addColumnIfMissing("bookmarks", "annotations", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	return s.addColumnIfMissing("bookmarks", "starred", "INTEGER DEFAULT 0")
+}
+
+// refreshAnnotations copies a bookmark's annotations into bookmarks.annotations.
+const refreshAnnotations = `
+	UPDATE bookmarks SET annotations = COALESCE((
+		SELECT group_concat(a.text || CASE WHEN a.note != '' THEN char(10) || a.note ELSE '' END, char(10) || char(10))
+		FROM (SELECT text, note FROM annotations WHERE bookmark_id = bookmarks.id ORDER BY created_at, id) a
+	), '') WHERE id = ?`
+
+// SetRaindropMetadata stores what the Raindrop source knows about the
+// bookmark id: its raindrop, its collection membership, its highlights and
+// whether it is starred.
+func (s *Store) SetRaindropMetadata(id string, m *RaindropMetadata) error {
+	var lastUpdate interface{}
+	if !m.LastUpdate.IsZero() {
+		lastUpdate = m.LastUpdate
+	}
+	// A raindrop merged into another bookmark is known by the one it was merged into
+	if _, err := s.db.Exec(`DELETE FROM raindrop_items WHERE raindrop_id = ? AND bookmark_id != ?`, m.RaindropID, id); err != nil {
+		return err
+	}
+	// Fields not edited in xhub take Raindrop's values, so both sides agree on
+	// them; an edited field agrees once it matches Raindrop. The sync point
+	// only moves up when both agree, so an edit waiting to be written back
+	// keeps the lastUpdate it was made against.
+	var notes, keywords, provenance sql.NullString
+	if err := s.db.QueryRow(`SELECT notes, keywords, provenance FROM bookmarks WHERE id = ?`, id).Scan(&notes, &keywords, &provenance); err != nil {
+		return err
+	}
+	var p Provenance
+	json.Unmarshal([]byte(provenance.String), &p)
+	noteAgrees := p[FieldNotes] != OriginUser || notes.String == m.Note
+	tagsAgree := p[FieldKeywords] != OriginUser || SameTags(taxonomy.Split(keywords.String), m.Tags)
+	tags, err := json.Marshal(nonNil(m.Tags))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO raindrop_items (bookmark_id, raindrop_id, collection_id, cover, last_update, synced_note, synced_tags)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(bookmark_id) DO UPDATE SET
+			raindrop_id = excluded.raindrop_id,
+			collection_id = excluded.collection_id,
+			cover = excluded.cover,
+			synced_note = CASE WHEN ? THEN excluded.synced_note ELSE raindrop_items.synced_note END,
+			synced_tags = CASE WHEN ? THEN excluded.synced_tags ELSE raindrop_items.synced_tags END,
+			last_update = CASE WHEN ? THEN excluded.last_update ELSE raindrop_items.last_update END`,
+		id, m.RaindropID, m.CollectionID, m.Cover, lastUpdate, m.Note, string(tags),
+		noteAgrees, tagsAgree, noteAgrees && tagsAgree)
+	if err != nil {
+		return err
+	}
+
+	var collectionID int64
+	if m.CollectionID > 0 {
+		name := m.CollectionName
+		if name == "" {
+			name = "Collection " + strconv.FormatInt(m.CollectionID, 10)
+		}
+		if collectionID, err = s.UpsertCollection(CollectionRaindrop, strconv.FormatInt(m.CollectionID, 10), name); err != nil {
+			return err
+		}
+	}
+	if err := s.SetCollectionMembership(CollectionRaindrop, id, collectionID); err != nil {
+		return err
+	}
+	if err := s.SetAnnotations(id, "raindrop", m.Highlights); err != nil {
+		return err
+	}
+	return s.SetStarred(id, m.Important)
+}
+
+// RaindropBookmarkID returns the bookmark the raindrop was imported as, or ""
+// if it wasn't.
+func (s *Store) RaindropBookmarkID(raindropID int64) (string, error) {
+	var id string
+	err := s.db.QueryRow(`SELECT bookmark_id FROM raindrop_items WHERE raindrop_id = ?`, raindropID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id, err
+}
+
+// GetRaindropMetadata returns the Raindrop metadata of bookmark id, or nil if
+// it didn't come from Raindrop. Highlights are not loaded; see Annotations.
+func (s *Store) GetRaindropMetadata(id string) (*RaindropMetadata, error) {
+	var m RaindropMetadata
+	var lastUpdate sql.NullTime
+	var name sql.NullString
+	err := s.db.QueryRow(`
+		SELECT r.raindrop_id, r.collection_id, c.name, r.cover, r.last_update, b.starred
+		FROM raindrop_items r
+		JOIN bookmarks b ON b.id = r.bookmark_id
+		LEFT JOIN collections c ON c.kind = ? AND c.external_id = CAST(r.collection_id AS TEXT)
+		WHERE r.bookmark_id = ?`, CollectionRaindrop, id).Scan(&m.RaindropID, &m.CollectionID, &name, &m.Cover, &lastUpdate, &m.Important)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.CollectionName = name.String
+	m.LastUpdate = lastUpdate.Time
+	return &m, nil
+}
+
+// SetAnnotations replaces the bookmark's annotations from a source.
+func (s *Store) SetAnnotations(id, source string, annotations []Annotation) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM annotations WHERE bookmark_id = ? AND source = ?`, id, source); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, a := range annotations {
+		if a.Text == "" && a.Note == "" {
+			continue
+		}
+		createdAt := a.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		if _, err := tx.Exec(`INSERT INTO annotations (bookmark_id, source, external_id, text, note, color, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id, source, a.ExternalID, a.Text, a.Note, a.Color, createdAt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(refreshAnnotations, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Annotations returns the bookmark's annotations, oldest first.
+func (s *Store) Annotations(id string) ([]Annotation, error) {
+	rows, err := s.db.Query(`
+		SELECT id, bookmark_id, source, external_id, text, note, color, created_at
+		FROM annotations WHERE bookmark_id = ? ORDER BY created_at, id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var annotations []Annotation
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(&a.ID, &a.BookmarkID, &a.Source, &a.ExternalID, &a.Text, &a.Note, &a.Color, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, rows.Err()
+}
+
+// SetStarred stars or unstars a bookmark.
+func (s *Store) SetStarred(id string, starred bool) error {
+	_, err := s.db.Exec(`UPDATE bookmarks SET starred = ? WHERE id = ? AND starred != ?`, starred, id, starred)
+	return err
+}
+
+// RaindropEdits returns up to limit Raindrop bookmarks with notes or tags
+// edited in xhub that differ from what Raindrop had at the last sync.
+func (s *Store) RaindropEdits(limit int) ([]RaindropEdit, error) {
+	rows, err := s.db.Query(`
+		SELECT b.id, r.raindrop_id, COALESCE(b.title, ''), COALESCE(b.notes, ''), COALESCE(b.keywords, ''),
+			json_extract(b.provenance, '$.notes') = 'user', json_extract(b.provenance, '$.keywords') = 'user',
+			COALESCE(r.synced_note, ''), COALESCE(r.synced_tags, '[]'), r.last_update
+		FROM raindrop_items r
+		JOIN bookmarks b ON b.id = r.bookmark_id
+		WHERE json_extract(b.provenance, '$.notes') = 'user' OR json_extract(b.provenance, '$.keywords') = 'user'
+		ORDER BY b.updated_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []RaindropEdit
+	for rows.Next() {
+		var e RaindropEdit
+		var keywords, syncedTags string
+		var noteEdited, tagsEdited sql.NullBool
+		var lastUpdate sql.NullTime
+		if err := rows.Scan(&e.BookmarkID, &e.RaindropID, &e.Title, &e.Note, &keywords, &noteEdited, &tagsEdited, &e.SyncedNote, &syncedTags, &lastUpdate); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(syncedTags), &e.SyncedTags)
+		e.Tags = nonNil(taxonomy.Split(keywords))
+		e.NoteEdited = noteEdited.Bool && e.Note != e.SyncedNote
+		e.TagsEdited = tagsEdited.Bool && !SameTags(e.Tags, e.SyncedTags)
+		e.LastUpdate = lastUpdate.Time
+		if !e.NoteEdited && !e.TagsEdited {
+			continue
+		}
+		edits = append(edits, e)
+		if len(edits) == limit {
+			break
+		}
+	}
+	return edits, rows.Err()
+}
+
+// SetRaindropSynced records the note and tags a bookmark's raindrop has now
+// that xhub and Raindrop agree, and Raindrop's lastUpdate for them.
+func (s *Store) SetRaindropSynced(id, note string, tags []string, lastUpdate time.Time) error {
+	data, err := json.Marshal(nonNil(tags))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`UPDATE raindrop_items SET synced_note = ?, synced_tags = ?, last_update = ? WHERE bookmark_id = ?`,
+		note, string(data), lastUpdate, id)
+	return err
+}
+
+// LogRaindropSync appends an entry to the Raindrop sync log.
+func (s *Store) LogRaindropSync(e RaindropSyncEntry) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	_, err := s.db.Exec(`INSERT INTO raindrop_sync_log (bookmark_id, raindrop_id, action, detail, created_at) VALUES (?, ?, ?, ?, ?)`,
+		e.BookmarkID, e.RaindropID, e.Action, e.Detail, e.CreatedAt)
+	return err
+}
+
+// RaindropSyncLog returns the latest limit entries of the Raindrop sync log, newest first.
+func (s *Store) RaindropSyncLog(limit int) ([]RaindropSyncEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, bookmark_id, raindrop_id, action, detail, created_at
+		FROM raindrop_sync_log ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []RaindropSyncEntry
+	for rows.Next() {
+		var e RaindropSyncEntry
+		if err := rows.Scan(&e.ID, &e.BookmarkID, &e.RaindropID, &e.Action, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func nonNil(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRaindropMetadata(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	essay := &Bookmark{Source: "raindrop", URL: "https://example.com/essay", Title: "An essay", Raindrop: &RaindropMetadata{
+		RaindropID: 101, CollectionID: 7, CollectionName: "Reading", Important: true, LastUpdate: created,
+		Highlights: []Annotation{
+			{ExternalID: "h1", Text: "Memory safety without garbage collection", Note: "key idea", CreatedAt: created},
+			{ExternalID: "h2", Text: "Ownership rules", CreatedAt: created.Add(time.Minute)},
+		},
+	}}
+	other := &Bookmark{Source: "raindrop", URL: "https://example.com/other", Title: "Another page", Raindrop: &RaindropMetadata{RaindropID: 102}}
+	manual := &Bookmark{Source: "manual", URL: "https://example.com/manual", Title: "A manual page"}
+	for _, b := range []*Bookmark{essay, other, manual} {
+		if err := store.Upsert(b); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+
+	meta, err := store.GetRaindropMetadata(essay.ID)
+	if err != nil || meta == nil {
+		t.Fatalf("GetRaindropMetadata failed: %v", err)
+	}
+	if meta.RaindropID != 101 || meta.CollectionName != "Reading" || !meta.Important || !meta.LastUpdate.Equal(created) {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+	if meta, _ := store.GetRaindropMetadata(manual.ID); meta != nil {
+		t.Errorf("expected no metadata for a manual bookmark, got %+v", meta)
+	}
+	if id, err := store.RaindropBookmarkID(101); err != nil || id != essay.ID {
+		t.Errorf("expected raindrop 101 known as %s, got %q (%v)", essay.ID, id, err)
+	}
+	if id, _ := store.RaindropBookmarkID(999); id != "" {
+		t.Errorf("expected an unknown raindrop, got %q", id)
+	}
+	notes, err := store.Annotations(essay.ID)
+	if err != nil || len(notes) != 2 || notes[0].Note != "key idea" || notes[1].Text != "Ownership rules" {
+		t.Errorf("unexpected annotations: %+v (%v)", notes, err)
+	}
+
+	// Highlights are searchable and reported as the match
+	results, err := store.Search("garbage", 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != essay.ID || !strings.Contains(results[0].Highlights[FieldAnnotations], HighlightOpen+"garbage") {
+		t.Errorf("expected a highlight match on the essay, got %+v", results)
+	}
+	if b, _ := store.Get(essay.ID); !b.Starred {
+		t.Error("expected the important raindrop to be starred")
+	}
+
+	// A later sync moves the raindrop, renames its collection and drops a highlight
+	essay.Raindrop = &RaindropMetadata{RaindropID: 101, CollectionID: 8, CollectionName: "Done", Important: true,
+		Highlights: essay.Raindrop.Highlights[1:]}
+	essay.ID = ""
+	store.Upsert(essay)
+	other.Raindrop = &RaindropMetadata{RaindropID: 102, CollectionID: 7, CollectionName: "To read"}
+	other.ID = ""
+	store.Upsert(other)
+	if results, _ := store.Search("garbage", 10); len(results) != 0 {
+		t.Errorf("expected the removed highlight to no longer match, got %d results", len(results))
+	}
+	collections, err := store.ListCollections(CollectionRaindrop)
+	if err != nil {
+		t.Fatalf("ListCollections failed: %v", err)
+	}
+	if len(collections) != 2 || collections[0].Count != 1 || collections[1].Count != 1 {
+		t.Errorf("expected two collections of one bookmark, got %+v", collections)
+	}
+	if c, err := store.FindCollection("", "to read"); err != nil || c.Kind != CollectionRaindrop {
+		t.Errorf("FindCollection = %+v, %v", c, err)
+	}
+
+	// Starring in xhub, and unstarring
+	if err := store.SetStarred(manual.ID, true); err != nil {
+		t.Fatalf("SetStarred failed: %v", err)
+	}
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"is:starred", []string{essay.ID, manual.ID}},
+		{"-is:starred", []string{other.ID}},
+		{"collection:done", []string{essay.ID}},
+		{`collection:"to read"`, []string{other.ID}},
+		{"-collection:done source:raindrop", []string{other.ID}},
+		{"has:highlights", []string{essay.ID}},
+	}
+	for _, tc := range cases {
+		results, err := store.Search(tc.query, 10)
+		if err != nil {
+			t.Fatalf("Search %q failed: %v", tc.query, err)
+		}
+		got := make(map[string]bool)
+		for _, r := range results {
+			got[r.ID] = true
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%q: got %v, want %v", tc.query, got, tc.want)
+			continue
+		}
+		for _, id := range tc.want {
+			if !got[id] {
+				t.Errorf("%q: missing %s in %v", tc.query, id, got)
+			}
+		}
+	}
+}
+
+func TestRefreshFromSource(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	synced := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := &Bookmark{Source: "raindrop", URL: "https://example.com/essay", Title: "An essay", Keywords: "rust", Notes: "read later",
+		Raindrop: &RaindropMetadata{RaindropID: 101, LastUpdate: synced, Note: "read later", Tags: []string{"rust"}}}
+	store.Upsert(b)
+	stored, _ := store.Get(b.ID)
+	stored.Title = "My title"
+	stored.SetOrigin(FieldTitle, OriginUser)
+	stored.Summary, stored.RawContent, stored.ScrapeStatus = "A summary", "The essay", "done"
+	stored.SetOrigin(FieldSummary, OriginLLM)
+	store.Update(stored)
+
+	// The note and tags changed in Raindrop, and the title too
+	edited := synced.Add(time.Hour)
+	upstream := &Bookmark{Source: "raindrop", URL: b.URL, Title: "An essay, retitled", Keywords: "rust,memory", Notes: "worth rereading",
+		Raindrop: &RaindropMetadata{RaindropID: 101, LastUpdate: edited, Note: "worth rereading", Tags: []string{"rust", "memory"}}}
+	if err := store.RefreshFromSource(b.ID, upstream); err != nil {
+		t.Fatalf("RefreshFromSource failed: %v", err)
+	}
+	if err := store.SetRaindropMetadata(b.ID, upstream.Raindrop); err != nil {
+		t.Fatalf("SetRaindropMetadata failed: %v", err)
+	}
+
+	got, _ := store.Get(b.ID)
+	if got.Notes != "worth rereading" || got.Keywords != "rust,memory" {
+		t.Errorf("expected Raindrop's note and tags, got %q / %q", got.Notes, got.Keywords)
+	}
+	if got.Title != "My title" {
+		t.Errorf("expected the title edited in xhub kept, got %q", got.Title)
+	}
+	if got.Summary != "A summary" || got.RawContent != "The essay" || got.ScrapeStatus != "done" {
+		t.Errorf("expected content and summary untouched, got %q / %q / %s", got.Summary, got.RawContent, got.ScrapeStatus)
+	}
+	if results, _ := store.Search("tag:memory", 10); len(results) != 1 {
+		t.Errorf("expected the new tag searchable, got %d results", len(results))
+	}
+	if meta, _ := store.GetRaindropMetadata(b.ID); meta == nil || !meta.LastUpdate.Equal(edited) {
+		t.Errorf("expected the sync point moved up, got %+v", meta)
+	}
+	if edits, _ := store.RaindropEdits(-1); len(edits) != 0 {
+		t.Errorf("expected nothing to write back, got %+v", edits)
+	}
+}
@@ -0,0 +1,240 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Fusion methods for combining ranking signals
+const (
+	FusionRRF   = "rrf"   // Reciprocal rank fusion: weight / (k + rank)
+	FusionScore = "score" // Weighted sum of min-max normalized raw scores
+)
+
+// ftsColumns are the bookmarks_fts columns in order, as named in Ranking.FieldWeights.
+var ftsColumns = [...]string{FieldTitle, FieldSummary, FieldKeywords, FieldNotes, "url", FieldAnnotations}
+
+// Ranking configures how search results are scored.
+type Ranking struct {
+	Fusion          string             // FusionRRF or FusionScore
+	RRFK            float64            // RRF constant; higher flattens the rank curve
+	SignalWeights   map[string]float64 // Signal -> fusion weight; unset signals weigh 1
+	FieldWeights    map[string]float64 // bookmarks_fts column -> BM25 weight; unset columns weigh 1
+	RecencyBoost    float64            // Extra score for a bookmark saved now, as a fraction (0 disables)
+	RecencyHalfLife time.Duration      // Age at which the recency boost has halved
+	SourceBoosts    map[string]float64 // Source -> score multiplier
+}
+
+// DefaultRanking is RRF with equal field weights and content matches at half weight.
+func DefaultRanking() Ranking {
+	return Ranking{
+		Fusion:          FusionRRF,
+		RRFK:            60,
+		SignalWeights:   map[string]float64{SignalContent: 0.5},
+		RecencyHalfLife: 180 * 24 * time.Hour,
+	}
+}
+
+// Validate reports settings that can't rank anything sensibly.
+func (r Ranking) Validate() error {
+	if r.Fusion != FusionRRF && r.Fusion != FusionScore {
+		return fmt.Errorf("fusion must be %q or %q, got %q", FusionRRF, FusionScore, r.Fusion)
+	}
+	if r.Fusion == FusionRRF && r.RRFK <= 0 {
+		return fmt.Errorf("rrf_k must be positive")
+	}
+	for name, w := range r.SignalWeights {
+		if name != SignalFTS && name != SignalContent && name != SignalVector {
+			return fmt.Errorf("unknown signal %q (expected fts, content or vector)", name)
+		}
+		if w < 0 {
+			return fmt.Errorf("signal weight for %s can't be negative", name)
+		}
+	}
+	for name, w := range r.FieldWeights {
+		if !isFTSColumn(name) {
+			return fmt.Errorf("unknown field %q (expected title, summary, keywords, notes, url or annotations)", name)
+		}
+		if w < 0 {
+			return fmt.Errorf("field weight for %s can't be negative", name)
+		}
+	}
+	if r.RecencyBoost < 0 {
+		return fmt.Errorf("recency boost can't be negative")
+	}
+	if r.RecencyBoost > 0 && r.RecencyHalfLife <= 0 {
+		return fmt.Errorf("recency half-life must be positive")
+	}
+	for source, b := range r.SourceBoosts {
+		if b <= 0 {
+			return fmt.Errorf("source boost for %s must be positive", source)
+		}
+	}
+	return nil
+}
+
+func isFTSColumn(name string) bool {
+	for _, c := range ftsColumns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRanking changes how Search and HybridSearchWithEmbedding score results.
+func (s *Store) SetRanking(r Ranking) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	s.ranking = r
+	return nil
+}
+
+func (r Ranking) signalWeight(signal string) float64 {
+	if w, ok := r.SignalWeights[signal]; ok {
+		return w
+	}
+	return 1
+}
+
+// bm25Weights returns the bm25() column weight arguments, in column order.
+func (r Ranking) bm25Weights() []interface{} {
+	weights := make([]interface{}, len(ftsColumns))
+	for i, c := range ftsColumns {
+		w, ok := r.FieldWeights[c]
+		if !ok {
+			w = 1
+		}
+		weights[i] = w
+	}
+	return weights
+}
+
+// fuse combines each signal's results into one ranking, recording each signal's
+// contribution.
+func (r Ranking) fuse(signals ...rankedSignal) []scoredResult {
+	byID := make(map[string]*scoredResult)
+	var results []*scoredResult
+	for _, sig := range signals {
+		norm := normalizer(sig.results)
+		for _, res := range sig.results {
+			combined, ok := byID[res.ID]
+			if !ok {
+				combined = &scoredResult{ID: res.ID, Explain: &Explanation{Fusion: r.Fusion}}
+				byID[res.ID] = combined
+				results = append(results, combined)
+			}
+			signal := SignalScore{Signal: sig.name, Rank: res.Rank, Raw: res.Score, Weight: sig.weight}
+			if r.Fusion == FusionScore {
+				signal.Normalized = norm(res.Score)
+				signal.Contribution = sig.weight * signal.Normalized
+			} else {
+				signal.Contribution = sig.weight / (r.RRFK + float64(res.Rank))
+			}
+			combined.Score += signal.Contribution
+			combined.Explain.Signals = append(combined.Explain.Signals, signal)
+			for field, text := range res.Highlights {
+				if combined.Highlights == nil {
+					combined.Highlights = make(map[string]string)
+				}
+				combined.Highlights[field] = text
+			}
+		}
+	}
+
+	ranked := make([]scoredResult, len(results))
+	for i, res := range results {
+		ranked[i] = *res
+	}
+	return ranked
+}
+
+// normalizer min-max scales a signal's raw scores to [0, 1].
+func normalizer(results []scoredResult) func(float64) float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, r := range results {
+		lo = math.Min(lo, r.Score)
+		hi = math.Max(hi, r.Score)
+	}
+	return func(score float64) float64 {
+		if hi == lo {
+			return 1
+		}
+		return (score - lo) / (hi - lo)
+	}
+}
+
+// hasBoosts reports whether scores depend on bookmark age or source.
+func (r Ranking) hasBoosts() bool {
+	return r.RecencyBoost > 0 || len(r.SourceBoosts) > 0
+}
+
+// boost multiplies fused scores by the recency and source boosts, then sorts
+// by score.
+func (s *Store) boost(ranked []scoredResult) ([]scoredResult, error) {
+	r := s.ranking
+	if r.hasBoosts() && len(ranked) > 0 {
+		ids := make([]interface{}, len(ranked))
+		for i, res := range ranked {
+			ids[i] = res.ID
+		}
+		rows, err := s.db.Query(`SELECT id, source, created_at FROM bookmarks WHERE id IN (`+placeholders(len(ids))+`)`, ids...)
+		if err != nil {
+			return nil, err
+		}
+		type info struct {
+			source  string
+			created time.Time
+		}
+		infos := make(map[string]info, len(ranked))
+		for rows.Next() {
+			var id string
+			var i info
+			if err := rows.Scan(&id, &i.source, &i.created); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			infos[id] = i
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		for i := range ranked {
+			bi, ok := infos[ranked[i].ID]
+			if !ok {
+				continue
+			}
+			if r.RecencyBoost > 0 {
+				age := now.Sub(bi.created)
+				factor := 1 + r.RecencyBoost*math.Exp2(-float64(age)/float64(r.RecencyHalfLife))
+				ranked[i].applyBoost("recency", factor)
+			}
+			if factor, ok := r.SourceBoosts[bi.source]; ok {
+				ranked[i].applyBoost("source:"+bi.source, factor)
+			}
+		}
+	}
+
+	sortByScore(ranked)
+	return ranked, nil
+}
+
+// sortByScore orders results by score descending, keeping ties in signal order.
+func sortByScore(ranked []scoredResult) {
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+}
+
+func (sr *scoredResult) applyBoost(name string, factor float64) {
+	sr.Score *= factor
+	if sr.Explain != nil {
+		sr.Explain.Boosts = append(sr.Explain.Boosts, Boost{Name: name, Factor: factor})
+	}
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRankingValidate(t *testing.T) {
+	if err := DefaultRanking().Validate(); err != nil {
+		t.Fatalf("default ranking invalid: %v", err)
+	}
+	for name, mutate := range map[string]func(*Ranking){
+		"fusion":    func(r *Ranking) { r.Fusion = "borda" },
+		"rrf k":     func(r *Ranking) { r.RRFK = 0 },
+		"signal":    func(r *Ranking) { r.SignalWeights = map[string]float64{"pagerank": 1} },
+		"field":     func(r *Ranking) { r.FieldWeights = map[string]float64{"body": 1} },
+		"negative":  func(r *Ranking) { r.FieldWeights = map[string]float64{"title": -1} },
+		"half-life": func(r *Ranking) { r.RecencyBoost, r.RecencyHalfLife = 0.2, 0 },
+		"source":    func(r *Ranking) { r.SourceBoosts = map[string]float64{"x": 0} },
+	} {
+		r := DefaultRanking()
+		mutate(&r)
+		if err := r.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestRankingFieldWeights(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	store.Upsert(&Bookmark{Source: "x", URL: "https://example.com/notes", Title: "Something else", Notes: "zig zig zig compiler"})
+	store.Upsert(&Bookmark{Source: "x", URL: "https://example.com/title", Title: "Zig", Notes: "a language"})
+
+	results, _ := store.Search("zig", 10)
+	if len(results) != 2 || results[0].URL != "https://example.com/notes" {
+		t.Fatalf("Expected the repeated notes match first with equal weights, got %+v", results)
+	}
+
+	r := DefaultRanking()
+	r.FieldWeights = map[string]float64{FieldTitle: 10}
+	if err := store.SetRanking(r); err != nil {
+		t.Fatalf("SetRanking failed: %v", err)
+	}
+	results, _ = store.Search("zig", 10)
+	if len(results) != 2 || results[0].URL != "https://example.com/title" {
+		t.Errorf("Expected the title match first with a heavy title weight, got %+v", results)
+	}
+}
+
+func TestRankingScoreFusionAndBoosts(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	old := &Bookmark{Source: "x", URL: "https://example.com/old", Title: "Rust", CreatedAt: time.Now().AddDate(-2, 0, 0)}
+	recent := &Bookmark{Source: "github", URL: "https://example.com/new", Title: "Rust notes on async runtimes", CreatedAt: time.Now()}
+	store.Upsert(old)
+	store.Upsert(recent)
+	store.UpdateEmbedding(old.ID, []float32{1, 0})
+	store.UpdateEmbedding(recent.ID, []float32{0.6, 0.8})
+
+	r := DefaultRanking()
+	r.Fusion = FusionScore
+	if err := store.SetRanking(r); err != nil {
+		t.Fatalf("SetRanking failed: %v", err)
+	}
+	results, err := store.HybridSearchWithEmbedding("rust", []float32{1, 0}, 10)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d (%v)", len(results), err)
+	}
+	if results[0].URL != "https://example.com/old" || results[0].Explain.Fusion != FusionScore {
+		t.Fatalf("Expected the exact vector match first, got %+v", results[0])
+	}
+	for _, s := range results[0].Explain.Signals {
+		if s.Signal == SignalVector && s.Normalized != 1 {
+			t.Errorf("Expected the best vector match normalized to 1, got %f", s.Normalized)
+		}
+	}
+
+	// Recency and source boosts lift the newer github bookmark
+	r.Fusion = FusionRRF
+	r.RecencyBoost = 1
+	r.RecencyHalfLife = 30 * 24 * time.Hour
+	r.SourceBoosts = map[string]float64{"github": 2}
+	store.SetRanking(r)
+	results, _ = store.HybridSearchWithEmbedding("rust", []float32{1, 0}, 10)
+	top := results[0]
+	if top.URL != "https://example.com/new" {
+		t.Fatalf("Expected the boosted bookmark first, got %s", top.URL)
+	}
+	if len(top.Explain.Boosts) != 2 || top.Explain.Boosts[1].Name != "source:github" {
+		t.Fatalf("Expected recency and source boosts, got %+v", top.Explain.Boosts)
+	}
+	fused := 0.0
+	for _, s := range top.Explain.Signals {
+		fused += s.Contribution
+	}
+	want := fused * top.Explain.Boosts[0].Factor * top.Explain.Boosts[1].Factor
+	if math.Abs(want-top.Score) > 1e-9 {
+		t.Errorf("score %f doesn't match fused score times boosts %f", top.Score, want)
+	}
+}
@@ -0,0 +1,194 @@
+package db
+
+import (
+	"database/sql"
+	"net/url"
+	"strings"
+)
+
+// Signals used only by Related
+const (
+	SignalTags   = "tags"   // Number of shared tags
+	SignalDomain = "domain" // Same site (or same account on GitHub and X)
+	SignalLinked = "linked" // One links to the other (a tweet and the page it shares)
+)
+
+// relatedCandidates caps each signal's results before fusion.
+const relatedCandidates = 50
+
+// relatedWeights weighs sharing a site below embedding and tag similarity.
+var relatedWeights = map[string]float64{SignalVector: 1, SignalTags: 1, SignalDomain: 0.5, SignalLinked: 1}
+
+// accountHosts are sites where the first path segment, not the host, says
+// whether two bookmarks have a common origin.
+var accountHosts = map[string]bool{
+	"github.com":  true,
+	"x.com":       true,
+	"twitter.com": true,
+}
+
+// Related finds the visible bookmarks most similar to the bookmark with the
+// given ID: nearest neighbours by embedding, fused with bookmarks sharing its
+// tags or site and those it links to or is linked from. Results carry an
+// Explanation of which signals matched.
+func (s *Store) Related(id string, limit int) ([]SearchResult, error) {
+	b, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	vecResults, err := s.relatedByEmbedding(id)
+	if err != nil {
+		return nil, err
+	}
+	tagResults, err := s.relatedByTags(id)
+	if err != nil {
+		return nil, err
+	}
+	domainResults, err := s.relatedBySite(b)
+	if err != nil {
+		return nil, err
+	}
+	linkResults, err := s.relatedByLinks(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r := Ranking{Fusion: FusionRRF, RRFK: s.ranking.RRFK}
+	if r.RRFK <= 0 {
+		r.RRFK = DefaultRanking().RRFK
+	}
+	combined := r.fuse(
+		rankedSignal{SignalVector, relatedWeights[SignalVector], vecResults},
+		rankedSignal{SignalTags, relatedWeights[SignalTags], tagResults},
+		rankedSignal{SignalDomain, relatedWeights[SignalDomain], domainResults},
+		rankedSignal{SignalLinked, relatedWeights[SignalLinked], linkResults},
+	)
+	sortByScore(combined)
+	if len(combined) > limit {
+		combined = combined[:limit]
+	}
+	return s.fetchRanked(combined), nil
+}
+
+// relatedByEmbedding ranks visible bookmarks by cosine similarity to id's embedding.
+func (s *Store) relatedByEmbedding(id string) ([]scoredResult, error) {
+	var blob []byte
+	err := s.db.QueryRow(`SELECT embedding FROM bookmarks_vec WHERE id = ?`, id).Scan(&blob)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.queryStrings(`SELECT id FROM bookmarks WHERE hidden = 0 AND id != ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[string]bool, len(ids))
+	for _, other := range ids {
+		allowed[other] = true
+	}
+	return s.searchWithEmbedding(bytesToFloat32Slice(blob), allowed, relatedCandidates)
+}
+
+// relatedByTags ranks visible bookmarks by how many tags they share with id.
+func (s *Store) relatedByTags(id string) ([]scoredResult, error) {
+	rows, err := s.db.Query(`
+		SELECT bt.bookmark_id, COUNT(*) AS shared
+		FROM bookmark_tags bt
+		JOIN bookmarks b ON b.id = bt.bookmark_id
+		WHERE bt.tag_id IN (SELECT tag_id FROM bookmark_tags WHERE bookmark_id = ?)
+		AND bt.bookmark_id != ?
+		AND b.hidden = 0
+		GROUP BY bt.bookmark_id
+		ORDER BY shared DESC, b.created_at DESC
+		LIMIT ?
+	`, id, id, relatedCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []scoredResult
+	for rows.Next() {
+		var r scoredResult
+		if err := rows.Scan(&r.ID, &r.Score); err != nil {
+			return nil, err
+		}
+		r.Rank = len(results) + 1
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// relatedBySite lists visible bookmarks from the same site as b, newest first.
+func (s *Store) relatedBySite(b *Bookmark) ([]scoredResult, error) {
+	site := siteKey(b.URL)
+	if site == "" {
+		return nil, nil
+	}
+	host := strings.SplitN(site, "/", 2)[0]
+	rows, err := s.db.Query(`
+		SELECT id, url FROM bookmarks
+		WHERE hidden = 0 AND id != ? AND url LIKE ?
+		ORDER BY created_at DESC
+	`, b.ID, "%"+host+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []scoredResult
+	for rows.Next() && len(results) < relatedCandidates {
+		var id, u string
+		if err := rows.Scan(&id, &u); err != nil {
+			return nil, err
+		}
+		if siteKey(u) == site {
+			results = append(results, scoredResult{ID: id, Score: 1, Rank: len(results) + 1})
+		}
+	}
+	return results, rows.Err()
+}
+
+// relatedByLinks lists visible bookmarks that id links to, then those linking to it.
+func (s *Store) relatedByLinks(id string) ([]scoredResult, error) {
+	ids, err := s.queryStrings(`
+		SELECT linked_id FROM (
+			SELECT l.linked_id, 0 AS dir, l.position FROM bookmark_links l WHERE l.bookmark_id = ?
+			UNION ALL
+			SELECT l.bookmark_id, 1, b.created_at FROM bookmark_links l JOIN bookmarks b ON b.id = l.bookmark_id WHERE l.linked_id = ?
+		) JOIN bookmarks ON bookmarks.id = linked_id
+		WHERE hidden = 0
+		ORDER BY dir, position
+		LIMIT ?
+	`, id, id, relatedCandidates)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]scoredResult, len(ids))
+	for i, linked := range ids {
+		results[i] = scoredResult{ID: linked, Score: 1, Rank: i + 1}
+	}
+	return results, nil
+}
+
+// siteKey identifies where a URL comes from: its host without "www.", plus
+// the account for GitHub and X ("github.com/tokio-rs").
+func siteKey(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if accountHosts[host] {
+		account := strings.SplitN(strings.Trim(u.Path, "/"), "/", 2)[0]
+		if account == "" {
+			return ""
+		}
+		return host + "/" + strings.ToLower(account)
+	}
+	return host
+}
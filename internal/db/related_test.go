@@ -0,0 +1,76 @@
+package db
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSiteKey(t *testing.T) {
+	for raw, want := range map[string]string{
+		"https://www.example.com/post/1":         "example.com",
+		"https://github.com/tokio-rs/tokio":      "github.com/tokio-rs",
+		"https://x.com/rustlang/status/123":      "x.com/rustlang",
+		"https://GitHub.com/Tokio-rs/mio/issues": "github.com/tokio-rs",
+		"https://github.com/":                    "",
+		"not a url":                              "",
+	} {
+		if got := siteKey(raw); got != want {
+			t.Errorf("siteKey(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestRelated(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	tokio := &Bookmark{Source: "github", URL: "https://github.com/tokio-rs/tokio", Title: "tokio", Keywords: "rust, async"}
+	mio := &Bookmark{Source: "github", URL: "https://github.com/tokio-rs/mio", Title: "mio", Keywords: "rust"}
+	smol := &Bookmark{Source: "github", URL: "https://github.com/smol-rs/smol", Title: "smol", Keywords: "rust, async"}
+	nearby := &Bookmark{Source: "x", URL: "https://x.com/someone/status/1", Title: "Async runtimes compared"}
+	unrelated := &Bookmark{Source: "x", URL: "https://x.com/other/status/2", Title: "Sourdough"}
+	hidden := &Bookmark{Source: "github", URL: "https://github.com/tokio-rs/hidden", Title: "hidden", Keywords: "rust, async", Hidden: true}
+	for _, b := range []*Bookmark{tokio, mio, smol, nearby, unrelated, hidden} {
+		store.Upsert(b)
+	}
+	store.UpdateEmbedding(tokio.ID, []float32{1, 0, 0})
+	store.UpdateEmbedding(nearby.ID, []float32{0.9, 0.1, 0})
+	store.UpdateEmbedding(unrelated.ID, []float32{-1, 0, 0})
+	store.UpdateEmbedding(hidden.ID, []float32{1, 0, 0})
+
+	results, err := store.Related(tokio.ID, 10)
+	if err != nil {
+		t.Fatalf("Related failed: %v", err)
+	}
+	signals := make(map[string][]string)
+	for _, r := range results {
+		if r.ID == tokio.ID || r.ID == hidden.ID {
+			t.Errorf("unexpected result %s", r.URL)
+		}
+		for _, s := range r.Explain.Signals {
+			signals[r.ID] = append(signals[r.ID], s.Signal)
+		}
+	}
+	if got := signals[mio.ID]; len(got) != 2 || got[0] != SignalTags || got[1] != SignalDomain {
+		t.Errorf("mio: expected tags and domain signals, got %v", got)
+	}
+	if got := signals[smol.ID]; len(got) != 1 || got[0] != SignalTags {
+		t.Errorf("smol: expected tags signal, got %v", got)
+	}
+	if got := signals[nearby.ID]; len(got) != 1 || got[0] != SignalVector {
+		t.Errorf("nearby: expected vector signal, got %v", got)
+	}
+	if results[0].ID != mio.ID && results[0].ID != nearby.ID {
+		t.Errorf("expected the closest match first, got %s", results[0].URL)
+	}
+
+	if _, err := store.Related("missing", 10); err == nil {
+		t.Error("expected error for unknown bookmark")
+	}
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Release is a release or tag of a starred GitHub repository.
+type Release struct {
+	ID          int64     `json:"-"`
+	BookmarkID  string    `json:"bookmark_id"`
+	Repo        string    `json:"repo"` // owner/name
+	Tag         string    `json:"tag"`
+	Name        string    `json:"name,omitempty"`
+	URL         string    `json:"url"`
+	Body        string    `json:"body,omitempty"`
+	Prerelease  bool      `json:"prerelease,omitempty"`
+	PublishedAt time.Time `json:"published_at,omitempty"` // Zero for tags that existed before the repository was first checked
+	FoundAt     time.Time `json:"found_at"`
+}
+
+// Title is the release name, or the tag when the release has no name of its own.
+func (r Release) Title() string {
+	if r.Name != "" && r.Name != r.Tag {
+		return r.Tag + ": " + r.Name
+	}
+	return r.Tag
+}
+
+// ReleaseCheck is where release polling stands for a repository.
+type ReleaseCheck struct {
+	BookmarkID   string
+	Repo         string
+	ReleasesETag string
+	TagsETag     string
+	CheckedAt    time.Time // Zero if never checked
+}
+
+func (s *Store) migrateReleases() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS releases (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		bookmark_id TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		name TEXT DEFAULT '',
+		url TEXT NOT NULL,
+		body TEXT DEFAULT '',
+		prerelease INTEGER DEFAULT 0,
+		published_at TIMESTAMP,
+		found_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (bookmark_id, tag)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_releases_published ON releases(published_at);
+
+	CREATE TABLE IF NOT EXISTS release_checks (
+		bookmark_id TEXT PRIMARY KEY,
+		releases_etag TEXT DEFAULT '',
+		tags_etag TEXT DEFAULT '',
+		checked_at TIMESTAMP
+	);
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_releases_ad AFTER DELETE ON bookmarks BEGIN
+		DELETE FROM releases WHERE bookmark_id = old.id;
+		DELETE FROM release_checks WHERE bookmark_id = old.id;
+	END;
+	`)
+	return err
+}
+
+// AddReleases stores releases not seen before and returns them.
+func (s *Store) AddReleases(releases []Release) ([]Release, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var added []Release
+	now := time.Now()
+	for _, r := range releases {
+		var publishedAt interface{}
+		if !r.PublishedAt.IsZero() {
+			publishedAt = r.PublishedAt
+		}
+		res, err := tx.Exec(`INSERT OR IGNORE INTO releases (bookmark_id, repo, tag, name, url, body, prerelease, published_at, found_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.BookmarkID, r.Repo, r.Tag, r.Name, r.URL, r.Body, r.Prerelease, publishedAt, now)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			r.ID, _ = res.LastInsertId()
+			r.FoundAt = now
+			added = append(added, r)
+		}
+	}
+	return added, tx.Commit()
+}
+
+// RecentReleases returns up to limit releases published since the given time, newest first.
+func (s *Store) RecentReleases(since time.Time, limit int) ([]Release, error) {
+	rows, err := s.db.Query(`
+		SELECT id, bookmark_id, repo, tag, name, url, body, prerelease, published_at, found_at
+		FROM releases WHERE published_at >= ?
+		ORDER BY published_at DESC, id DESC LIMIT ?`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []Release
+	for rows.Next() {
+		var r Release
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.BookmarkID, &r.Repo, &r.Tag, &r.Name, &r.URL, &r.Body, &r.Prerelease, &publishedAt, &r.FoundAt); err != nil {
+			return nil, err
+		}
+		r.PublishedAt = publishedAt.Time
+		releases = append(releases, r)
+	}
+	return releases, rows.Err()
+}
+
+// ReleaseTargets returns up to limit GitHub bookmarks to poll for releases,
+// never checked first, then least recently checked.
+func (s *Store) ReleaseTargets(limit int) ([]ReleaseCheck, error) {
+	rows, err := s.db.Query(`
+		SELECT b.id, b.url, COALESCE(g.full_name, ''), COALESCE(c.releases_etag, ''), COALESCE(c.tags_etag, ''), c.checked_at
+		FROM bookmarks b
+		LEFT JOIN github_metadata g ON g.bookmark_id = b.id
+		LEFT JOIN release_checks c ON c.bookmark_id = b.id
+		WHERE b.source = 'github'
+		ORDER BY c.checked_at IS NOT NULL, c.checked_at
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []ReleaseCheck
+	for rows.Next() {
+		var c ReleaseCheck
+		var url string
+		var checkedAt sql.NullTime
+		if err := rows.Scan(&c.BookmarkID, &url, &c.Repo, &c.ReleasesETag, &c.TagsETag, &checkedAt); err != nil {
+			return nil, err
+		}
+		if c.Repo == "" {
+			var ok bool
+			if c.Repo, ok = repoFullName(url); !ok {
+				continue
+			}
+		}
+		c.CheckedAt = checkedAt.Time
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
+
+// SetReleaseCheck records a poll of a repository's releases.
+func (s *Store) SetReleaseCheck(c ReleaseCheck) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO release_checks (bookmark_id, releases_etag, tags_etag, checked_at) VALUES (?, ?, ?, ?)`,
+		c.BookmarkID, c.ReleasesETag, c.TagsETag, c.CheckedAt)
+	return err
+}
@@ -7,87 +7,143 @@ import (
 	"strings"
 )
 
-// addPrefixWildcard adds a wildcard to the end of each search term for partial matching
-// e.g., "matt pocock" -> "matt* pocock*"
-func addPrefixWildcard(query string) string {
-	// Split by whitespace and add * to each term
-	terms := strings.Fields(query)
-	if len(terms) == 0 {
-		return query
+// Search performs hybrid search combining BM25 (FTS5) and vector similarity.
+// The query uses the syntax described on Query; malformed filters return a *QueryError.
+func (s *Store) Search(query string, limit int) ([]SearchResult, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
 	}
-
-	for i := range terms {
-		terms[i] += "*"
+	if !q.HasText() {
+		return s.listMatching(q, limit)
 	}
 
-	return strings.Join(terms, " ")
-}
-
-// Search performs hybrid search combining BM25 (FTS5) and vector similarity
-func (s *Store) Search(query string, limit int) ([]Bookmark, error) {
-	if query == "" {
-		return s.List(nil, limit)
+	// Get FTS results with BM25 scores
+	ftsResults, err := s.ftsSearch(q, 50)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get FTS results with BM25 scores
-	// FTS5 can fail on special characters (spaces, quotes, operators)
-	// Gracefully fall back to listing if FTS fails
-	ftsResults, err := s.ftsSearch(query, 50)
+	// Get raw content results (if the content index is enabled)
+	contentResults, err := s.contentSearch(q, 50)
 	if err != nil {
-		// Fall back to simple listing when FTS5 query fails
-		ftsResults = nil
+		return nil, err
 	}
 
 	// Get vector results (if embeddings available)
-	vecResults, err := s.vectorSearch(query, 50)
+	vecResults, err := s.vectorSearch(q.Text(), 50)
 	if err != nil {
 		// Vector search may fail if no embeddings, continue with FTS only
 		vecResults = nil
 	}
 
-	// Combine results using reciprocal rank fusion
-	combined := hybridRank(ftsResults, vecResults)
+	// Combine results per the store's Ranking
+	combined, err := s.hybridRank(ftsResults, contentResults, vecResults)
+	if err != nil {
+		return nil, err
+	}
 
 	// Limit results
 	if len(combined) > limit {
 		combined = combined[:limit]
 	}
 
-	// Fetch full bookmarks
-	bookmarks := make([]Bookmark, 0, len(combined))
-	for _, sr := range combined {
+	return s.fetchRanked(combined), nil
+}
+
+// fetchRanked loads the bookmarks for ranked results, skipping any since deleted.
+func (s *Store) fetchRanked(ranked []scoredResult) []SearchResult {
+	results := make([]SearchResult, 0, len(ranked))
+	for _, sr := range ranked {
 		b, err := s.Get(sr.ID)
 		if err != nil {
 			continue
 		}
-		bookmarks = append(bookmarks, *b)
+		results = append(results, SearchResult{Bookmark: *b, Score: sr.Score, Highlights: sr.Highlights, Explain: sr.Explain})
 	}
+	s.attachLinkedFrom(results)
+	return results
+}
+
+// listMatching lists bookmarks passing the query's filters, in List order.
+func (s *Store) listMatching(q *Query, limit int) ([]SearchResult, error) {
+	where, args := q.filterSQL()
+	query := `SELECT ` + listColumns + ` FROM bookmarks b WHERE b.hidden = 0` + where +
+		` ORDER BY CASE WHEN b.source IN ('raindrop', 'github', 'x') THEN b.created_at ELSE b.updated_at END DESC LIMIT ?`
+	args = append(args, limit)
 
-	return bookmarks, nil
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	bookmarks, err := scanBookmarks(rows)
+	results := NewSearchResults(bookmarks)
+	s.attachLinkedFrom(results)
+	return results, err
+}
+
+// matchingIDs returns the visible bookmarks passing the query's filters, or nil
+// when the query has none.
+func (s *Store) matchingIDs(q *Query) (map[string]bool, error) {
+	where, args := q.filterSQL()
+	if where == "" {
+		return nil, nil
+	}
+	ids, err := s.queryStrings(`SELECT b.id FROM bookmarks b WHERE b.hidden = 0`+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		allowed[id] = true
+	}
+	return allowed, nil
 }
 
 type scoredResult struct {
-	ID    string
-	Score float64
-	Rank  int
+	ID         string
+	Score      float64
+	Rank       int
+	Highlights map[string]string
+	Explain    *Explanation
+}
+
+// rankedSignal is one signal's results, in rank order, with its fusion weight.
+type rankedSignal struct {
+	name    string
+	weight  float64
+	results []scoredResult
 }
 
-func (s *Store) ftsSearch(query string, limit int) ([]scoredResult, error) {
-	// FTS5 search with BM25 ranking
-	// Add wildcard for partial matching (e.g., "matt" -> "matt*")
-	query = addPrefixWildcard(query)
+// ftsHighlightFields are the bookmarks_fts columns ftsSearch marks matches in, in column order.
+var ftsHighlightFields = [...]string{FieldTitle, FieldSummary, FieldKeywords, FieldNotes, FieldAnnotations}
 
+func (s *Store) ftsSearch(q *Query, limit int) ([]scoredResult, error) {
+	// FTS5 search with BM25 ranking, restricted by the query's filters
+	where, filterArgs := q.filterSQL()
 	sqlQuery := `
-		SELECT b.id, bm25(bookmarks_fts) as score
+		SELECT b.id, bm25(bookmarks_fts, ?, ?, ?, ?, ?, ?) as score,
+			highlight(bookmarks_fts, 0, ?, ?),
+			snippet(bookmarks_fts, 1, ?, ?, '…', 16),
+			highlight(bookmarks_fts, 2, ?, ?),
+			snippet(bookmarks_fts, 3, ?, ?, '…', 16),
+			snippet(bookmarks_fts, 5, ?, ?, '…', 16)
 		FROM bookmarks_fts
 		JOIN bookmarks b ON bookmarks_fts.rowid = b.rowid
 		WHERE bookmarks_fts MATCH ?
-		AND b.hidden = 0
+		AND b.hidden = 0` + where + `
 		ORDER BY score
 		LIMIT ?
 	`
+	args := s.ranking.bm25Weights()
+	for range ftsHighlightFields {
+		args = append(args, HighlightOpen, HighlightClose)
+	}
+	args = append(args, q.MatchExpr())
+	args = append(args, filterArgs...)
+	args = append(args, limit)
 
-	rows, err := s.db.Query(sqlQuery, query, limit)
+	rows, err := s.db.Query(sqlQuery, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -98,10 +154,17 @@ func (s *Store) ftsSearch(query string, limit int) ([]scoredResult, error) {
 	for rows.Next() {
 		var id string
 		var score float64
-		if err := rows.Scan(&id, &score); err != nil {
+		var marked [len(ftsHighlightFields)]sql.NullString
+		if err := rows.Scan(&id, &score, &marked[0], &marked[1], &marked[2], &marked[3], &marked[4]); err != nil {
 			return nil, err
 		}
-		results = append(results, scoredResult{ID: id, Score: -score, Rank: rank}) // BM25 returns negative scores
+		highlights := make(map[string]string)
+		for i, field := range ftsHighlightFields {
+			if strings.Contains(marked[i].String, HighlightOpen) {
+				highlights[field] = marked[i].String
+			}
+		}
+		results = append(results, scoredResult{ID: id, Score: -score, Rank: rank, Highlights: highlights}) // BM25 returns negative scores
 		rank++
 	}
 
@@ -117,6 +180,12 @@ func (s *Store) vectorSearch(query string, limit int) ([]scoredResult, error) {
 
 // SearchWithEmbedding performs vector search with a pre-computed query embedding
 func (s *Store) SearchWithEmbedding(queryEmbedding []float32, limit int) ([]scoredResult, error) {
+	return s.searchWithEmbedding(queryEmbedding, nil, limit)
+}
+
+// searchWithEmbedding ranks stored embeddings by similarity; allowed, if non-nil,
+// restricts the candidates.
+func (s *Store) searchWithEmbedding(queryEmbedding []float32, allowed map[string]bool, limit int) ([]scoredResult, error) {
 	embeddings, err := s.GetAllWithEmbeddings()
 	if err != nil {
 		return nil, err
@@ -124,7 +193,7 @@ func (s *Store) SearchWithEmbedding(queryEmbedding []float32, limit int) ([]scor
 
 	var results []scoredResult
 	for id, emb := range embeddings {
-		if len(emb) == 0 {
+		if len(emb) == 0 || (allowed != nil && !allowed[id]) {
 			continue
 		}
 		score := cosineSimilarity(queryEmbedding, emb)
@@ -148,70 +217,61 @@ func (s *Store) SearchWithEmbedding(queryEmbedding []float32, limit int) ([]scor
 	return results, nil
 }
 
-// HybridSearchWithEmbedding combines FTS and vector search
-func (s *Store) HybridSearchWithEmbedding(query string, queryEmbedding []float32, limit int) ([]Bookmark, error) {
-	if query == "" {
-		return s.List(nil, limit)
+// HybridSearchWithEmbedding combines FTS and vector search. queryEmbedding should
+// embed the query's text terms (Query.Text); filters apply to both result sets.
+func (s *Store) HybridSearchWithEmbedding(query string, queryEmbedding []float32, limit int) ([]SearchResult, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if !q.HasText() {
+		return s.listMatching(q, limit)
 	}
 
 	// Get FTS results
-	ftsResults, err := s.ftsSearch(query, 50)
-	if err != nil && err != sql.ErrNoRows {
-		// FTS might fail on special characters, continue without it
-		ftsResults = nil
+	ftsResults, err := s.ftsSearch(q, 50)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get raw content results
+	contentResults, err := s.contentSearch(q, 50)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get vector results
-	vecResults, err := s.SearchWithEmbedding(queryEmbedding, 50)
+	allowed, err := s.matchingIDs(q)
+	if err != nil {
+		return nil, err
+	}
+	vecResults, err := s.searchWithEmbedding(queryEmbedding, allowed, 50)
 	if err != nil {
 		vecResults = nil
 	}
 
 	// Combine results
-	combined := hybridRank(ftsResults, vecResults)
+	combined, err := s.hybridRank(ftsResults, contentResults, vecResults)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(combined) > limit {
 		combined = combined[:limit]
 	}
 
-	// Fetch full bookmarks
-	bookmarks := make([]Bookmark, 0, len(combined))
-	for _, sr := range combined {
-		b, err := s.Get(sr.ID)
-		if err != nil {
-			continue
-		}
-		bookmarks = append(bookmarks, *b)
-	}
-
-	return bookmarks, nil
+	return s.fetchRanked(combined), nil
 }
 
-// hybridRank combines results using Reciprocal Rank Fusion (RRF)
-func hybridRank(ftsResults, vecResults []scoredResult) []scoredResult {
-	const k = 60 // RRF constant
-
-	scores := make(map[string]float64)
-
-	for _, r := range ftsResults {
-		scores[r.ID] += 1.0 / (float64(k) + float64(r.Rank))
-	}
-
-	for _, r := range vecResults {
-		scores[r.ID] += 1.0 / (float64(k) + float64(r.Rank))
-	}
-
-	var results []scoredResult
-	for id, score := range scores {
-		results = append(results, scoredResult{ID: id, Score: score})
-	}
-
-	// Sort by combined score descending
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
-
-	return results
+// hybridRank fuses the FTS, raw content and vector results and applies boosts.
+func (s *Store) hybridRank(ftsResults, contentResults, vecResults []scoredResult) ([]scoredResult, error) {
+	r := s.ranking
+	combined := r.fuse(
+		rankedSignal{SignalFTS, r.signalWeight(SignalFTS), ftsResults},
+		rankedSignal{SignalContent, r.signalWeight(SignalContent), contentResults},
+		rankedSignal{SignalVector, r.signalWeight(SignalVector), vecResults},
+	)
+	return s.boost(combined)
 }
 
 func cosineSimilarity(a, b []float32) float64 {
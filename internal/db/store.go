@@ -5,15 +5,21 @@ import (
 	"database/sql"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math"
 	"path/filepath"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/user/xhub/internal/urlnorm"
 )
 
 type Store struct {
-	db *sql.DB
+	db         *sql.DB
+	contentFTS bool                // Raw content is indexed in bookmarks_content_fts
+	ranking    Ranking             // How search results are scored
+	urls       *urlnorm.Normalizer // Canonicalizes URLs on the way in
 }
 
 func NewStore(dataDir string) (*Store, error) {
@@ -23,7 +29,7 @@ func NewStore(dataDir string) (*Store, error) {
 		return nil, err
 	}
 
-	s := &Store{db: db}
+	s := &Store{db: db, ranking: DefaultRanking()}
 	if err := s.migrate(); err != nil {
 		db.Close()
 		return nil, err
@@ -66,6 +72,40 @@ func (s *Store) migrate() error {
 		key TEXT PRIMARY KEY,
 		value TEXT
 	);
+
+	CREATE TABLE IF NOT EXISTS tag_proposals (
+		tag TEXT NOT NULL,
+		bookmark_id TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (tag, bookmark_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE
+	);
+
+	CREATE TABLE IF NOT EXISTS bookmark_tags (
+		bookmark_id TEXT NOT NULL,
+		tag_id INTEGER NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (bookmark_id, tag_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_bookmark_tags_tag ON bookmark_tags(tag_id);
+
+	CREATE TABLE IF NOT EXISTS llm_cache (
+		kind TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (kind, key)
+	);
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_tags_ad AFTER DELETE ON bookmarks BEGIN
+		DELETE FROM bookmark_tags WHERE bookmark_id = old.id;
+	END;
 	`
 
 	_, err := s.db.Exec(schema)
@@ -73,10 +113,61 @@ func (s *Store) migrate() error {
 		return err
 	}
 
-	// Check if FTS table needs to be rebuilt (add url column)
+	if err := s.addColumnIfMissing("bookmarks", "provenance", "TEXT DEFAULT '{}'"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("bookmarks", "status_reason", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("bookmarks", "original_url", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.migrateLinks(); err != nil {
+		return err
+	}
+	if err := s.migrateTweets(); err != nil {
+		return err
+	}
+	if err := s.migrateGitHub(); err != nil {
+		return err
+	}
+	if err := s.migrateReleases(); err != nil {
+		return err
+	}
+	if err := s.migrateRaindrop(); err != nil {
+		return err
+	}
+	if err := s.migrateTags(); err != nil {
+		return err
+	}
+	if err := s.migrateCollections(); err != nil {
+		return err
+	}
+	if err := s.migrateDedupe(); err != nil {
+		return err
+	}
+	if err := s.detectContentIndex(); err != nil {
+		return err
+	}
+
+	// Check if FTS table needs to be rebuilt (add url and annotations columns)
 	return s.migrateFTS()
 }
 
+// addColumnIfMissing adds a column to an existing table created by an older version.
+func (s *Store) addColumnIfMissing(table, column, definition string) error {
+	var name string
+	err := s.db.QueryRow(`SELECT name FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&name)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+	_, err = s.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
 func (s *Store) migrateFTS() error {
 	// Check if bookmarks_fts table exists and has url column
 	var tableName string
@@ -89,14 +180,17 @@ func (s *Store) migrateFTS() error {
 		return s.createFTSTable()
 	}
 
-	// Check if url column exists
-	var colName string
+	// Check if the url and annotations columns exist
+	var columns int
 	err = s.db.QueryRow(`
-		SELECT name FROM pragma_table_info('bookmarks_fts') 
-		WHERE name='url'
-	`).Scan(&colName)
+		SELECT COUNT(*) FROM pragma_table_info('bookmarks_fts') 
+		WHERE name IN ('url', 'annotations')
+	`).Scan(&columns)
 	if err != nil {
-		// url column doesn't exist, need to rebuild FTS table
+		return err
+	}
+	if columns < 2 {
+		// A column doesn't exist, need to rebuild FTS table
 		return s.rebuildFTSTable()
 	}
 
@@ -106,26 +200,26 @@ func (s *Store) migrateFTS() error {
 func (s *Store) createFTSTable() error {
 	schema := `
 	CREATE VIRTUAL TABLE IF NOT EXISTS bookmarks_fts USING fts5(
-		title, summary, keywords, notes, url,
+		title, summary, keywords, notes, url, annotations,
 		content='bookmarks',
 		content_rowid='rowid'
 	);
 
 	CREATE TRIGGER IF NOT EXISTS bookmarks_ai AFTER INSERT ON bookmarks BEGIN
-		INSERT INTO bookmarks_fts(rowid, title, summary, keywords, notes, url)
-		VALUES (new.rowid, new.title, new.summary, new.keywords, new.notes, new.url);
+		INSERT INTO bookmarks_fts(rowid, title, summary, keywords, notes, url, annotations)
+		VALUES (new.rowid, new.title, new.summary, new.keywords, new.notes, new.url, new.annotations);
 	END;
 
 	CREATE TRIGGER IF NOT EXISTS bookmarks_ad AFTER DELETE ON bookmarks BEGIN
-		INSERT INTO bookmarks_fts(bookmarks_fts, rowid, title, summary, keywords, notes, url)
-		VALUES ('delete', old.rowid, old.title, old.summary, old.keywords, old.notes, old.url);
+		INSERT INTO bookmarks_fts(bookmarks_fts, rowid, title, summary, keywords, notes, url, annotations)
+		VALUES ('delete', old.rowid, old.title, old.summary, old.keywords, old.notes, old.url, old.annotations);
 	END;
 
 	CREATE TRIGGER IF NOT EXISTS bookmarks_au AFTER UPDATE ON bookmarks BEGIN
-		INSERT INTO bookmarks_fts(bookmarks_fts, rowid, title, summary, keywords, notes, url)
-		VALUES ('delete', old.rowid, old.title, old.summary, old.keywords, old.notes, old.url);
-		INSERT INTO bookmarks_fts(rowid, title, summary, keywords, notes, url)
-		VALUES (new.rowid, new.title, new.summary, new.keywords, new.notes, new.url);
+		INSERT INTO bookmarks_fts(bookmarks_fts, rowid, title, summary, keywords, notes, url, annotations)
+		VALUES ('delete', old.rowid, old.title, old.summary, old.keywords, old.notes, old.url, old.annotations);
+		INSERT INTO bookmarks_fts(rowid, title, summary, keywords, notes, url, annotations)
+		VALUES (new.rowid, new.title, new.summary, new.keywords, new.notes, new.url, new.annotations);
 	END;
 	`
 
@@ -136,8 +230,8 @@ func (s *Store) createFTSTable() error {
 
 	// Populate FTS table with existing data
 	_, err = s.db.Exec(`
-		INSERT INTO bookmarks_fts(rowid, title, summary, keywords, notes, url)
-		SELECT rowid, title, summary, keywords, notes, url FROM bookmarks
+		INSERT INTO bookmarks_fts(rowid, title, summary, keywords, notes, url, annotations)
+		SELECT rowid, title, summary, keywords, notes, url, annotations FROM bookmarks
 	`)
 	return err
 }
@@ -163,7 +257,7 @@ func (s *Store) rebuildFTSTable() error {
 		return err
 	}
 
-	// Create new FTS table with url column
+	// Create new FTS table with the current columns
 	return s.createFTSTable()
 }
 
@@ -172,13 +266,82 @@ func generateID(url string) string {
 	return hex.EncodeToString(hash[:8])
 }
 
+// bookmarkColumns lists the columns read by scanBookmark, in order.
+const bookmarkColumns = `id, source, url, original_url, title, summary, keywords, notes, raw_content, created_at, updated_at, scraped_at, scrape_status, status_reason, hidden, provenance, parent_id, starred`
+
+// listColumns is bookmarkColumns without the (large) raw content.
+const listColumns = `id, source, url, original_url, title, summary, keywords, notes, '' AS raw_content, created_at, updated_at, scraped_at, scrape_status, status_reason, hidden, provenance, parent_id, starred`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanBookmark reads a row selected with bookmarkColumns or listColumns.
+func scanBookmark(row rowScanner) (*Bookmark, error) {
+	var b Bookmark
+	var originalURL, title, summary, keywords, notes, rawContent, reason, provenance, parentID sql.NullString
+	var scrapedAt sql.NullTime
+	err := row.Scan(
+		&b.ID, &b.Source, &b.URL, &originalURL, &title, &summary, &keywords, &notes, &rawContent,
+		&b.CreatedAt, &b.UpdatedAt, &scrapedAt, &b.ScrapeStatus, &reason, &b.Hidden, &provenance, &parentID, &b.Starred,
+	)
+	if err != nil {
+		return nil, err
+	}
+	b.OriginalURL = originalURL.String
+	b.Title = title.String
+	b.Summary = summary.String
+	b.Keywords = keywords.String
+	b.Notes = notes.String
+	b.RawContent = rawContent.String
+	b.StatusReason = reason.String
+	b.ParentID = parentID.String
+	if scrapedAt.Valid {
+		b.ScrapedAt = scrapedAt.Time
+	}
+	if provenance.String != "" {
+		json.Unmarshal([]byte(provenance.String), &b.Provenance)
+	}
+	return &b, nil
+}
+
+func scanBookmarks(rows *sql.Rows) ([]Bookmark, error) {
+	defer rows.Close()
+	var bookmarks []Bookmark
+	for rows.Next() {
+		b, err := scanBookmark(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, *b)
+	}
+	return bookmarks, rows.Err()
+}
+
+func encodeProvenance(p Provenance) string {
+	if len(p) == 0 {
+		return "{}"
+	}
+	data, _ := json.Marshal(p)
+	return string(data)
+}
+
 func (s *Store) Upsert(b *Bookmark) error {
 	_, err := s.UpsertReturningNew(b)
 	return err
 }
 
 // UpsertReturningNew inserts or updates a bookmark and returns true if it was a new insert.
+// b.URL is canonicalized first, keeping the URL as given in b.OriginalURL.
+// Fields the user edited by hand are never overwritten by an update, and b.ID is
+// set to the stored bookmark's. A URL that was merged into another bookmark is
+// left alone, with b.ID set to that bookmark.
 func (s *Store) UpsertReturningNew(b *Bookmark) (bool, error) {
+	s.canonicalize(b)
+	if keptID := s.aliasOf(b.URL, b.OriginalURL); keptID != "" {
+		b.ID = keptID
+		return false, nil
+	}
 	if b.ID == "" {
 		b.ID = generateID(b.URL)
 	}
@@ -188,23 +351,47 @@ func (s *Store) UpsertReturningNew(b *Bookmark) (bool, error) {
 		b.CreatedAt = now
 	}
 
+	// Values without a recorded origin come from the source
+	for field, value := range map[string]string{
+		FieldTitle: b.Title, FieldSummary: b.Summary, FieldKeywords: b.Keywords, FieldNotes: b.Notes,
+	} {
+		if value != "" && b.Provenance[field] == "" {
+			b.SetOrigin(field, OriginSource)
+		}
+	}
+
 	// Check if URL already exists
 	var existingID string
-	err := s.db.QueryRow(`SELECT id FROM bookmarks WHERE url = ?`, b.URL).Scan(&existingID)
+	var existingProvenance sql.NullString
+	err := s.db.QueryRow(`SELECT id, provenance FROM bookmarks WHERE url = ?`, b.URL).Scan(&existingID, &existingProvenance)
 	isNew := err == sql.ErrNoRows
 
+	// Keep user origins from the stored row; the SQL below keeps their values
+	if existingProvenance.String != "" {
+		var existing Provenance
+		json.Unmarshal([]byte(existingProvenance.String), &existing)
+		for field, origin := range existing {
+			if origin == OriginUser {
+				b.SetOrigin(field, OriginUser)
+			}
+		}
+	}
+
 	query := `
-	INSERT INTO bookmarks (id, source, url, title, summary, keywords, notes, raw_content, created_at, updated_at, scraped_at, scrape_status, hidden)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO bookmarks (id, source, url, original_url, title, summary, keywords, notes, raw_content, created_at, updated_at, scraped_at, scrape_status, status_reason, hidden, provenance, parent_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	ON CONFLICT(url) DO UPDATE SET
-		title = COALESCE(excluded.title, bookmarks.title),
-		summary = COALESCE(excluded.summary, bookmarks.summary),
-		keywords = COALESCE(excluded.keywords, bookmarks.keywords),
-		notes = COALESCE(excluded.notes, bookmarks.notes),
+		original_url = COALESCE(NULLIF(bookmarks.original_url, ''), excluded.original_url),
+		title = CASE WHEN json_extract(bookmarks.provenance, '$.title') = 'user' THEN bookmarks.title ELSE COALESCE(excluded.title, bookmarks.title) END,
+		summary = CASE WHEN json_extract(bookmarks.provenance, '$.summary') = 'user' THEN bookmarks.summary ELSE COALESCE(excluded.summary, bookmarks.summary) END,
+		keywords = CASE WHEN json_extract(bookmarks.provenance, '$.keywords') = 'user' THEN bookmarks.keywords ELSE COALESCE(excluded.keywords, bookmarks.keywords) END,
+		notes = CASE WHEN json_extract(bookmarks.provenance, '$.notes') = 'user' THEN bookmarks.notes ELSE COALESCE(excluded.notes, bookmarks.notes) END,
 		raw_content = COALESCE(excluded.raw_content, bookmarks.raw_content),
 		updated_at = excluded.updated_at,
 		scraped_at = COALESCE(excluded.scraped_at, bookmarks.scraped_at),
-		scrape_status = COALESCE(excluded.scrape_status, bookmarks.scrape_status)
+		scrape_status = COALESCE(excluded.scrape_status, bookmarks.scrape_status),
+		status_reason = excluded.status_reason,
+		provenance = excluded.provenance
 	`
 
 	var scrapedAt interface{}
@@ -213,49 +400,104 @@ func (s *Store) UpsertReturningNew(b *Bookmark) (bool, error) {
 	}
 
 	_, err = s.db.Exec(query,
-		b.ID, b.Source, b.URL, b.Title, b.Summary, b.Keywords, b.Notes, b.RawContent,
-		b.CreatedAt, b.UpdatedAt, scrapedAt, b.ScrapeStatus, b.Hidden,
+		b.ID, b.Source, b.URL, b.OriginalURL, b.Title, b.Summary, b.Keywords, b.Notes, b.RawContent,
+		b.CreatedAt, b.UpdatedAt, scrapedAt, b.ScrapeStatus, b.StatusReason, b.Hidden, encodeProvenance(b.Provenance), b.ParentID,
 	)
-	return isNew, err
+	if err != nil {
+		return isNew, err
+	}
+	// The stored row keeps its ID, which differs from the generated one for
+	// rows saved under an older URL and since canonicalized
+	if !isNew {
+		b.ID = existingID
+	}
+	if b.Tweet != nil {
+		if err := s.SetTweetMetadata(b.ID, b.Tweet); err != nil {
+			return isNew, err
+		}
+	}
+	if b.Repo != nil {
+		if err := s.SetRepoMetadata(b.ID, b.Repo); err != nil {
+			return isNew, err
+		}
+	}
+	if b.Raindrop != nil {
+		if err := s.SetRaindropMetadata(b.ID, b.Raindrop); err != nil {
+			return isNew, err
+		}
+	}
+	return isNew, s.syncTags(b.ID)
 }
 
-func (s *Store) Get(id string) (*Bookmark, error) {
-	query := `SELECT id, source, url, title, summary, keywords, notes, raw_content, created_at, updated_at, scraped_at, scrape_status, hidden FROM bookmarks WHERE id = ?`
-
-	var b Bookmark
-	var scrapedAt sql.NullTime
-	err := s.db.QueryRow(query, id).Scan(
-		&b.ID, &b.Source, &b.URL, &b.Title, &b.Summary, &b.Keywords, &b.Notes, &b.RawContent,
-		&b.CreatedAt, &b.UpdatedAt, &scrapedAt, &b.ScrapeStatus, &b.Hidden,
-	)
+// RefreshFromSource updates the title, keywords and notes of bookmark id to
+// the values in b, which its source has now. As in UpsertReturningNew, fields
+// the user edited keep their value; an empty value only clears a field that
+// came from the source. Content, summary and scrape status are left alone.
+func (s *Store) RefreshFromSource(id string, b *Bookmark) error {
+	stored, err := s.Get(id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if scrapedAt.Valid {
-		b.ScrapedAt = scrapedAt.Time
+	changed := false
+	for field, values := range map[string][2]*string{
+		FieldTitle:    {&stored.Title, &b.Title},
+		FieldKeywords: {&stored.Keywords, &b.Keywords},
+		FieldNotes:    {&stored.Notes, &b.Notes},
+	} {
+		current, value := values[0], values[1]
+		origin := stored.Provenance[field]
+		if origin == OriginUser || *current == *value || (*value == "" && origin != OriginSource && origin != "") {
+			continue
+		}
+		*current = *value
+		if *value != "" {
+			stored.SetOrigin(field, OriginSource)
+		}
+		changed = true
 	}
-	return &b, nil
+	if !changed {
+		return nil
+	}
+	return s.Update(stored)
 }
 
-func (s *Store) GetByURL(url string) (*Bookmark, error) {
-	query := `SELECT id, source, url, title, summary, keywords, notes, raw_content, created_at, updated_at, scraped_at, scrape_status, hidden FROM bookmarks WHERE url = ?`
+func (s *Store) Get(id string) (*Bookmark, error) {
+	return scanBookmark(s.db.QueryRow(`SELECT `+bookmarkColumns+` FROM bookmarks WHERE id = ?`, id))
+}
 
-	var b Bookmark
-	var scrapedAt sql.NullTime
-	err := s.db.QueryRow(query, url).Scan(
-		&b.ID, &b.Source, &b.URL, &b.Title, &b.Summary, &b.Keywords, &b.Notes, &b.RawContent,
-		&b.CreatedAt, &b.UpdatedAt, &scrapedAt, &b.ScrapeStatus, &b.Hidden,
-	)
-	if err != nil {
-		return nil, err
-	}
-	if scrapedAt.Valid {
-		b.ScrapedAt = scrapedAt.Time
+// GetByURL returns the bookmark saved under url, its canonical form or that
+// form with the other of http and https, or the one it was merged into.
+func (s *Store) GetByURL(url string) (*Bookmark, error) {
+	canonical := s.urls.Canonical(url)
+	other := otherScheme(canonical)
+	b, err := scanBookmark(s.db.QueryRow(`SELECT `+bookmarkColumns+` FROM bookmarks WHERE url IN (?, ?, ?) ORDER BY url = ? DESC, url = ? DESC LIMIT 1`, canonical, url, other, canonical, url))
+	if err == sql.ErrNoRows {
+		if keptID := s.aliasOf(canonical, url, other); keptID != "" {
+			return s.Get(keptID)
+		}
 	}
-	return &b, nil
+	return b, err
 }
 
 func (s *Store) Delete(id string) error {
+	// Pages saved only because this bookmark linked to them go with it. Those
+	// other bookmarks link to as well are kept for the oldest of them.
+	children, _ := s.queryStrings(`SELECT id FROM bookmarks WHERE parent_id = ? AND id != ?`, id, id)
+	for _, child := range children {
+		var next string
+		s.db.QueryRow(`
+			SELECT l.bookmark_id FROM bookmark_links l
+			JOIN bookmarks b ON b.id = l.bookmark_id
+			WHERE l.linked_id = ? AND l.bookmark_id NOT IN (?, ?)
+			ORDER BY b.created_at, b.id LIMIT 1`, child, id, child).Scan(&next)
+		if next == "" {
+			s.Delete(child)
+			continue
+		}
+		if _, err := s.db.Exec(`UPDATE bookmarks SET parent_id = ? WHERE id = ?`, next, child); err != nil {
+			return err
+		}
+	}
 	// Delete embedding first
 	_, _ = s.db.Exec(`DELETE FROM bookmarks_vec WHERE id = ?`, id)
 	// Delete bookmark
@@ -264,7 +506,7 @@ func (s *Store) Delete(id string) error {
 }
 
 func (s *Store) List(sources []string, limit int) ([]Bookmark, error) {
-	query := `SELECT id, source, url, title, summary, keywords, notes, created_at, updated_at, scrape_status, hidden FROM bookmarks WHERE hidden = 0`
+	query := `SELECT ` + listColumns + ` FROM bookmarks WHERE hidden = 0`
 
 	var args []interface{}
 	if len(sources) > 0 {
@@ -286,41 +528,48 @@ func (s *Store) List(sources []string, limit int) ([]Bookmark, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var bookmarks []Bookmark
-	for rows.Next() {
-		var b Bookmark
-		if err := rows.Scan(&b.ID, &b.Source, &b.URL, &b.Title, &b.Summary, &b.Keywords, &b.Notes, &b.CreatedAt, &b.UpdatedAt, &b.ScrapeStatus, &b.Hidden); err != nil {
-			return nil, err
-		}
-		bookmarks = append(bookmarks, b)
-	}
-	return bookmarks, rows.Err()
+	return scanBookmarks(rows)
 }
 
-func (s *Store) GetPending(limit int) ([]Bookmark, error) {
-	query := `SELECT id, source, url, title, summary, keywords, notes, raw_content, created_at, updated_at, scraped_at, scrape_status, hidden FROM bookmarks WHERE scrape_status = 'pending' OR scrape_status = 'failed' LIMIT ?`
+// GetPending returns bookmarks waiting to be scraped and summarized. With
+// includeBatched, those submitted to a batch that may never be polled again
+// are included, for runs that don't use batch mode.
+func (s *Store) GetPending(limit int, includeBatched bool) ([]Bookmark, error) {
+	query := `SELECT ` + bookmarkColumns + ` FROM bookmarks WHERE scrape_status = 'pending' OR scrape_status = 'failed' LIMIT ?`
+	if includeBatched {
+		query = `SELECT ` + bookmarkColumns + ` FROM bookmarks WHERE scrape_status IN ('pending', 'failed', 'batched') LIMIT ?`
+	}
 
 	rows, err := s.db.Query(query, limit)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanBookmarks(rows)
+}
 
-	var bookmarks []Bookmark
-	for rows.Next() {
-		var b Bookmark
-		var scrapedAt sql.NullTime
-		if err := rows.Scan(&b.ID, &b.Source, &b.URL, &b.Title, &b.Summary, &b.Keywords, &b.Notes, &b.RawContent, &b.CreatedAt, &b.UpdatedAt, &scrapedAt, &b.ScrapeStatus, &b.Hidden); err != nil {
-			return nil, err
-		}
-		if scrapedAt.Valid {
-			b.ScrapedAt = scrapedAt.Time
-		}
-		bookmarks = append(bookmarks, b)
+// GetNeedingSummary returns visible bookmarks with raw content but no summary,
+// most recently updated first. A limit of 0 returns all of them.
+func (s *Store) GetNeedingSummary(limit int) ([]Bookmark, error) {
+	query := `
+		SELECT ` + bookmarkColumns + `
+		FROM bookmarks
+		WHERE raw_content != ''
+		AND (summary = '' OR summary IS NULL)
+		AND hidden = 0
+		ORDER BY updated_at DESC
+	`
+
+	var rows *sql.Rows
+	var err error
+	if limit > 0 {
+		rows, err = s.db.Query(query+" LIMIT ?", limit)
+	} else {
+		rows, err = s.db.Query(query)
 	}
-	return bookmarks, rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	return scanBookmarks(rows)
 }
 
 func (s *Store) UpdateEmbedding(id string, embedding []float32) error {
@@ -371,15 +620,18 @@ func (s *Store) Count() (int, error) {
 func (s *Store) Update(b *Bookmark) error {
 	b.UpdatedAt = time.Now()
 
-	query := `UPDATE bookmarks SET title = ?, summary = ?, keywords = ?, notes = ?, raw_content = ?, updated_at = ?, scraped_at = ?, scrape_status = ?, hidden = ? WHERE id = ?`
+	query := `UPDATE bookmarks SET title = ?, summary = ?, keywords = ?, notes = ?, raw_content = ?, updated_at = ?, scraped_at = ?, scrape_status = ?, status_reason = ?, hidden = ?, provenance = ? WHERE id = ?`
 
 	var scrapedAt interface{}
 	if !b.ScrapedAt.IsZero() {
 		scrapedAt = b.ScrapedAt
 	}
 
-	_, err := s.db.Exec(query, b.Title, b.Summary, b.Keywords, b.Notes, b.RawContent, b.UpdatedAt, scrapedAt, b.ScrapeStatus, b.Hidden, b.ID)
-	return err
+	_, err := s.db.Exec(query, b.Title, b.Summary, b.Keywords, b.Notes, b.RawContent, b.UpdatedAt, scrapedAt, b.ScrapeStatus, b.StatusReason, b.Hidden, encodeProvenance(b.Provenance), b.ID)
+	if err != nil {
+		return err
+	}
+	return s.syncTags(b.ID)
 }
 
 func (s *Store) GetAllWithEmbeddings() (map[string][]float32, error) {
@@ -414,14 +666,15 @@ func (s *Store) GetOrphanedBySource(source string, currentURLs []string) ([]Book
 	}
 
 	// Build URL set for exclusion
-	query := `SELECT id, source, url, title FROM bookmarks WHERE source = ? AND url NOT IN (`
+	// Match both forms: rows saved before canonicalization keep the URL as given
+	query := `SELECT id, source, url, title FROM bookmarks WHERE source = ? AND COALESCE(parent_id, '') = '' AND url NOT IN (`
 	args := []interface{}{source}
 	for i, url := range currentURLs {
 		if i > 0 {
 			query += ","
 		}
-		query += "?"
-		args = append(args, url)
+		query += "?,?"
+		args = append(args, url, s.urls.Canonical(url))
 	}
 	query += `)`
 
@@ -442,9 +695,10 @@ func (s *Store) GetOrphanedBySource(source string, currentURLs []string) ([]Book
 	return orphans, rows.Err()
 }
 
-// getBookmarksBySource returns all bookmarks from a given source.
+// getBookmarksBySource returns all bookmarks from a given source, except pages
+// saved as links from another bookmark.
 func (s *Store) getBookmarksBySource(source string) ([]Bookmark, error) {
-	query := `SELECT id, source, url, title FROM bookmarks WHERE source = ?`
+	query := `SELECT id, source, url, title FROM bookmarks WHERE source = ? AND COALESCE(parent_id, '') = ''`
 	rows, err := s.db.Query(query, source)
 	if err != nil {
 		return nil, err
@@ -463,13 +717,27 @@ func (s *Store) getBookmarksBySource(source string) ([]Bookmark, error) {
 }
 
 // MarkForReprocess resets items to pending so they get re-scraped/re-summarized/re-embedded.
-// Clears raw_content, summary, keywords to force full reprocessing.
+// Clears raw_content, summary, keywords to force full reprocessing, keeping hand-edited values.
 func (s *Store) MarkForReprocess(ids []string) error {
+	return s.ResetForReprocess(ids, false)
+}
+
+// ResetForReprocess resets items to pending. With overwriteEdits, hand-edited
+// fields are cleared too and lose their user provenance so they can be regenerated.
+func (s *Store) ResetForReprocess(ids []string, overwriteEdits bool) error {
 	if len(ids) == 0 {
 		return nil
 	}
 
-	query := `UPDATE bookmarks SET scrape_status = 'pending', raw_content = '', summary = '', keywords = '' WHERE id IN (`
+	query := `UPDATE bookmarks SET scrape_status = 'pending', status_reason = '', raw_content = '',
+		summary = CASE WHEN json_extract(provenance, '$.summary') = 'user' THEN summary ELSE '' END,
+		keywords = CASE WHEN json_extract(provenance, '$.keywords') = 'user' THEN keywords ELSE '' END
+		WHERE id IN (`
+	if overwriteEdits {
+		query = `UPDATE bookmarks SET scrape_status = 'pending', status_reason = '', raw_content = '', summary = '', keywords = '',
+			provenance = json_remove(COALESCE(provenance, '{}'), '$.title', '$.summary', '$.keywords', '$.notes')
+			WHERE id IN (`
+	}
 	args := make([]interface{}, len(ids))
 	for i, id := range ids {
 		if i > 0 {
@@ -480,6 +748,8 @@ func (s *Store) MarkForReprocess(ids []string) error {
 	}
 	query += `)`
 
-	_, err := s.db.Exec(query, args...)
-	return err
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return err
+	}
+	return s.syncTags(ids...)
 }
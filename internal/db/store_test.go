@@ -89,3 +89,206 @@ func TestMarkForReprocess(t *testing.T) {
         t.Error("Expected keywords to be cleared")
     }
 }
+
+func TestUpsertKeepsUserEditedFields(t *testing.T) {
+    tmpDir, _ := os.MkdirTemp("", "xhub-test")
+    defer os.RemoveAll(tmpDir)
+
+    store, err := NewStore(tmpDir)
+    if err != nil {
+        t.Fatalf("Failed to create store: %v", err)
+    }
+    defer store.Close()
+
+    b := &Bookmark{
+        Source: "raindrop",
+        URL:    "https://example.com/post",
+        Title:  "Source title",
+        Notes:  "Source note",
+    }
+    store.Upsert(b)
+
+    got, _ := store.Get(b.ID)
+    if got.Provenance[FieldTitle] != OriginSource {
+        t.Fatalf("Expected source provenance for title, got %q", got.Provenance[FieldTitle])
+    }
+
+    // User edits the title
+    got.Title = "My title"
+    got.SetOrigin(FieldTitle, OriginUser)
+    if err := store.Update(got); err != nil {
+        t.Fatalf("Failed to update: %v", err)
+    }
+
+    // Source sync brings new values for both fields
+    again := &Bookmark{
+        Source: "raindrop",
+        URL:    "https://example.com/post",
+        Title:  "Renamed upstream",
+        Notes:  "Updated note",
+    }
+    store.Upsert(again)
+
+    got, _ = store.Get(b.ID)
+    if got.Title != "My title" {
+        t.Errorf("Expected user title to survive upsert, got %q", got.Title)
+    }
+    if !got.IsUserEdited(FieldTitle) {
+        t.Error("Expected title to stay user-edited")
+    }
+    if got.Notes != "Updated note" {
+        t.Errorf("Expected notes from source, got %q", got.Notes)
+    }
+}
+
+func TestResetForReprocessRespectsUserEdits(t *testing.T) {
+    tmpDir, _ := os.MkdirTemp("", "xhub-test")
+    defer os.RemoveAll(tmpDir)
+
+    store, err := NewStore(tmpDir)
+    if err != nil {
+        t.Fatalf("Failed to create store: %v", err)
+    }
+    defer store.Close()
+
+    b := &Bookmark{
+        Source:       "github",
+        URL:          "https://github.com/test/repo",
+        Title:        "Test",
+        ScrapeStatus: "success",
+        RawContent:   "Test content",
+        Summary:      "Hand-written summary",
+        Keywords:     "llm, keywords",
+        Provenance:   Provenance{FieldSummary: OriginUser, FieldKeywords: OriginLLM},
+    }
+    store.Upsert(b)
+
+    if err := store.MarkForReprocess([]string{b.ID}); err != nil {
+        t.Fatalf("Failed to mark for reprocess: %v", err)
+    }
+    got, _ := store.Get(b.ID)
+    if got.Summary != "Hand-written summary" {
+        t.Errorf("Expected user summary to be kept, got %q", got.Summary)
+    }
+    if got.Keywords != "" {
+        t.Errorf("Expected LLM keywords to be cleared, got %q", got.Keywords)
+    }
+
+    if err := store.ResetForReprocess([]string{b.ID}, true); err != nil {
+        t.Fatalf("Failed to force reprocess: %v", err)
+    }
+    got, _ = store.Get(b.ID)
+    if got.Summary != "" || got.IsUserEdited(FieldSummary) {
+        t.Errorf("Expected forced reprocess to clear user summary, got %q (%v)", got.Summary, got.Provenance)
+    }
+}
+
+func TestTagProposals(t *testing.T) {
+    tmpDir, _ := os.MkdirTemp("", "xhub-test")
+    defer os.RemoveAll(tmpDir)
+
+    store, err := NewStore(tmpDir)
+    if err != nil {
+        t.Fatalf("Failed to create store: %v", err)
+    }
+    defer store.Close()
+
+    store.ProposeTags("a", []string{"webgpu", "shaders"})
+    store.ProposeTags("b", []string{"webgpu"})
+    store.ProposeTags("b", []string{"webgpu"}) // Duplicate is ignored
+
+    proposals, err := store.ListTagProposals()
+    if err != nil {
+        t.Fatalf("Failed to list proposals: %v", err)
+    }
+    if len(proposals) != 2 || proposals[0].Tag != "webgpu" || len(proposals[0].BookmarkIDs) != 2 {
+        t.Fatalf("Expected webgpu first with 2 bookmarks, got %+v", proposals)
+    }
+
+    ids, err := store.ResolveTagProposal("webgpu", "accepted")
+    if err != nil || len(ids) != 2 {
+        t.Fatalf("Expected 2 proposing bookmarks, got %v (%v)", ids, err)
+    }
+
+    // Rejected tags, even never-proposed ones, are not queued again
+    store.ResolveTagProposal("crypto", "rejected")
+    store.ProposeTags("c", []string{"crypto", "webgpu"})
+    proposals, _ = store.ListTagProposals()
+    if len(proposals) != 1 || proposals[0].Tag != "shaders" {
+        t.Errorf("Expected only shaders pending, got %+v", proposals)
+    }
+}
+
+func TestTagsTable(t *testing.T) {
+    tmpDir, _ := os.MkdirTemp("", "xhub-test")
+    defer os.RemoveAll(tmpDir)
+
+    store, err := NewStore(tmpDir)
+    if err != nil {
+        t.Fatalf("Failed to create store: %v", err)
+    }
+
+    a := &Bookmark{Source: "raindrop", URL: "https://a.com", Title: "A", Keywords: "golang,tui"}
+    b := &Bookmark{Source: "raindrop", URL: "https://b.com", Title: "B", Keywords: "Go, cli"}
+    c := &Bookmark{Source: "raindrop", URL: "https://c.com", Title: "C", Keywords: "golang, Go"}
+    store.Upsert(a)
+    store.Upsert(b)
+    store.Upsert(c)
+
+    // Simulate a database from before the tags table existed
+    store.DB().Exec(`DELETE FROM bookmark_tags`)
+    store.DB().Exec(`DELETE FROM tags`)
+    store.DB().Exec(`DELETE FROM metadata WHERE key = ?`, tagsMigratedKey)
+    store.Close()
+
+    store, err = NewStore(tmpDir)
+    if err != nil {
+        t.Fatalf("Failed to reopen store: %v", err)
+    }
+    defer store.Close()
+
+    tags, err := store.ListTags()
+    if err != nil || len(tags) != 4 {
+        t.Fatalf("Expected 4 migrated tags, got %+v (%v)", tags, err)
+    }
+
+    n, err := store.MergeTags([]string{"golang", "GO"}, "go")
+    if err != nil || n != 3 {
+        t.Fatalf("Expected merge to touch 3 bookmarks, got %d (%v)", n, err)
+    }
+    got, _ := store.Get(a.ID)
+    if got.Keywords != "go, tui" {
+        t.Errorf("Expected keywords rewritten, got %q", got.Keywords)
+    }
+    if got, _ := store.Get(c.ID); got.Keywords != "go" {
+        t.Errorf("Expected merged tags to appear once, got %q", got.Keywords)
+    }
+    tags, _ = store.ListTags()
+    if tags[0].Name != "go" || tags[0].Count != 3 {
+        t.Errorf("Expected go on 3 bookmarks first, got %+v", tags)
+    }
+
+    // FTS indexes the rewritten keywords
+    if _, err := store.RenameTag("tui", "terminal"); err != nil {
+        t.Fatalf("Rename failed: %v", err)
+    }
+    results, err := store.ftsSearch(&Query{Groups: [][]Term{{{Text: "terminal"}}}}, 10)
+    if err != nil || len(results) != 1 || results[0].ID != a.ID {
+        t.Errorf("Expected FTS hit on renamed tag, got %+v (%v)", results, err)
+    }
+    if byTag, _ := store.ListByTag("TERMINAL", 10); len(byTag) != 1 {
+        t.Errorf("Expected 1 bookmark tagged terminal, got %d", len(byTag))
+    }
+
+    if n, _ := store.DeleteTag("cli"); n != 1 {
+        t.Errorf("Expected delete to touch 1 bookmark, got %d", n)
+    }
+    got, _ = store.Get(b.ID)
+    if got.Keywords != "go" {
+        t.Errorf("Expected cli removed, got %q", got.Keywords)
+    }
+    tags, _ = store.ListTags()
+    if len(tags) != 2 {
+        t.Errorf("Expected go and terminal left, got %+v", tags)
+    }
+}
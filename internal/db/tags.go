@@ -0,0 +1,310 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/xhub/internal/taxonomy"
+)
+
+// tagsMigratedKey marks that bookmark_tags was populated from the keywords column.
+const tagsMigratedKey = "tags_migrated"
+
+// TagProposal is a keyword outside the taxonomy awaiting review.
+type TagProposal struct {
+	Tag         string
+	BookmarkIDs []string // Bookmarks the LLM suggested it for
+}
+
+// ProposeTags queues tags for review. Tags already accepted or rejected are ignored.
+func (s *Store) ProposeTags(bookmarkID string, tags []string) error {
+	for _, tag := range tags {
+		_, err := s.db.Exec(`
+			INSERT OR IGNORE INTO tag_proposals (tag, bookmark_id)
+			SELECT ?, ?
+			WHERE NOT EXISTS (SELECT 1 FROM tag_proposals WHERE tag = ? AND status != 'pending')
+		`, tag, bookmarkID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTagProposals returns pending proposals, most requested first.
+func (s *Store) ListTagProposals() ([]TagProposal, error) {
+	rows, err := s.db.Query(`
+		SELECT tag, group_concat(bookmark_id)
+		FROM tag_proposals
+		WHERE status = 'pending'
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var proposals []TagProposal
+	for rows.Next() {
+		var p TagProposal
+		var ids string
+		if err := rows.Scan(&p.Tag, &ids); err != nil {
+			return nil, err
+		}
+		p.BookmarkIDs = strings.Split(ids, ",")
+		proposals = append(proposals, p)
+	}
+	return proposals, rows.Err()
+}
+
+// ResolveTagProposal marks a proposal "accepted" or "rejected" and returns the
+// bookmarks that proposed it.
+func (s *Store) ResolveTagProposal(tag, status string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT bookmark_id FROM tag_proposals WHERE tag = ? AND status = 'pending'`, tag)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if _, err := s.db.Exec(`UPDATE tag_proposals SET status = ? WHERE tag = ?`, status, tag); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		// Remember the decision so the tag isn't proposed again
+		_, err = s.db.Exec(`INSERT OR IGNORE INTO tag_proposals (tag, bookmark_id, status) VALUES (?, '', ?)`, tag, status)
+	}
+	return ids, err
+}
+
+// ListWithKeywords returns all bookmarks that have keywords, without raw content.
+func (s *Store) ListWithKeywords() ([]Bookmark, error) {
+	rows, err := s.db.Query(`SELECT ` + listColumns + ` FROM bookmarks WHERE keywords != '' AND keywords IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	return scanBookmarks(rows)
+}
+
+// SetKeywords rewrites only the keywords of a bookmark, leaving provenance as is.
+func (s *Store) SetKeywords(id, keywords string) error {
+	if _, err := s.db.Exec(`UPDATE bookmarks SET keywords = ?, updated_at = ? WHERE id = ?`, keywords, time.Now(), id); err != nil {
+		return err
+	}
+	return s.syncTags(id)
+}
+
+// TagCount is a tag and the number of visible bookmarks carrying it.
+type TagCount struct {
+	Name  string
+	Count int
+}
+
+// migrateTags fills tags and bookmark_tags from keywords written by older versions.
+func (s *Store) migrateTags() error {
+	if done, _ := s.GetMetadata(tagsMigratedKey); done != "" {
+		return nil
+	}
+	ids, err := s.queryStrings(`SELECT id FROM bookmarks WHERE keywords != '' AND keywords IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	if err := s.syncTags(ids...); err != nil {
+		return err
+	}
+	return s.SetMetadata(tagsMigratedKey, "1")
+}
+
+// syncTags rebuilds bookmark_tags from the keywords column, which stays the
+// denormalized copy that FTS indexes.
+func (s *Store) syncTags(ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		var keywords sql.NullString
+		err := tx.QueryRow(`SELECT keywords FROM bookmarks WHERE id = ?`, id).Scan(&keywords)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM bookmark_tags WHERE bookmark_id = ?`, id); err != nil {
+			return err
+		}
+		for i, name := range taxonomy.Split(keywords.String) {
+			if _, err := tx.Exec(`INSERT INTO tags (name) VALUES (?) ON CONFLICT(name) DO NOTHING`, name); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`
+				INSERT OR IGNORE INTO bookmark_tags (bookmark_id, tag_id, position)
+				SELECT ?, id, ? FROM tags WHERE name = ?
+			`, id, i, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) queryStrings(query string, args ...interface{}) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListTags returns tags on visible bookmarks, most used first.
+func (s *Store) ListTags() ([]TagCount, error) {
+	rows, err := s.db.Query(`
+		SELECT t.name, COUNT(*) AS n
+		FROM tags t
+		JOIN bookmark_tags bt ON bt.tag_id = t.id
+		JOIN bookmarks b ON b.id = bt.bookmark_id
+		WHERE b.hidden = 0
+		GROUP BY t.id
+		ORDER BY n DESC, t.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []TagCount
+	for rows.Next() {
+		var t TagCount
+		if err := rows.Scan(&t.Name, &t.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// ListByTag returns visible bookmarks carrying a tag (case-insensitive), newest first.
+func (s *Store) ListByTag(tag string, limit int) ([]Bookmark, error) {
+	rows, err := s.db.Query(`
+		SELECT `+listColumns+` FROM bookmarks
+		WHERE hidden = 0 AND id IN (
+			SELECT bt.bookmark_id FROM bookmark_tags bt JOIN tags t ON t.id = bt.tag_id WHERE t.name = ?
+		)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, tag, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanBookmarks(rows)
+}
+
+// RenameTag renames a tag on every bookmark. Renaming onto an existing tag merges them.
+func (s *Store) RenameTag(from, to string) (int, error) {
+	return s.MergeTags([]string{from}, to)
+}
+
+// MergeTags replaces each of the from tags with into. Returns the number of bookmarks changed.
+func (s *Store) MergeTags(from []string, into string) (int, error) {
+	into = strings.TrimSpace(into)
+	if into == "" || strings.Contains(into, ",") {
+		return 0, fmt.Errorf("invalid tag name %q", into)
+	}
+	n, err := s.rewriteTags(from, func(tag string) string { return into })
+	if err != nil {
+		return n, err
+	}
+	// Names are unique case-insensitively; keep the spelling asked for
+	_, err = s.db.Exec(`UPDATE tags SET name = ? WHERE name = ?`, into, into)
+	return n, err
+}
+
+// DeleteTag removes a tag from every bookmark. Returns the number of bookmarks changed.
+func (s *Store) DeleteTag(tag string) (int, error) {
+	return s.rewriteTags([]string{tag}, func(string) string { return "" })
+}
+
+// rewriteTags maps the named tags through replace ("" drops the tag) on every
+// bookmark carrying one, then rewrites their keywords and drops unused tags.
+func (s *Store) rewriteTags(names []string, replace func(tag string) string) (int, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+	args := make([]interface{}, len(names))
+	for i, n := range names {
+		args[i] = n
+	}
+	ids, err := s.queryStrings(`
+		SELECT DISTINCT bt.bookmark_id FROM bookmark_tags bt JOIN tags t ON t.id = bt.tag_id
+		WHERE t.name IN (`+placeholders(len(names))+`)
+	`, args...)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	matches := func(tag string) bool {
+		for _, n := range names {
+			if strings.EqualFold(tag, n) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, id := range ids {
+		current, err := s.queryStrings(`
+			SELECT t.name FROM bookmark_tags bt JOIN tags t ON t.id = bt.tag_id
+			WHERE bt.bookmark_id = ? ORDER BY bt.position
+		`, id)
+		if err != nil {
+			return 0, err
+		}
+		var kept []string
+		seen := make(map[string]bool)
+		for _, tag := range current {
+			if matches(tag) {
+				tag = replace(tag)
+			}
+			// A tag merged into one the bookmark already has appears once
+			if tag != "" && !seen[taxonomy.Key(tag)] {
+				seen[taxonomy.Key(tag)] = true
+				kept = append(kept, tag)
+			}
+		}
+		_, err = s.db.Exec(`UPDATE bookmarks SET keywords = ?, updated_at = ? WHERE id = ?`, strings.Join(kept, ", "), time.Now(), id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.syncTags(ids...); err != nil {
+		return 0, err
+	}
+	_, err = s.db.Exec(`DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM bookmark_tags)`)
+	return len(ids), err
+}
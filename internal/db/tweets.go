@@ -0,0 +1,183 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+// TweetMetadata is what the X source knows about a bookmarked tweet beyond
+// its URL and title.
+type TweetMetadata struct {
+	TweetID        string        `json:"tweet_id"`
+	AuthorHandle   string        `json:"author_handle"`
+	AuthorName     string        `json:"author_name,omitempty"`
+	Text           string        `json:"text"` // Full text
+	ConversationID string        `json:"conversation_id,omitempty"`
+	InReplyTo      string        `json:"in_reply_to,omitempty"`
+	Thread         []ThreadTweet `json:"thread,omitempty"` // The author's tweets in the conversation, oldest first
+	Quoted         *QuotedTweet  `json:"quoted,omitempty"`
+	Media          []TweetMedia  `json:"media,omitempty"`
+	Cards          []LinkCard    `json:"cards,omitempty"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// ThreadTweet is one tweet of a thread.
+type ThreadTweet struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// QuotedTweet is the tweet a bookmarked tweet quotes.
+type QuotedTweet struct {
+	ID           string `json:"id,omitempty"`
+	AuthorHandle string `json:"author_handle"`
+	Text         string `json:"text"`
+}
+
+// TweetMedia is an attached photo, video or GIF.
+type TweetMedia struct {
+	Type    string `json:"type"`
+	URL     string `json:"url,omitempty"`
+	AltText string `json:"alt_text,omitempty"`
+}
+
+// LinkCard is the preview X shows for a linked page.
+type LinkCard struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Title is the tweet's first line, cut at a word boundary after 100
+// characters. A tweet that is only a link is titled by its link card.
+func (t *TweetMetadata) Title() string {
+	const maxLen = 100
+	text := strings.TrimSpace(linkPattern.ReplaceAllString(t.Text, ""))
+	if text == "" {
+		if len(t.Cards) > 0 && t.Cards[0].Title != "" {
+			return t.Cards[0].Title
+		}
+		text = strings.TrimSpace(t.Text)
+	}
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text
+	}
+	cut := string(runes[:maxLen])
+	if i := strings.LastIndexAny(cut, " \t"); i > maxLen/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "…"
+}
+
+// Document renders the tweet with its thread, quoted tweet, media descriptions
+// and link previews as plain text, for summarizing and indexing in place of
+// the scraped x.com page.
+func (t *TweetMetadata) Document() string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(t.Text))
+	b.WriteString("\n— @" + t.AuthorHandle)
+	if t.AuthorName != "" {
+		b.WriteString(" (" + t.AuthorName + ")")
+	}
+	b.WriteString("\n")
+
+	if len(t.Thread) > 0 {
+		fmt.Fprintf(&b, "\nThread by @%s:\n", t.AuthorHandle)
+		for i, tweet := range t.Thread {
+			marker := ""
+			if tweet.ID == t.TweetID {
+				marker = " (bookmarked)"
+			}
+			fmt.Fprintf(&b, "%d.%s %s\n", i+1, marker, strings.TrimSpace(tweet.Text))
+		}
+	}
+	if t.Quoted != nil && t.Quoted.Text != "" {
+		fmt.Fprintf(&b, "\nQuoting @%s:\n%s\n", t.Quoted.AuthorHandle, strings.TrimSpace(t.Quoted.Text))
+	}
+	for _, m := range t.Media {
+		if m.AltText != "" {
+			fmt.Fprintf(&b, "\n%s: %s", m.label(), strings.TrimSpace(m.AltText))
+		}
+	}
+	for _, c := range t.Cards {
+		b.WriteString("\nLink: ")
+		if c.Title != "" {
+			b.WriteString(c.Title)
+			if c.Description != "" {
+				b.WriteString(" — " + c.Description)
+			}
+			b.WriteString(" ")
+		}
+		b.WriteString("(" + c.URL + ")")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (m TweetMedia) label() string {
+	switch m.Type {
+	case "video":
+		return "Video"
+	case "animated_gif", "gif":
+		return "GIF"
+	default:
+		return "Image"
+	}
+}
+
+func (s *Store) migrateTweets() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS tweet_metadata (
+		bookmark_id TEXT PRIMARY KEY,
+		tweet_id TEXT NOT NULL,
+		author_handle TEXT NOT NULL COLLATE NOCASE,
+		data TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tweet_metadata_author ON tweet_metadata(author_handle);
+
+	CREATE TRIGGER IF NOT EXISTS bookmarks_tweets_ad AFTER DELETE ON bookmarks BEGIN
+		DELETE FROM tweet_metadata WHERE bookmark_id = old.id;
+	END;
+	`)
+	return err
+}
+
+// SetTweetMetadata stores what the X source knows about the tweet bookmarked as id.
+func (s *Store) SetTweetMetadata(id string, t *TweetMetadata) error {
+	t.UpdatedAt = time.Now()
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO tweet_metadata (bookmark_id, tweet_id, author_handle, data, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		id, t.TweetID, strings.TrimPrefix(t.AuthorHandle, "@"), string(data), t.UpdatedAt)
+	return err
+}
+
+// GetTweetMetadata returns the tweet metadata of bookmark id, or nil if there is none.
+func (s *Store) GetTweetMetadata(id string) (*TweetMetadata, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM tweet_metadata WHERE bookmark_id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t TweetMetadata
+	if err := json.Unmarshal([]byte(data), &t); err != nil {
+		return nil, fmt.Errorf("tweet metadata of %s: %w", id, err)
+	}
+	return &t, nil
+}
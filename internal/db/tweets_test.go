@@ -0,0 +1,90 @@
+package db
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTweetMetadata(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	tweet := &Bookmark{Source: "x", URL: "https://x.com/Karpathy/status/2", Title: "Thread on tokenizers", Tweet: &TweetMetadata{
+		TweetID:      "2",
+		AuthorHandle: "Karpathy",
+		AuthorName:   "Andrej Karpathy",
+		Text:         "Thread on tokenizers 🧵",
+		Thread:       []ThreadTweet{{ID: "2", Text: "Thread on tokenizers 🧵"}, {ID: "3", Text: "BPE merges frequent pairs"}},
+		Quoted:       &QuotedTweet{AuthorHandle: "openai", Text: "New tokenizer released"},
+		Media:        []TweetMedia{{Type: "photo", AltText: "A merge table"}},
+		Cards:        []LinkCard{{URL: "https://blog.dev/bpe", Title: "BPE explained", Description: "From bytes to tokens"}},
+	}}
+	if err := store.Upsert(tweet); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	article := &Bookmark{Source: "x", URL: "https://blog.dev/bpe", Title: "BPE explained", ParentID: tweet.ID}
+	store.Upsert(article)
+	legacy := &Bookmark{Source: "x", URL: "https://x.com/karpathy/status/1", Title: "Saved before metadata"}
+	store.Upsert(legacy)
+	other := &Bookmark{Source: "x", URL: "https://x.com/someone/status/4", Title: "Tokenizers are hard", Tweet: &TweetMetadata{TweetID: "4", AuthorHandle: "someone", Text: "Tokenizers are hard"}}
+	store.Upsert(other)
+
+	meta, err := store.GetTweetMetadata(tweet.ID)
+	if err != nil || meta == nil {
+		t.Fatalf("GetTweetMetadata failed: %v", err)
+	}
+	doc := meta.Document()
+	for _, want := range []string{"— @Karpathy (Andrej Karpathy)", "2. BPE merges frequent pairs", "Quoting @openai:\nNew tokenizer released", "Image: A merge table", "Link: BPE explained — From bytes to tokens (https://blog.dev/bpe)"} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("expected %q in document:\n%s", want, doc)
+		}
+	}
+	long := TweetMetadata{Text: strings.Repeat("tokenizers ", 20) + "\nsecond line"}
+	if title := long.Title(); len([]rune(title)) > 101 || !strings.HasSuffix(title, "tokenizers…") {
+		t.Errorf("expected the title cut at a word, got %q", title)
+	}
+	if meta, _ := store.GetTweetMetadata(article.ID); meta != nil {
+		t.Errorf("expected no metadata for the article, got %+v", meta)
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"author:@KARPATHY", []string{tweet.ID, article.ID, legacy.ID}},
+		{"tokenizers -author:karpathy", []string{other.ID}},
+	}
+	for _, tc := range cases {
+		results, err := store.Search(tc.query, 10)
+		if err != nil {
+			t.Fatalf("Search %q failed: %v", tc.query, err)
+		}
+		got := make(map[string]bool)
+		for _, r := range results {
+			got[r.ID] = true
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%q: got %v, want %v", tc.query, got, tc.want)
+			continue
+		}
+		for _, id := range tc.want {
+			if !got[id] {
+				t.Errorf("%q: missing %s in %v", tc.query, id, got)
+			}
+		}
+	}
+
+	if err := store.Delete(tweet.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if meta, _ := store.GetTweetMetadata(tweet.ID); meta != nil {
+		t.Error("expected the metadata deleted with the tweet")
+	}
+}
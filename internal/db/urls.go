@@ -0,0 +1,187 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/user/xhub/internal/urlnorm"
+)
+
+// urlsCanonicalizedKey marks that bookmarks saved before URLs were
+// canonicalized were moved to their canonical URLs.
+const urlsCanonicalizedKey = "urls_canonicalized"
+
+// URLChange is a bookmark CanonicalizeURLs moves to its canonical URL.
+type URLChange struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Canonical  string `json:"canonical"`
+	MergedInto string `json:"merged_into,omitempty"` // The bookmark kept when several share the canonical URL
+	MergeID    int64  `json:"merge_id,omitempty"`    // Merge log entry, for undo
+}
+
+// SetURLNormalizer changes how Upsert and GetByURL canonicalize URLs.
+func (s *Store) SetURLNormalizer(n *urlnorm.Normalizer) {
+	s.urls = n
+}
+
+// canonicalize replaces b.URL with its canonical form, keeping the URL as
+// given in b.OriginalURL. A bookmark stored before canonicalization under the
+// URL as given keeps it until CanonicalizeURLs moves it, and one stored under
+// the other of http and https keeps its scheme.
+func (s *Store) canonicalize(b *Bookmark) {
+	canonical := s.urls.Canonical(b.URL)
+	if canonical != b.URL && s.hasURL(b.URL) {
+		return
+	}
+	if !s.hasURL(canonical) {
+		if other := otherScheme(canonical); other != "" && s.hasURL(other) {
+			canonical = other
+		}
+	}
+	if canonical == b.URL {
+		return
+	}
+	if b.OriginalURL == "" {
+		b.OriginalURL = b.URL
+	}
+	b.URL = canonical
+}
+
+// hasURL reports whether a bookmark is stored under url.
+func (s *Store) hasURL(url string) bool {
+	var exists int
+	return s.db.QueryRow(`SELECT 1 FROM bookmarks WHERE url = ?`, url).Scan(&exists) == nil
+}
+
+// otherScheme swaps http for https and back, or returns "" for other schemes.
+func otherScheme(url string) string {
+	if rest, ok := strings.CutPrefix(url, "https://"); ok {
+		return "http://" + rest
+	}
+	if rest, ok := strings.CutPrefix(url, "http://"); ok {
+		return "https://" + rest
+	}
+	return ""
+}
+
+// CanonicalizeURLs moves bookmarks saved under a non-canonical URL to the
+// canonical one, keeping the old URL as their original URL. Bookmarks that
+// share a canonical URL are merged into the one sortKeeperFirst prefers, so
+// the merges can be undone. Bookmark IDs don't change. With dryRun nothing is
+// written.
+func (s *Store) CanonicalizeURLs(dryRun bool) ([]URLChange, error) {
+	rows, err := s.db.Query(`SELECT ` + listColumns + ` FROM bookmarks ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	bookmarks, err := scanBookmarks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var order []string
+	groups := make(map[string][]Bookmark)
+	for _, b := range bookmarks {
+		canonical := s.urls.Canonical(b.URL)
+		if _, ok := groups[canonical]; !ok {
+			order = append(order, canonical)
+		}
+		groups[canonical] = append(groups[canonical], b)
+	}
+
+	var changes []URLChange
+	for _, canonical := range order {
+		group := groups[canonical]
+		if len(group) == 1 && group[0].URL == canonical {
+			continue
+		}
+		sortKeeperFirst(group)
+		kept := group[0]
+
+		first := len(changes)
+		if kept.URL != canonical {
+			changes = append(changes, URLChange{ID: kept.ID, URL: kept.URL, Canonical: canonical})
+		}
+		var dupIDs []string
+		for _, b := range group[1:] {
+			dupIDs = append(dupIDs, b.ID)
+			changes = append(changes, URLChange{ID: b.ID, URL: b.URL, Canonical: canonical, MergedInto: kept.ID})
+		}
+		if dryRun {
+			continue
+		}
+
+		if len(dupIDs) > 0 {
+			record, err := s.mergeBookmarks(kept.ID, dupIDs, canonical)
+			if err != nil {
+				return changes, err
+			}
+			for i := first; i < len(changes); i++ {
+				changes[i].MergeID = record.ID
+			}
+			continue
+		}
+		if _, err := s.db.Exec(`UPDATE bookmarks SET url = ?, original_url = COALESCE(NULLIF(original_url, ''), ?) WHERE id = ?`, canonical, kept.URL, kept.ID); err != nil {
+			return changes, err
+		}
+		if _, err := s.db.Exec(`DELETE FROM bookmark_urls WHERE url = ?`, canonical); err != nil {
+			return changes, err
+		}
+	}
+	if dryRun {
+		return changes, nil
+	}
+	return changes, s.canonicalizeAliases()
+}
+
+// MigrateURLs moves bookmarks saved by versions that stored URLs as given to
+// their canonical URLs, once; later rule changes need CanonicalizeURLs. Call it
+// after SetURLNormalizer, so the configured rules apply.
+func (s *Store) MigrateURLs() ([]URLChange, error) {
+	if done, _ := s.GetMetadata(urlsCanonicalizedKey); done != "" {
+		return nil, nil
+	}
+	changes, err := s.CanonicalizeURLs(false)
+	if err != nil {
+		return changes, err
+	}
+	return changes, s.SetMetadata(urlsCanonicalizedKey, "1")
+}
+
+// canonicalizeAliases adds the canonical form of each merged-away URL, so
+// fetching either form finds the bookmark it was merged into.
+func (s *Store) canonicalizeAliases() error {
+	rows, err := s.db.Query(`SELECT url, bookmark_id, source FROM bookmark_urls`)
+	if err != nil {
+		return err
+	}
+	var aliases []alternateURL
+	for rows.Next() {
+		var u alternateURL
+		if err := rows.Scan(&u.URL, &u.BookmarkID, &u.Source); err != nil {
+			rows.Close()
+			return err
+		}
+		aliases = append(aliases, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, u := range aliases {
+		canonical := s.urls.Canonical(u.URL)
+		if canonical == u.URL {
+			continue
+		}
+		var id string
+		if err := s.db.QueryRow(`SELECT id FROM bookmarks WHERE url = ?`, canonical).Scan(&id); err != sql.ErrNoRows {
+			continue // Saved as a bookmark of its own, or the lookup failed
+		}
+		if _, err := s.db.Exec(`INSERT OR IGNORE INTO bookmark_urls (url, bookmark_id, source) VALUES (?, ?, ?)`, canonical, u.BookmarkID, u.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
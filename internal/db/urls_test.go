@@ -0,0 +1,186 @@
+package db
+
+import (
+	"os"
+	"testing"
+
+	"github.com/user/xhub/internal/urlnorm"
+)
+
+func TestUpsertCanonicalizesURL(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	raw := "http://www.Blog.dev/post/?utm_source=rss#top"
+	b := &Bookmark{Source: "raindrop", URL: raw, Title: "Post"}
+	if isNew, err := store.UpsertReturningNew(b); err != nil || !isNew {
+		t.Fatalf("expected a new bookmark, got new=%v err=%v", isNew, err)
+	}
+	if b.URL != "http://blog.dev/post" || b.ID != generateID(b.URL) {
+		t.Errorf("expected the canonical URL and its ID, got %s (%s)", b.URL, b.ID)
+	}
+
+	again := &Bookmark{Source: "manual", URL: "https://blog.dev/post/", Title: "Post"}
+	if isNew, _ := store.UpsertReturningNew(again); isNew || again.ID != b.ID {
+		t.Errorf("expected the variant to update the same bookmark, got new=%v id=%s", isNew, again.ID)
+	}
+	if again.URL != b.URL {
+		t.Errorf("expected the stored scheme kept, got %s", again.URL)
+	}
+	stored, err := store.GetByURL("https://www.blog.dev/post")
+	if err != nil {
+		t.Fatalf("GetByURL failed: %v", err)
+	}
+	if stored.OriginalURL != raw {
+		t.Errorf("expected the first URL as given kept, got %q", stored.OriginalURL)
+	}
+
+	// Rows saved before canonicalization are updated under their own URL
+	legacy := insertLegacyBookmark(t, store, "x", "https://twitter.com/a/status/1", "Tweet")
+	update := &Bookmark{Source: "x", URL: legacy.URL, Title: "Tweet, updated"}
+	if isNew, _ := store.UpsertReturningNew(update); isNew || update.ID != legacy.ID || update.URL != legacy.URL {
+		t.Errorf("expected the legacy row updated in place, got new=%v url=%s", isNew, update.URL)
+	}
+
+	n, err := urlnorm.New([]urlnorm.Rule{{Domain: "shop.dev", KeepParams: []string{"id"}}})
+	if err != nil {
+		t.Fatalf("urlnorm.New failed: %v", err)
+	}
+	store.SetURLNormalizer(n)
+	item := &Bookmark{Source: "manual", URL: "https://shop.dev/item?id=7&color=red", Title: "Item"}
+	store.Upsert(item)
+	if item.URL != "https://shop.dev/item?id=7" {
+		t.Errorf("expected the configured rule applied, got %s", item.URL)
+	}
+}
+
+func TestCanonicalizeURLs(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	tweet := insertLegacyBookmark(t, store, "raindrop", "https://twitter.com/a/status/1?s=20", "Tweet")
+	store.db.Exec(`UPDATE bookmarks SET notes = 'great thread' WHERE id = ?`, tweet.ID)
+	post := insertLegacyBookmark(t, store, "manual", "https://www.blog.dev/post/", "Post")
+	canonical := &Bookmark{Source: "x", URL: "https://x.com/a/status/1", Title: "Tweet"}
+	store.Upsert(canonical)
+
+	changes, err := store.CanonicalizeURLs(true)
+	if err != nil {
+		t.Fatalf("CanonicalizeURLs failed: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", changes)
+	}
+	if _, err := store.Get(canonical.ID); err != nil {
+		t.Fatal("expected a dry run to change nothing")
+	}
+
+	changes, err = store.CanonicalizeURLs(false)
+	if err != nil {
+		t.Fatalf("CanonicalizeURLs failed: %v", err)
+	}
+	var mergeID int64
+	for _, c := range changes {
+		if c.ID == canonical.ID {
+			if c.MergedInto != tweet.ID || c.MergeID == 0 {
+				t.Errorf("expected the bookmark without notes merged into the other, got %+v", c)
+			}
+			mergeID = c.MergeID
+		}
+	}
+
+	kept, err := store.GetByURL("https://x.com/a/status/1")
+	if err != nil || kept.ID != tweet.ID {
+		t.Fatalf("expected the canonical URL to find the kept bookmark, got %v", err)
+	}
+	if kept.URL != "https://x.com/a/status/1" || kept.OriginalURL != tweet.URL {
+		t.Errorf("expected the URL moved and the old one kept, got %s / %s", kept.URL, kept.OriginalURL)
+	}
+	if b, _ := store.Get(post.ID); b.URL != "https://blog.dev/post" || b.OriginalURL != post.URL {
+		t.Errorf("expected the post moved to its canonical URL, got %s / %s", b.URL, b.OriginalURL)
+	}
+	if again, _ := store.CanonicalizeURLs(false); len(again) != 0 {
+		t.Errorf("expected nothing left to change, got %+v", again)
+	}
+
+	if _, err := store.UndoMerge(mergeID); err != nil {
+		t.Fatalf("UndoMerge failed: %v", err)
+	}
+	if b, err := store.Get(canonical.ID); err != nil || b.URL != canonical.URL {
+		t.Errorf("expected the merged bookmark restored, got %v", err)
+	}
+	if b, _ := store.Get(tweet.ID); b.URL != tweet.URL {
+		t.Errorf("expected the kept bookmark back at its old URL, got %s", b.URL)
+	}
+}
+
+func TestUpsertAfterCanonicalize(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	legacy := insertLegacyBookmark(t, store, "x", "https://twitter.com/a/status/1", "Tweet")
+	if _, err := store.CanonicalizeURLs(false); err != nil {
+		t.Fatalf("CanonicalizeURLs failed: %v", err)
+	}
+
+	// The row keeps the ID of its old URL; upserting under the new one finds it
+	b := &Bookmark{Source: "x", URL: "https://twitter.com/a/status/1", Title: "Tweet", Keywords: "go, rust"}
+	isNew, err := store.UpsertReturningNew(b)
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if isNew || b.ID != legacy.ID {
+		t.Errorf("expected the existing bookmark %s, got %s (new: %v)", legacy.ID, b.ID, isNew)
+	}
+	tags, err := store.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if len(tags) != 2 {
+		t.Errorf("expected the keywords synced as tags, got %+v", tags)
+	}
+}
+
+func TestMigrateURLs(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	legacy := insertLegacyBookmark(t, store, "x", "https://twitter.com/a/status/1", "Tweet")
+	changes, err := store.MigrateURLs()
+	if err != nil {
+		t.Fatalf("MigrateURLs failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].ID != legacy.ID {
+		t.Errorf("expected the legacy bookmark moved, got %+v", changes)
+	}
+
+	// Runs once; later legacy rows wait for CanonicalizeURLs
+	insertLegacyBookmark(t, store, "raindrop", "https://www.blog.dev/post/", "Post")
+	if again, err := store.MigrateURLs(); err != nil || len(again) != 0 {
+		t.Errorf("expected the migration to run once, got %+v (%v)", again, err)
+	}
+}
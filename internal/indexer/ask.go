@@ -0,0 +1,312 @@
+package indexer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+const (
+	defaultAskSources    = 8    // Bookmarks given to the LLM per question
+	askChunkSize         = 1200 // Characters per raw content excerpt
+	askChunksPerBookmark = 2
+)
+
+const askSystemPrompt = `You answer questions about the user's saved bookmarks, using only the numbered bookmarks provided with each question.
+Cite every bookmark you rely on by its number in square brackets, like [2] or [1][3].
+If the bookmarks don't answer the question, say so briefly instead of guessing.
+Be concise.`
+
+// askStopwords are dropped when turning a question into search terms.
+var askStopwords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "any": true, "are": true, "did": true, "do": true,
+	"does": true, "for": true, "from": true, "have": true, "how": true, "i": true, "in": true, "is": true,
+	"it": true, "me": true, "my": true, "of": true, "on": true, "or": true, "saved": true, "save": true,
+	"some": true, "that": true, "the": true, "there": true, "to": true, "was": true, "what": true,
+	"when": true, "where": true, "which": true, "who": true, "why": true, "with": true, "you": true,
+}
+
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// AskSource is a bookmark given to the LLM as context, numbered for citation.
+type AskSource struct {
+	N     int    `json:"n"` // Cited as [n]
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Cited bool   `json:"cited"`
+}
+
+// Answer is the LLM's answer to a question and the bookmarks it was given.
+type Answer struct {
+	Question string      `json:"question"`
+	Answer   string      `json:"answer"`
+	Sources  []AskSource `json:"sources"`
+}
+
+// CitedSources returns the sources the answer cites.
+func (a *Answer) CitedSources() []AskSource {
+	var cited []AskSource
+	for _, s := range a.Sources {
+		if s.Cited {
+			cited = append(cited, s)
+		}
+	}
+	return cited
+}
+
+// AskOptions configures Ask.
+type AskOptions struct {
+	Sources int               // Bookmarks retrieved per question (default 8)
+	History []ChatMessage     // Earlier turns of the conversation, without their context
+	OnText  func(text string) // Called with each piece of the answer as it streams
+}
+
+// Ask answers a question from the bookmark index: it retrieves the most relevant
+// bookmarks, gives the LLM their summaries and matching excerpts of their
+// content, and streams an answer citing them by number.
+func Ask(cfg *config.Config, store *db.Store, question string, opts AskOptions) (*Answer, error) {
+	limit := opts.Sources
+	if limit <= 0 {
+		limit = defaultAskSources
+	}
+	results, err := Retrieve(cfg, store, question, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	answer := &Answer{Question: question}
+	terms := questionTerms(question)
+	var sources strings.Builder
+	for _, r := range results {
+		n := len(answer.Sources) + 1
+		answer.Sources = append(answer.Sources, AskSource{N: n, ID: r.ID, URL: r.URL, Title: r.Title})
+		writeAskSource(&sources, n, &r.Bookmark, terms)
+	}
+
+	var prompt string
+	if len(answer.Sources) == 0 {
+		prompt = "No saved bookmarks matched this question.\n\nQuestion: " + question
+	} else {
+		prompt = "Bookmarks:\n\n" + sources.String() + "Question: " + question
+	}
+	messages := append(append([]ChatMessage{}, opts.History...), ChatMessage{Role: RoleUser, Content: prompt})
+
+	text, err := NewSummarizer(cfg).Stream(askSystemPrompt, messages, opts.OnText)
+	answer.Answer = text
+	if err != nil {
+		return answer, err
+	}
+
+	for _, n := range ParseCitations(text) {
+		if n >= 1 && n <= len(answer.Sources) {
+			answer.Sources[n-1].Cited = true
+		}
+	}
+	return answer, nil
+}
+
+// Retrieve finds the bookmarks most relevant to a natural-language question:
+// any of its significant words match, ranked with the question's embedding when
+// embeddings are configured.
+func Retrieve(cfg *config.Config, store *db.Store, question string, limit int) ([]db.SearchResult, error) {
+	terms := questionTerms(question)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	query := strings.Join(terms, " OR ")
+
+	if embedder, err := NewEmbedder(cfg); err == nil {
+		if emb, err := embedder.Embed(question); err == nil {
+			return store.HybridSearchWithEmbedding(query, emb, limit)
+		}
+	}
+	return store.Search(query, limit)
+}
+
+// questionTerms returns the question's significant words, lowercased and without
+// search syntax.
+func questionTerms(question string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.'
+	}) {
+		word = strings.Trim(word, "-_.")
+		if len(word) < 2 || askStopwords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// writeAskSource renders one numbered bookmark for the prompt.
+func writeAskSource(w *strings.Builder, n int, b *db.Bookmark, terms []string) {
+	fmt.Fprintf(w, "[%d] %s\nURL: %s\n", n, strings.TrimSpace(b.Title), b.URL)
+	if b.Summary != "" {
+		fmt.Fprintf(w, "Summary: %s\n", strings.TrimSpace(b.Summary))
+	}
+	if b.Keywords != "" {
+		fmt.Fprintf(w, "Tags: %s\n", b.Keywords)
+	}
+	if b.Notes != "" {
+		fmt.Fprintf(w, "My notes: %s\n", strings.TrimSpace(b.Notes))
+	}
+	for _, chunk := range relevantChunks(b.RawContent, terms, askChunksPerBookmark) {
+		fmt.Fprintf(w, "Excerpt: %s\n", chunk)
+	}
+	w.WriteString("\n")
+}
+
+// relevantChunks splits content into excerpts of about askChunkSize characters
+// and returns up to limit that mention the most question terms, in document order.
+func relevantChunks(content string, terms []string, limit int) []string {
+	words := strings.Fields(content)
+	if len(words) == 0 || len(terms) == 0 {
+		return nil
+	}
+
+	type chunk struct {
+		text  string
+		pos   int
+		score int
+	}
+	var chunks []chunk
+	var current []string
+	size := 0
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		text := strings.Join(current, " ")
+		lower := strings.ToLower(text)
+		score := 0
+		for _, t := range terms {
+			if strings.Contains(lower, t) {
+				score++
+			}
+		}
+		chunks = append(chunks, chunk{text: text, pos: len(chunks), score: score})
+		current, size = nil, 0
+	}
+	for _, w := range words {
+		if size+len(w) > askChunkSize {
+			flush()
+		}
+		current = append(current, w)
+		size += len(w) + 1
+	}
+	flush()
+
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].score > chunks[j].score })
+	var best []chunk
+	for _, c := range chunks {
+		if c.score == 0 || len(best) == limit {
+			break
+		}
+		best = append(best, c)
+	}
+	sort.Slice(best, func(i, j int) bool { return best[i].pos < best[j].pos })
+
+	texts := make([]string, len(best))
+	for i, c := range best {
+		texts[i] = c.text
+	}
+	return texts
+}
+
+// ParseCitations returns the citation numbers in an answer, in order of first use.
+func ParseCitations(text string) []int {
+	var nums []int
+	seen := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(text, -1) {
+		for _, part := range strings.Split(m[1], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err == nil && !seen[n] {
+				seen[n] = true
+				nums = append(nums, n)
+			}
+		}
+	}
+	return nums
+}
+
+// citedList renders an answer's cited sources as "[1] Title (url)" lines.
+func (a *Answer) citedList() string {
+	var b strings.Builder
+	for _, s := range a.CitedSources() {
+		fmt.Fprintf(&b, "[%d] %s (%s)\n", s.N, strings.TrimSpace(s.Title), s.URL)
+	}
+	return b.String()
+}
+
+// AskHistory turns earlier answers into AskOptions.History for a follow-up
+// question. Each answer keeps the list of what it cited, since the numbers are
+// only meaningful within their own turn.
+func AskHistory(turns []*Answer) []ChatMessage {
+	var history []ChatMessage
+	for _, a := range turns {
+		content := a.Answer
+		if cited := a.citedList(); cited != "" {
+			content += "\n\nSources:\n" + cited
+		}
+		history = append(history,
+			ChatMessage{Role: RoleUser, Content: a.Question},
+			ChatMessage{Role: RoleAssistant, Content: content})
+	}
+	return history
+}
+
+// FormatConversation renders a conversation as plain text for a note.
+func FormatConversation(turns []*Answer, at time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Chat, %s\n", at.Format("2006-01-02"))
+	for _, a := range turns {
+		fmt.Fprintf(&b, "\nQ: %s\nA: %s\n", strings.TrimSpace(a.Question), strings.TrimSpace(a.Answer))
+		if cited := a.citedList(); cited != "" {
+			b.WriteString("Sources:\n" + cited)
+		}
+	}
+	return b.String()
+}
+
+// SaveConversationNote appends the conversation to the notes of every bookmark
+// it cites, and returns the updated bookmarks.
+func SaveConversationNote(store *db.Store, turns []*Answer) ([]*db.Bookmark, error) {
+	note := FormatConversation(turns, time.Now())
+	var saved []*db.Bookmark
+	seen := make(map[string]bool)
+	for _, a := range turns {
+		for _, s := range a.CitedSources() {
+			if seen[s.ID] {
+				continue
+			}
+			seen[s.ID] = true
+
+			b, err := store.Get(s.ID)
+			if err != nil {
+				return saved, fmt.Errorf("bookmark %s: %w", s.ID, err)
+			}
+			if notes := strings.TrimSpace(b.Notes); notes != "" {
+				b.Notes = notes + "\n\n" + note
+			} else {
+				b.Notes = note
+			}
+			b.SetOrigin(db.FieldNotes, db.OriginUser)
+			if err := store.Update(b); err != nil {
+				return saved, fmt.Errorf("bookmark %s: %w", s.ID, err)
+			}
+			saved = append(saved, b)
+		}
+	}
+	return saved, nil
+}
@@ -0,0 +1,175 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+func TestQuestionTerms(t *testing.T) {
+	got := questionTerms(`What libraries did I save for terminal UIs in Go? "bubbletea" source:x`)
+	want := []string{"libraries", "terminal", "uis", "go", "bubbletea", "source"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("questionTerms = %v, want %v", got, want)
+	}
+}
+
+func TestParseCitations(t *testing.T) {
+	got := ParseCitations("Use bubbletea [2] or tview [1, 3]. Both are popular [2][4].")
+	if want := []int{2, 1, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCitations = %v, want %v", got, want)
+	}
+}
+
+func TestRelevantChunks(t *testing.T) {
+	filler := strings.Repeat("lorem ipsum dolor ", 100)
+	content := filler + "bubbletea is a terminal framework " + filler + "nothing here " + filler
+	chunks := relevantChunks(content, []string{"bubbletea", "terminal"}, 2)
+	if len(chunks) != 1 || !strings.Contains(chunks[0], "bubbletea is a terminal framework") {
+		t.Errorf("expected the one matching excerpt, got %d chunks", len(chunks))
+	}
+	for _, c := range chunks {
+		if len(c) > askChunkSize+20 {
+			t.Errorf("chunk too long: %d", len(c))
+		}
+	}
+}
+
+// anthropicStream answers like the Anthropic streaming API, one delta per part.
+func anthropicStream(w http.ResponseWriter, parts ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"type\":\"message\",\"role\":\"assistant\",\"content\":[]}}\n\n")
+	fmt.Fprint(w, "event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n")
+	for _, p := range parts {
+		data, _ := json.Marshal(map[string]interface{}{
+			"type": "content_block_delta", "index": 0,
+			"delta": map[string]string{"type": "text_delta", "text": p},
+		})
+		fmt.Fprintf(w, "event: content_block_delta\ndata: %s\n\n", data)
+	}
+	fmt.Fprint(w, "event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n")
+	fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+}
+
+func TestAsk(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	var prompt, system string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			System   string `json:"system"`
+			Stream   bool   `json:"stream"`
+			Messages []struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("expected a streaming request")
+		}
+		system = req.System
+		prompt = req.Messages[len(req.Messages)-1].Content[0].Text
+		anthropicStream(w, "Try ", "bubbletea [1]", ", or see [7].")
+	}))
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test")
+	t.Setenv("OPENAI_API_KEY", "")
+	cfg := &config.Config{
+		DataDir: tmpDir,
+		LLM:     config.LLMConfig{Provider: "anthropic", Model: "test-model", BaseURL: srv.URL + "/v1"},
+	}
+
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	store.Upsert(&db.Bookmark{
+		Source: "github", URL: "https://github.com/charmbracelet/bubbletea", Title: "bubbletea",
+		Summary: "A Go framework for terminal apps", RawContent: "The fun, functional and stateful way to build terminal apps.",
+	})
+	store.Upsert(&db.Bookmark{Source: "x", URL: "https://x.com/a/status/1", Title: "Sourdough starter tips"})
+
+	var streamed strings.Builder
+	answer, err := Ask(cfg, store, "what terminal UI libraries did I save?", AskOptions{
+		OnText: func(s string) { streamed.WriteString(s) },
+	})
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+
+	if streamed.String() != "Try bubbletea [1], or see [7]." || answer.Answer != streamed.String() {
+		t.Errorf("streamed %q, answer %q", streamed.String(), answer.Answer)
+	}
+	if !strings.Contains(system, "square brackets") {
+		t.Errorf("expected citation instructions in the system prompt, got %q", system)
+	}
+	if !strings.Contains(prompt, "[1] bubbletea") || !strings.Contains(prompt, "Excerpt: The fun, functional") || strings.Contains(prompt, "Sourdough") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+	cited := answer.CitedSources()
+	if len(answer.Sources) != 1 || len(cited) != 1 || cited[0].URL != "https://github.com/charmbracelet/bubbletea" {
+		t.Errorf("expected bubbletea cited (and [7] ignored), got %+v", answer.Sources)
+	}
+}
+
+func TestAskHistoryAndConversationNote(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	tokio := &db.Bookmark{Source: "github", URL: "https://github.com/tokio-rs/tokio", Title: "tokio", Notes: "use for the crawler"}
+	smol := &db.Bookmark{Source: "github", URL: "https://github.com/smol-rs/smol", Title: "smol"}
+	store.Upsert(tokio)
+	store.Upsert(smol)
+
+	turns := []*Answer{
+		{Question: "async runtimes?", Answer: "tokio [1] and smol [2].", Sources: []AskSource{
+			{N: 1, ID: tokio.ID, URL: tokio.URL, Title: "tokio", Cited: true},
+			{N: 2, ID: smol.ID, URL: smol.URL, Title: "smol", Cited: true},
+		}},
+		{Question: "which is smaller?", Answer: "smol [1].", Sources: []AskSource{
+			{N: 1, ID: smol.ID, URL: smol.URL, Title: "smol", Cited: true},
+		}},
+	}
+
+	history := AskHistory(turns)
+	if len(history) != 4 || history[0].Role != RoleUser || history[1].Role != RoleAssistant {
+		t.Fatalf("expected alternating user/assistant turns, got %+v", history)
+	}
+	if !strings.Contains(history[3].Content, "[1] smol (https://github.com/smol-rs/smol)") {
+		t.Errorf("expected the answer's citations to be spelled out, got %q", history[3].Content)
+	}
+
+	saved, err := SaveConversationNote(store, turns)
+	if err != nil {
+		t.Fatalf("SaveConversationNote failed: %v", err)
+	}
+	if len(saved) != 2 {
+		t.Fatalf("expected both cited bookmarks updated once, got %d", len(saved))
+	}
+
+	got, _ := store.Get(tokio.ID)
+	if !strings.HasPrefix(got.Notes, "use for the crawler\n\nChat, ") || !strings.Contains(got.Notes, "Q: which is smaller?\nA: smol [1].") {
+		t.Errorf("expected the conversation appended to existing notes, got %q", got.Notes)
+	}
+	if !got.IsUserEdited(db.FieldNotes) {
+		t.Error("expected saved notes to be marked as user-authored")
+	}
+}
@@ -0,0 +1,429 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/sashabaranov/go-openai"
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+// pendingBatchesKey stores the JSON list of submitted, not yet applied batches.
+const pendingBatchesKey = "pending_batches"
+
+// Batch kinds
+const (
+	batchKindSummarize = "summarize"
+	batchKindEmbed     = "embed"
+)
+
+// pendingBatch records a batch job submitted to a provider.
+type pendingBatch struct {
+	ID          string    `json:"id"`
+	Provider    string    `json:"provider"` // anthropic, openai
+	Kind        string    `json:"kind"`     // summarize, embed
+	Items       []string  `json:"items"`    // bookmark IDs
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// BatchStats summarizes the outcome of polling pending batches.
+type BatchStats struct {
+	Pending    int // Batches still in progress
+	Summarized int // Bookmarks that received a summary
+	Embedded   int // Bookmarks that received an embedding
+	Failed     int // Bookmarks whose batch request failed
+	Rejected   int // Bookmarks whose summary was an LLM refusal
+}
+
+// Batcher submits summarization and embedding jobs through the Anthropic
+// Message Batches and OpenAI Batch APIs, and applies their results on later runs.
+type Batcher struct {
+	cfg        *config.Config
+	summarizer *Summarizer
+	validator  *Validator
+	anthropic  *anthropic.Client // Summaries when provider is anthropic
+	chat       *openai.Client    // Summaries when provider is openai
+	embedder   *Embedder         // Embeddings (nil disables embedding batches)
+}
+
+// NewBatcher creates a batcher for the configured LLM and embeddings providers.
+// Embeddings are skipped when the embedder is unavailable.
+func NewBatcher(cfg *config.Config, embedder *Embedder) (*Batcher, error) {
+	b := &Batcher{cfg: cfg, summarizer: NewSummarizer(cfg), validator: NewValidator(cfg)}
+
+	switch cfg.LLM.Provider {
+	case "anthropic":
+		client, err := b.summarizer.anthropicClient()
+		if err != nil {
+			return nil, err
+		}
+		b.anthropic = client
+	case "openai":
+		client, _, err := b.summarizer.openAIClient()
+		if err != nil {
+			return nil, err
+		}
+		b.chat = client
+	default:
+		return nil, fmt.Errorf("batch mode not supported for LLM provider: %s", cfg.LLM.Provider)
+	}
+
+	b.embedder = embedder
+	return b, nil
+}
+
+// Submit sends summarization jobs for bookmarks without a summary and embedding
+// jobs for the rest. Submitted bookmarks are marked "batched" so batch-mode
+// runs don't pick them up again as pending until their batch resolves.
+func (b *Batcher) Submit(store *db.Store, bookmarks []db.Bookmark) error {
+	var toSummarize, toEmbed []db.Bookmark
+	for _, bm := range bookmarks {
+		if bm.Summary == "" {
+			toSummarize = append(toSummarize, bm)
+		} else {
+			toEmbed = append(toEmbed, bm)
+		}
+	}
+
+	if len(toSummarize) > 0 {
+		if err := b.submitSummaries(store, toSummarize); err != nil {
+			return err
+		}
+	}
+	return b.submitEmbeddings(store, toEmbed)
+}
+
+func (b *Batcher) submitSummaries(store *db.Store, bookmarks []db.Bookmark) error {
+	ctx := context.Background()
+	batch := pendingBatch{Kind: batchKindSummarize, SubmittedAt: time.Now()}
+
+	if b.anthropic != nil {
+		req := anthropic.BatchRequest{}
+		for _, bm := range bookmarks {
+			prompt, err := b.summarizer.buildPrompt(&bm)
+			if err != nil {
+				return err
+			}
+			req.Requests = append(req.Requests, anthropic.InnerRequests{
+				CustomId: bm.ID,
+				Params: anthropic.MessagesRequest{
+					Model:     anthropic.Model(b.cfg.LLM.Model),
+					MaxTokens: 2000,
+					Messages: []anthropic.Message{
+						{
+							Role:    anthropic.RoleUser,
+							Content: []anthropic.MessageContent{{Type: "text", Text: &prompt}},
+						},
+					},
+				},
+			})
+			batch.Items = append(batch.Items, bm.ID)
+		}
+		resp, err := b.anthropic.CreateBatch(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to create Anthropic batch: %w", err)
+		}
+		batch.ID = string(resp.Id)
+		batch.Provider = "anthropic"
+	} else {
+		req := openai.CreateBatchWithUploadFileRequest{Endpoint: openai.BatchEndpointChatCompletions}
+		for _, bm := range bookmarks {
+			prompt, err := b.summarizer.buildPrompt(&bm)
+			if err != nil {
+				return err
+			}
+			req.AddChatCompletion(bm.ID, openai.ChatCompletionRequest{
+				Model:     b.cfg.LLM.Model,
+				MaxTokens: 2000,
+				Messages: []openai.ChatCompletionMessage{
+					{Role: openai.ChatMessageRoleUser, Content: prompt},
+				},
+			})
+			batch.Items = append(batch.Items, bm.ID)
+		}
+		resp, err := b.chat.CreateBatchWithUploadFile(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to create OpenAI batch: %w", err)
+		}
+		batch.ID = resp.ID
+		batch.Provider = "openai"
+	}
+
+	return b.track(store, batch, bookmarks)
+}
+
+func (b *Batcher) submitEmbeddings(store *db.Store, bookmarks []db.Bookmark) error {
+	if len(bookmarks) == 0 {
+		return nil
+	}
+	if b.embedder == nil {
+		// Nothing to wait for without embeddings
+		for i := range bookmarks {
+			markProcessed(store, &bookmarks[i])
+		}
+		return nil
+	}
+
+	batch := pendingBatch{Kind: batchKindEmbed, Provider: "openai", SubmittedAt: time.Now()}
+	req := openai.CreateBatchWithUploadFileRequest{Endpoint: openai.BatchEndpointEmbeddings}
+	for _, bm := range bookmarks {
+		text := bm.Title + " " + bm.Summary + " " + bm.Keywords
+		if len(text) > 30000 {
+			text = text[:30000]
+		}
+		req.AddEmbedding(bm.ID, openai.EmbeddingRequest{
+			Model: openai.EmbeddingModel(b.embedder.model()),
+			Input: []string{text},
+		})
+		batch.Items = append(batch.Items, bm.ID)
+	}
+
+	resp, err := b.embedder.client.CreateBatchWithUploadFile(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("failed to create embeddings batch: %w", err)
+	}
+	batch.ID = resp.ID
+
+	return b.track(store, batch, bookmarks)
+}
+
+// track persists the batch and marks its bookmarks as in-flight.
+func (b *Batcher) track(store *db.Store, batch pendingBatch, bookmarks []db.Bookmark) error {
+	batches, err := loadPendingBatches(store)
+	if err != nil {
+		return err
+	}
+	batches = append(batches, batch)
+	if err := savePendingBatches(store, batches); err != nil {
+		return err
+	}
+
+	for i := range bookmarks {
+		bookmarks[i].ScrapeStatus = "batched"
+		store.Update(&bookmarks[i])
+	}
+	return nil
+}
+
+// Poll checks every pending batch and applies the results of finished ones.
+// Bookmarks summarized by a finished batch are resubmitted for embedding.
+// Batches that can't be checked are kept for the next poll, and the first
+// such error is returned once the others are applied.
+func (b *Batcher) Poll(store *db.Store) (BatchStats, error) {
+	var stats BatchStats
+
+	batches, err := loadPendingBatches(store)
+	if err != nil {
+		return stats, err
+	}
+
+	var remaining []pendingBatch
+	var summarized []db.Bookmark
+	var pollErr error
+	for _, batch := range batches {
+		results, done, err := b.fetchResults(batch)
+		if err != nil && pollErr == nil {
+			pollErr = fmt.Errorf("batch %s: %w", batch.ID, err)
+		}
+		if err != nil || !done {
+			remaining = append(remaining, batch)
+			stats.Pending++
+			continue
+		}
+
+		for _, id := range batch.Items {
+			bm, err := store.Get(id)
+			if err != nil || bm.ScrapeStatus != "batched" {
+				continue // Deleted, or processed without batch mode, while the batch was running
+			}
+
+			result, ok := results[id]
+			if !ok {
+				bm.ScrapeStatus = "failed"
+				store.Update(bm)
+				stats.Failed++
+				continue
+			}
+
+			switch batch.Kind {
+			case batchKindSummarize:
+				parsed := b.summarizer.parse(result.text)
+				if reason := b.validator.CheckSummary(parsed); reason != "" {
+					rejectBookmark(store, bm, reason)
+					stats.Rejected++
+					continue
+				}
+				ApplySummary(store, bm, parsed, false)
+				store.Update(bm)
+				summarized = append(summarized, *bm)
+				stats.Summarized++
+			case batchKindEmbed:
+				store.UpdateEmbedding(bm.ID, result.embedding)
+				markProcessed(store, bm)
+				stats.Embedded++
+			}
+		}
+	}
+
+	if err := savePendingBatches(store, remaining); err != nil {
+		return stats, err
+	}
+	if err := b.submitEmbeddings(store, summarized); err != nil {
+		return stats, err
+	}
+	return stats, pollErr
+}
+
+// batchResult holds the decoded output of one batch request.
+type batchResult struct {
+	text      string
+	embedding []float32
+}
+
+// fetchResults returns the results keyed by bookmark ID once the batch has ended.
+// Requests that errored are omitted. done=false means the batch is still running.
+func (b *Batcher) fetchResults(batch pendingBatch) (map[string]batchResult, bool, error) {
+	ctx := context.Background()
+	results := make(map[string]batchResult)
+
+	if batch.Provider == "anthropic" {
+		if b.anthropic == nil {
+			return nil, false, nil // Provider changed, leave batch for later
+		}
+		resp, err := b.anthropic.RetrieveBatch(ctx, anthropic.BatchId(batch.ID))
+		if err != nil {
+			return nil, false, err
+		}
+		if resp.ProcessingStatus != anthropic.ProcessingStatusEnded {
+			return nil, false, nil
+		}
+		out, err := b.anthropic.RetrieveBatchResults(ctx, anthropic.BatchId(batch.ID))
+		if err != nil {
+			return nil, false, err
+		}
+		for _, r := range out.Responses {
+			if r.Result.Type != anthropic.ResultTypeSucceeded || len(r.Result.Result.Content) == 0 {
+				continue
+			}
+			results[r.CustomId] = batchResult{text: r.Result.Result.Content[0].GetText()}
+		}
+		return results, true, nil
+	}
+
+	client := b.chat
+	if batch.Kind == batchKindEmbed {
+		client = nil
+		if b.embedder != nil {
+			client = b.embedder.client
+		}
+	}
+	if client == nil {
+		return nil, false, nil
+	}
+
+	resp, err := client.RetrieveBatch(ctx, batch.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	switch resp.Status {
+	case "completed":
+	case "failed", "expired", "cancelled":
+		return results, true, nil
+	default:
+		return nil, false, nil
+	}
+	if resp.OutputFileID == nil {
+		return results, true, nil
+	}
+
+	content, err := client.GetFileContent(ctx, *resp.OutputFileID)
+	if err != nil {
+		return nil, false, err
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, false, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var out struct {
+			CustomID string `json:"custom_id"`
+			Response *struct {
+				StatusCode int             `json:"status_code"`
+				Body       json.RawMessage `json:"body"`
+			} `json:"response"`
+		}
+		if err := json.Unmarshal(line, &out); err != nil {
+			return nil, false, fmt.Errorf("failed to parse batch output: %w", err)
+		}
+		if out.Response == nil || out.Response.StatusCode != 200 {
+			continue
+		}
+
+		if batch.Kind == batchKindEmbed {
+			var body openai.EmbeddingResponse
+			if err := json.Unmarshal(out.Response.Body, &body); err != nil || len(body.Data) == 0 {
+				continue
+			}
+			results[out.CustomID] = batchResult{embedding: body.Data[0].Embedding}
+		} else {
+			var body openai.ChatCompletionResponse
+			if err := json.Unmarshal(out.Response.Body, &body); err != nil || len(body.Choices) == 0 {
+				continue
+			}
+			results[out.CustomID] = batchResult{text: body.Choices[0].Message.Content}
+		}
+	}
+	return results, true, scanner.Err()
+}
+
+// HasPendingBatches reports whether any batch is waiting to be applied.
+func HasPendingBatches(store *db.Store) bool {
+	batches, err := loadPendingBatches(store)
+	return err == nil && len(batches) > 0
+}
+
+func loadPendingBatches(store *db.Store) ([]pendingBatch, error) {
+	raw, err := store.GetMetadata(pendingBatchesKey)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	var batches []pendingBatch
+	if err := json.Unmarshal([]byte(raw), &batches); err != nil {
+		return nil, fmt.Errorf("invalid %s metadata: %w", pendingBatchesKey, err)
+	}
+	return batches, nil
+}
+
+func savePendingBatches(store *db.Store, batches []pendingBatch) error {
+	if len(batches) == 0 {
+		return store.SetMetadata(pendingBatchesKey, "")
+	}
+	data, err := json.Marshal(batches)
+	if err != nil {
+		return err
+	}
+	return store.SetMetadata(pendingBatchesKey, string(data))
+}
+
+// markProcessed records a bookmark as fully processed.
+func markProcessed(store *db.Store, b *db.Bookmark) {
+	b.ScrapeStatus = "success"
+	b.StatusReason = ""
+	b.ScrapedAt = time.Now()
+	store.Update(b)
+}
@@ -0,0 +1,244 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+// batchAPIStandIn serves the subset of the Anthropic and OpenAI batch endpoints used by Batcher.
+type batchAPIStandIn struct {
+	mu            sync.Mutex
+	anthropicDone bool
+	customIDs     []string // From the last Anthropic batch request
+	embedInput    []string // Custom IDs from the last uploaded JSONL file
+}
+
+func (s *batchAPIStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/messages/batches":
+		var req struct {
+			Requests []struct {
+				CustomID string `json:"custom_id"`
+			} `json:"requests"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		s.customIDs = nil
+		for _, r := range req.Requests {
+			s.customIDs = append(s.customIDs, r.CustomID)
+		}
+		fmt.Fprint(w, `{"id":"msgbatch_1","type":"message_batch","processing_status":"in_progress"}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/messages/batches/msgbatch_1":
+		status := "in_progress"
+		if s.anthropicDone {
+			status = "ended"
+		}
+		fmt.Fprintf(w, `{"id":"msgbatch_1","type":"message_batch","processing_status":%q}`, status)
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/messages/batches/msgbatch_1/results":
+		for _, id := range s.customIDs {
+			fmt.Fprintf(w, `{"custom_id":%q,"result":{"type":"succeeded","message":{"type":"message","content":[{"type":"text","text":"SUMMARY: Summary of %s\nKEYWORDS: go, batch"}]}}}`+"\n", id, id)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+		r.ParseMultipartForm(1 << 20)
+		file, _, _ := r.FormFile("file")
+		s.embedInput = nil
+		if file != nil {
+			var line struct {
+				CustomID string `json:"custom_id"`
+			}
+			dec := json.NewDecoder(file)
+			for dec.Decode(&line) == nil {
+				s.embedInput = append(s.embedInput, line.CustomID)
+			}
+		}
+		fmt.Fprint(w, `{"id":"file-in","object":"file","purpose":"batch"}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/batches":
+		fmt.Fprint(w, `{"id":"batch_emb","object":"batch","status":"validating"}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/batches/batch_emb":
+		fmt.Fprint(w, `{"id":"batch_emb","object":"batch","status":"completed","output_file_id":"file-out"}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/files/file-out/content":
+		for _, id := range s.embedInput {
+			fmt.Fprintf(w, `{"custom_id":%q,"response":{"status_code":200,"body":{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.5,0.25]}]}}}`+"\n", id)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestBatcherSubmitAndPoll(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	api := &batchAPIStandIn{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test")
+	t.Setenv("OPENAI_API_KEY", "test")
+	cfg := &config.Config{
+		DataDir:    tmpDir,
+		LLM:        config.LLMConfig{Provider: "anthropic", Model: "test-model", BaseURL: srv.URL + "/v1"},
+		Embeddings: config.EmbeddingsConfig{Model: "test-embed", BaseURL: srv.URL + "/v1"},
+	}
+
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	var bookmarks []db.Bookmark
+	for _, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		b := db.Bookmark{Source: "manual", URL: url, Title: url, RawContent: "content of " + url, ScrapeStatus: "pending"}
+		store.Upsert(&b)
+		bookmarks = append(bookmarks, b)
+	}
+
+	embedder, err := NewEmbedder(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create embedder: %v", err)
+	}
+	batcher, err := NewBatcher(cfg, embedder)
+	if err != nil {
+		t.Fatalf("Failed to create batcher: %v", err)
+	}
+
+	if err := batcher.Submit(store, bookmarks); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if !HasPendingBatches(store) {
+		t.Fatal("Expected pending batch after submit")
+	}
+	if pending, _ := store.GetPending(100, false); len(pending) != 0 {
+		t.Errorf("Expected batched items to leave the pending queue, got %d", len(pending))
+	}
+
+	// Still running: nothing applied
+	stats, err := batcher.Poll(store)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if stats.Pending != 1 || stats.Summarized != 0 {
+		t.Errorf("Expected 1 running batch and no summaries, got %+v", stats)
+	}
+
+	// Summaries land and embeddings are submitted
+	api.mu.Lock()
+	api.anthropicDone = true
+	api.mu.Unlock()
+	stats, err = batcher.Poll(store)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if stats.Summarized != 2 {
+		t.Errorf("Expected 2 summaries, got %+v", stats)
+	}
+	got, _ := store.Get(bookmarks[0].ID)
+	if !strings.HasPrefix(got.Summary, "Summary of ") || got.Keywords != "go, batch" {
+		t.Errorf("Unexpected summary/keywords: %q / %q", got.Summary, got.Keywords)
+	}
+	if got.ScrapeStatus != "batched" {
+		t.Errorf("Expected batched while embedding, got %s", got.ScrapeStatus)
+	}
+
+	// Embeddings land and items are done
+	stats, err = batcher.Poll(store)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if stats.Embedded != 2 {
+		t.Errorf("Expected 2 embeddings, got %+v", stats)
+	}
+	if HasPendingBatches(store) {
+		t.Error("Expected no pending batches once everything is applied")
+	}
+	got, _ = store.Get(bookmarks[1].ID)
+	if got.ScrapeStatus != "success" {
+		t.Errorf("Expected success, got %s", got.ScrapeStatus)
+	}
+	embeddings, _ := store.GetAllWithEmbeddings()
+	if len(embeddings[bookmarks[1].ID]) != 2 {
+		t.Errorf("Expected stored embedding, got %v", embeddings[bookmarks[1].ID])
+	}
+}
+
+func TestBatcherPollKeepsUncheckedBatches(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	api := &batchAPIStandIn{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test")
+	t.Setenv("OPENAI_API_KEY", "test")
+	cfg := &config.Config{
+		DataDir:    tmpDir,
+		LLM:        config.LLMConfig{Provider: "anthropic", Model: "test-model", BaseURL: srv.URL + "/v1"},
+		Embeddings: config.EmbeddingsConfig{Model: "test-embed", BaseURL: srv.URL + "/v1"},
+	}
+
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	var bookmarks []db.Bookmark
+	for _, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		b := db.Bookmark{Source: "manual", URL: url, Title: url, RawContent: "content of " + url, ScrapeStatus: "pending"}
+		store.Upsert(&b)
+		bookmarks = append(bookmarks, b)
+	}
+	embedder, _ := NewEmbedder(cfg)
+	batcher, err := NewBatcher(cfg, embedder)
+	if err != nil {
+		t.Fatalf("Failed to create batcher: %v", err)
+	}
+
+	// A batch the provider no longer knows, submitted before this one
+	if err := savePendingBatches(store, []pendingBatch{{ID: "msgbatch_gone", Provider: "anthropic", Kind: batchKindSummarize}}); err != nil {
+		t.Fatalf("savePendingBatches failed: %v", err)
+	}
+	if err := batcher.Submit(store, bookmarks); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	// A run without batch mode picks the batched items up and processes one
+	pending, _ := store.GetPending(100, true)
+	if len(pending) != 2 {
+		t.Fatalf("Expected batched items pending outside batch mode, got %d", len(pending))
+	}
+	direct := pending[1]
+	direct.Summary = "Summarized directly"
+	markProcessed(store, &direct)
+
+	api.mu.Lock()
+	api.anthropicDone = true
+	api.mu.Unlock()
+	stats, err := batcher.Poll(store)
+	if err == nil || !strings.Contains(err.Error(), "msgbatch_gone") {
+		t.Errorf("Expected the unchecked batch reported, got %v", err)
+	}
+	if stats.Summarized != 1 || stats.Pending != 1 {
+		t.Errorf("Expected the finished batch applied past the error, got %+v", stats)
+	}
+	if got, _ := store.Get(direct.ID); got.Summary != "Summarized directly" || got.ScrapeStatus != "success" {
+		t.Errorf("Expected the directly processed item left alone, got %q (%s)", got.Summary, got.ScrapeStatus)
+	}
+	batches, _ := loadPendingBatches(store)
+	if len(batches) != 2 || batches[0].ID != "msgbatch_gone" || batches[1].Kind != batchKindEmbed {
+		t.Errorf("Expected the unchecked batch kept next to the new embeddings batch, got %+v", batches)
+	}
+}
@@ -0,0 +1,120 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/sashabaranov/go-openai"
+)
+
+// chatMaxTokens caps the length of a streamed reply.
+const chatMaxTokens = 2000
+
+// Chat roles
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// ChatMessage is one turn of a conversation with the LLM.
+type ChatMessage struct {
+	Role    string `json:"role"` // RoleUser or RoleAssistant
+	Content string `json:"content"`
+}
+
+// Stream sends a conversation to the configured LLM provider, calling onText
+// with each piece of the reply as it arrives (onText may be nil). Returns the
+// full reply.
+func (s *Summarizer) Stream(system string, messages []ChatMessage, onText func(string)) (string, error) {
+	if onText == nil {
+		onText = func(string) {}
+	}
+	switch s.cfg.LLM.Provider {
+	case "anthropic":
+		return s.streamWithAnthropic(system, messages, onText)
+	case "openai", "openrouter", "cerebras", "zai", "gemini":
+		return s.streamWithOpenAI(system, messages, onText)
+	default:
+		return "", fmt.Errorf("unsupported LLM provider: %s", s.cfg.LLM.Provider)
+	}
+}
+
+func (s *Summarizer) streamWithAnthropic(system string, messages []ChatMessage, onText func(string)) (string, error) {
+	client, err := s.anthropicClient()
+	if err != nil {
+		return "", err
+	}
+
+	req := anthropic.MessagesRequest{
+		Model:     anthropic.Model(s.cfg.LLM.Model),
+		MaxTokens: chatMaxTokens,
+		System:    system,
+	}
+	for _, m := range messages {
+		if m.Role == RoleAssistant {
+			req.Messages = append(req.Messages, anthropic.NewAssistantTextMessage(m.Content))
+		} else {
+			req.Messages = append(req.Messages, anthropic.NewUserTextMessage(m.Content))
+		}
+	}
+
+	var reply strings.Builder
+	_, err = client.CreateMessagesStream(context.Background(), anthropic.MessagesStreamRequest{
+		MessagesRequest: req,
+		OnContentBlockDelta: func(data anthropic.MessagesEventContentBlockDeltaData) {
+			if data.Delta.Text != nil {
+				reply.WriteString(*data.Delta.Text)
+				onText(*data.Delta.Text)
+			}
+		},
+	})
+	return reply.String(), err
+}
+
+func (s *Summarizer) streamWithOpenAI(system string, messages []ChatMessage, onText func(string)) (string, error) {
+	client, _, err := s.openAIClient()
+	if err != nil {
+		return "", err
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model:     s.cfg.LLM.Model,
+		MaxTokens: chatMaxTokens,
+		Stream:    true,
+	}
+	if system != "" {
+		req.Messages = append(req.Messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: system})
+	}
+	for _, m := range messages {
+		role := openai.ChatMessageRoleUser
+		if m.Role == RoleAssistant {
+			role = openai.ChatMessageRoleAssistant
+		}
+		req.Messages = append(req.Messages, openai.ChatCompletionMessage{Role: role, Content: m.Content})
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), req)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	var reply strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return reply.String(), nil
+		}
+		if err != nil {
+			return reply.String(), err
+		}
+		if len(resp.Choices) > 0 && resp.Choices[0].Delta.Content != "" {
+			reply.WriteString(resp.Choices[0].Delta.Content)
+			onText(resp.Choices[0].Delta.Content)
+		}
+	}
+}
@@ -24,7 +24,11 @@ func NewEmbedder(cfg *config.Config) (*Embedder, error) {
 		return nil, fmt.Errorf("OPENAI_API_KEY not set (set in config.yaml or environment)")
 	}
 
-	client := openai.NewClient(apiKey)
+	clientConfig := openai.DefaultConfig(apiKey)
+	if cfg.Embeddings.BaseURL != "" {
+		clientConfig.BaseURL = cfg.Embeddings.BaseURL
+	}
+	client := openai.NewClientWithConfig(clientConfig)
 
 	return &Embedder{
 		cfg:    cfg,
@@ -32,6 +36,13 @@ func NewEmbedder(cfg *config.Config) (*Embedder, error) {
 	}, nil
 }
 
+func (e *Embedder) model() string {
+	if e.cfg.Embeddings.Model == "" {
+		return "text-embedding-3-small"
+	}
+	return e.cfg.Embeddings.Model
+}
+
 // Embed generates embeddings for text
 func (e *Embedder) Embed(text string) ([]float32, error) {
 	// Truncate text if too long (8191 tokens max for text-embedding-3-small)
@@ -40,13 +51,8 @@ func (e *Embedder) Embed(text string) ([]float32, error) {
 		text = text[:maxChars]
 	}
 
-	model := e.cfg.Embeddings.Model
-	if model == "" {
-		model = "text-embedding-3-small"
-	}
-
 	resp, err := e.client.CreateEmbeddings(context.Background(), openai.EmbeddingRequest{
-		Model: openai.EmbeddingModel(model),
+		Model: openai.EmbeddingModel(e.model()),
 		Input: []string{text},
 	})
 
@@ -74,13 +80,8 @@ func (e *Embedder) EmbedBatch(texts []string) ([][]float32, error) {
 		}
 	}
 
-	model := e.cfg.Embeddings.Model
-	if model == "" {
-		model = "text-embedding-3-small"
-	}
-
 	resp, err := e.client.CreateEmbeddings(context.Background(), openai.EmbeddingRequest{
-		Model: openai.EmbeddingModel(model),
+		Model: openai.EmbeddingModel(e.model()),
 		Input: truncated,
 	})
 
@@ -0,0 +1,120 @@
+package indexer
+
+import (
+	"time"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/releases"
+	"github.com/user/xhub/internal/sources"
+)
+
+// repoRefreshLimit caps how many repositories one fetch refreshes, one gh
+// call or two each.
+const repoRefreshLimit = 100
+
+// Release polling leaves a few API requests for everything else, and the feed
+// holds the latest releases.
+const (
+	releaseMinRemaining = 10
+	releaseFeedLen      = 50
+)
+
+// Repository changes reported by a refresh
+const (
+	RepoArchived   = "archived"
+	RepoUnarchived = "unarchived"
+	RepoAbandoned  = "abandoned" // No push in db.AbandonedAfter
+)
+
+// RepoChange is a starred repository whose state changed since it was last checked.
+type RepoChange struct {
+	ID       string
+	FullName string
+	Change   string
+}
+
+// RepoRefresher keeps the metadata of starred repositories current.
+type RepoRefresher struct {
+	store *db.Store
+	fetch func(fullName string) (*db.RepoMetadata, error)
+}
+
+func NewRepoRefresher(store *db.Store) *RepoRefresher {
+	return &RepoRefresher{store: store, fetch: sources.FetchRepo}
+}
+
+// Refresh refetches up to limit repositories that weren't checked in maxAge,
+// and reports those that were archived, unarchived or abandoned since. A
+// repository that can't be fetched is skipped; the first such error is
+// returned with the results.
+func (r *RepoRefresher) Refresh(maxAge time.Duration, limit int) (int, []RepoChange, error) {
+	stale, err := r.store.StaleRepos(time.Now().Add(-maxAge), limit)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	refreshed := 0
+	var changes []RepoChange
+	var firstErr error
+	for _, prev := range stale {
+		meta, err := r.fetch(prev.FullName)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if err := r.store.SetRepoMetadata(prev.BookmarkID, meta); err != nil {
+			return refreshed, changes, err
+		}
+		refreshed++
+
+		change := ""
+		switch {
+		case meta.Archived && !prev.Archived:
+			change = RepoArchived
+		case !meta.Archived && prev.Archived:
+			change = RepoUnarchived
+		case !meta.Archived && meta.Abandoned() && !prev.Abandoned():
+			change = RepoAbandoned
+		}
+		if change != "" {
+			changes = append(changes, RepoChange{ID: prev.BookmarkID, FullName: meta.FullName, Change: change})
+		}
+	}
+	return refreshed, changes, firstErr
+}
+
+// PollReleases checks starred repositories for new releases, then rewrites
+// the releases.feed file if one is configured.
+func PollReleases(cfg *config.Config, store *db.Store) (*releases.PollStats, error) {
+	client := releases.NewClient(cfg.Releases.APIURL, releases.Token(cfg.Releases.Token))
+	stats, err := releases.NewPoller(store, client).Poll(releases.PollOptions{
+		MaxRepos:     cfg.Releases.MaxRepos,
+		MinRemaining: releaseMinRemaining,
+	})
+	if err != nil {
+		return stats, err
+	}
+	if cfg.Releases.Feed != "" {
+		if err := WriteReleaseFeed(store, cfg.Releases.Feed); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// FeedReleases returns the releases an Atom feed of them holds, newest first.
+func FeedReleases(store *db.Store) ([]db.Release, error) {
+	return store.RecentReleases(time.Time{}, releaseFeedLen)
+}
+
+// WriteReleaseFeed writes the latest releases to an Atom feed file.
+func WriteReleaseFeed(store *db.Store, path string) error {
+	recent, err := FeedReleases(store)
+	if err != nil {
+		return err
+	}
+	return releases.WriteFeedFile(path, recent)
+}
@@ -0,0 +1,70 @@
+package indexer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/user/xhub/internal/db"
+)
+
+func TestRepoRefresher(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	recent := time.Now().Add(-24 * time.Hour)
+	active := &db.Bookmark{Source: "github", URL: "https://github.com/a/active", Title: "a/active", Repo: &db.RepoMetadata{FullName: "a/active", PushedAt: recent}}
+	archived := &db.Bookmark{Source: "github", URL: "https://github.com/a/archived", Title: "a/archived", Repo: &db.RepoMetadata{FullName: "a/archived", PushedAt: recent}}
+	stalled := &db.Bookmark{Source: "github", URL: "https://github.com/a/stalled", Title: "a/stalled", Repo: &db.RepoMetadata{FullName: "a/stalled", PushedAt: recent}}
+	gone := &db.Bookmark{Source: "github", URL: "https://github.com/a/gone", Title: "a/gone"}
+	for _, b := range []*db.Bookmark{active, archived, stalled, gone} {
+		store.Upsert(b)
+	}
+
+	refresher := NewRepoRefresher(store)
+	refresher.fetch = func(fullName string) (*db.RepoMetadata, error) {
+		meta := &db.RepoMetadata{FullName: fullName, PushedAt: recent, ReadmeExcerpt: "About " + fullName, CheckedAt: time.Now()}
+		switch fullName {
+		case "a/archived":
+			meta.Archived = true
+		case "a/stalled":
+			meta.PushedAt = time.Now().Add(-2 * db.AbandonedAfter)
+		case "a/gone":
+			return nil, fmt.Errorf("not found")
+		}
+		return meta, nil
+	}
+
+	refreshed, changes, err := refresher.Refresh(7*24*time.Hour, 10)
+	if err == nil {
+		t.Error("expected the missing repository's error")
+	}
+	if refreshed != 3 {
+		t.Errorf("expected 3 refreshed, got %d", refreshed)
+	}
+	want := map[string]string{archived.ID: RepoArchived, stalled.ID: RepoAbandoned}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, changes)
+	}
+	for _, c := range changes {
+		if want[c.ID] != c.Change {
+			t.Errorf("unexpected change %+v", c)
+		}
+	}
+	if meta, _ := store.GetRepoMetadata(active.ID); meta == nil || meta.ReadmeExcerpt != "About a/active" {
+		t.Errorf("expected the README excerpt stored, got %+v", meta)
+	}
+
+	// Fresh repositories aren't fetched again
+	refreshed, _, _ = refresher.Refresh(7*24*time.Hour, 10)
+	if refreshed != 0 {
+		t.Errorf("expected nothing to refresh, got %d", refreshed)
+	}
+}
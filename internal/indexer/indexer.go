@@ -3,16 +3,21 @@ package indexer
 import (
 	"database/sql"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/user/xhub/internal/config"
 	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/raindrop"
 	"github.com/user/xhub/internal/sources"
 )
 
 const lastRefreshKey = "last_refresh_at"
 
+// batchPendingLimit caps how many pending items one batch-mode fetch submits.
+const batchPendingLimit = 10000
+
 func extractTitleFromContent(content, fallback string) string {
 	if content == "" {
 		return fallback
@@ -52,16 +57,84 @@ func extractTitleFromContent(content, fallback string) string {
 
 // FetchOptions configures fetch behavior
 type FetchOptions struct {
-	Force     bool     // Full reimport (vs incremental)
-	Reprocess bool     // Re-scrape, re-summarize, re-embed existing items
-	Verbose   bool     // Show detailed processing steps
-	Silent    bool     // Suppress all output (for TUI background refresh)
-	Sources   []string // Filter to specific sources (empty = all)
+	Force          bool     // Full reimport (vs incremental)
+	Reprocess      bool     // Re-scrape, re-summarize, re-embed existing items
+	Verbose        bool     // Show detailed processing steps
+	Silent         bool     // Suppress all output (for TUI background refresh)
+	Batch          bool     // Submit summaries/embeddings through provider batch APIs
+	OverwriteEdits bool     // With Reprocess, also regenerate hand-edited fields
+	Sources        []string // Filter to specific sources (empty = all)
+}
+
+// OpenStore opens the database, creates or drops the raw content index to match
+// search.content_index, and applies search.ranking and the urls rules. The
+// first time, bookmarks saved before URLs were canonicalized are moved to
+// their canonical URLs.
+func OpenStore(cfg *config.Config) (*db.Store, error) {
+	store, err := db.NewStore(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	normalizer, err := cfg.URLs.Normalizer()
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("invalid urls config: %w", err)
+	}
+	store.SetURLNormalizer(normalizer)
+	changes, err := store.MigrateURLs()
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("canonicalizing URLs: %w", err)
+	}
+	if len(changes) > 0 {
+		fmt.Fprintf(os.Stderr, "Moved %d bookmarks to canonical URLs, merging %d duplicates (undo with `xhub dedupe undo`)\n", len(changes), countMerged(changes))
+	}
+	if err := store.SetRanking(searchRanking(cfg.Search.Ranking)); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("invalid search.ranking: %w", err)
+	}
+	if err := store.SetContentIndex(cfg.Search.ContentIndex); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("updating content index: %w", err)
+	}
+	return store, nil
+}
+
+// countMerged counts the changes that merged a bookmark into another.
+func countMerged(changes []db.URLChange) int {
+	n := 0
+	for _, c := range changes {
+		if c.MergedInto != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// searchRanking overlays the configured ranking settings on the defaults.
+func searchRanking(rc config.RankingConfig) db.Ranking {
+	r := db.DefaultRanking()
+	if rc.Fusion != "" {
+		r.Fusion = strings.ToLower(rc.Fusion)
+	}
+	if rc.RRFK != 0 {
+		r.RRFK = rc.RRFK
+	}
+	for signal, w := range rc.Weights {
+		r.SignalWeights[signal] = w
+	}
+	r.FieldWeights = rc.Fields
+	r.RecencyBoost = rc.RecencyBoost
+	if rc.RecencyHalfLifeDays != 0 {
+		r.RecencyHalfLife = time.Duration(rc.RecencyHalfLifeDays * float64(24*time.Hour))
+	}
+	r.SourceBoosts = rc.Sources
+	return r
 }
 
 // Fetch fetches and indexes bookmarks from enabled sources
 func Fetch(cfg *config.Config, opts FetchOptions) error {
-	store, err := db.NewStore(cfg.DataDir)
+	store, err := OpenStore(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -115,7 +188,9 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 
 	// Initialize components
 	scraper := NewScraper()
+	resolver := NewLinkResolver(store)
 	summarizer := NewSummarizer(cfg)
+	validator := NewValidator(cfg)
 	embedder, err := NewEmbedder(cfg)
 	if err != nil {
 		if !opts.Silent {
@@ -124,6 +199,17 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 		embedder = nil
 	}
 
+	var batcher *Batcher
+	if opts.Batch {
+		batcher, err = NewBatcher(cfg, embedder)
+		if err != nil {
+			if !opts.Silent {
+				fmt.Printf("Warning: batch mode disabled: %v\n", err)
+			}
+			batcher = nil
+		}
+	}
+
 	var totalItems int
 	var totalNewItems int
 
@@ -135,6 +221,7 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 	type sourceStats struct {
 		newItems     int
 		skippedItems int
+		linkedPages  int
 	}
 	stats := make(map[string]*sourceStats)
 
@@ -172,6 +259,13 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 					idsToReprocess = append(idsToReprocess, b.ID)
 				}
 			}
+			if len(b.Links) > 0 {
+				linked, err := indexLinks(store, resolver, &b)
+				if err != nil && !opts.Silent {
+					fmt.Printf("Warning: could not save links from %s: %v\n", b.URL, err)
+				}
+				stats[src.Name()].linkedPages += linked
+			}
 			printProgress(i+1, len(bookmarks), "Storing", opts.Silent)
 		}
 		if !opts.Silent {
@@ -180,7 +274,7 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 
 		// Mark existing items for reprocessing if requested
 		if opts.Reprocess && len(idsToReprocess) > 0 {
-			if err := store.MarkForReprocess(idsToReprocess); err != nil {
+			if err := store.ResetForReprocess(idsToReprocess, opts.OverwriteEdits); err != nil {
 				if !opts.Silent {
 					fmt.Printf("Warning: could not mark items for reprocessing: %v\n", err)
 				}
@@ -217,7 +311,7 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 		}
 
 		totalItems += len(bookmarks)
-		totalNewItems += stats[src.Name()].newItems
+		totalNewItems += stats[src.Name()].newItems + stats[src.Name()].linkedPages
 	}
 
 	// Print per-source delta stats
@@ -225,6 +319,76 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 		fmt.Println()
 		for name, s := range stats {
 			fmt.Printf("Found %d new %s items, skipped %d existing\n", s.newItems, name, s.skippedItems)
+			if s.linkedPages > 0 {
+				fmt.Printf("Saved %d new pages linked from %s items\n", s.linkedPages, name)
+			}
+		}
+	}
+
+	// Keep starred repositories' metadata current
+	if stats["github"] != nil && cfg.Sources.GitHubRefreshDays > 0 {
+		maxAge := time.Duration(cfg.Sources.GitHubRefreshDays) * 24 * time.Hour
+		refreshed, changes, err := NewRepoRefresher(store).Refresh(maxAge, repoRefreshLimit)
+		if !opts.Silent {
+			if err != nil {
+				fmt.Printf("Warning: could not refresh all GitHub repositories: %v\n", err)
+			}
+			if refreshed > 0 {
+				fmt.Printf("Refreshed %d GitHub repositories\n", refreshed)
+			}
+			for _, c := range changes {
+				fmt.Printf("  %s: %s\n", c.FullName, c.Change)
+			}
+		}
+	}
+
+	// Track releases of starred repositories when opted in
+	if stats["github"] != nil && cfg.Releases.Enabled {
+		rstats, err := PollReleases(cfg, store)
+		if !opts.Silent {
+			if err != nil {
+				fmt.Printf("Warning: release polling failed: %v\n", err)
+			}
+			if rstats != nil {
+				if len(rstats.New) > 0 {
+					fmt.Printf("Found %d new releases:\n", len(rstats.New))
+				}
+				for _, r := range rstats.New {
+					fmt.Printf("  %s %s\n", r.Repo, r.Title())
+				}
+				if rstats.RateLimited != nil {
+					fmt.Printf("Warning: %v; the remaining repositories are checked next time\n", rstats.RateLimited)
+				}
+			}
+		}
+	}
+
+	// Write edits back to Raindrop when opted in
+	if stats["raindrop"] != nil && cfg.Raindrop.WriteBack {
+		pstats, err := PushRaindrop(cfg, store, raindrop.PushOptions{})
+		if !opts.Silent {
+			if err != nil {
+				fmt.Printf("Warning: Raindrop write-back failed: %v\n", err)
+			}
+			if pstats != nil && pstats.Pushed > 0 {
+				fmt.Printf("Wrote %d edited bookmarks back to Raindrop\n", pstats.Pushed)
+			}
+			if pstats != nil && pstats.Conflicts+pstats.Failed > 0 {
+				fmt.Printf("Warning: %d conflicts and %d failures writing back to Raindrop; see \"xhub raindrop log\"\n", pstats.Conflicts, pstats.Failed)
+			}
+		}
+	}
+
+	// Apply results of batches submitted by earlier runs
+	if batcher != nil && HasPendingBatches(store) {
+		bstats, err := batcher.Poll(store)
+		if err != nil {
+			if !opts.Silent {
+				fmt.Printf("Warning: could not poll batches: %v\n", err)
+			}
+		} else if !opts.Silent {
+			fmt.Printf("Batches: applied %d summaries and %d embeddings, %d failed, %d rejected, %d still running\n",
+				bstats.Summarized, bstats.Embedded, bstats.Failed, bstats.Rejected, bstats.Pending)
 		}
 	}
 
@@ -232,7 +396,11 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 	// Only process if we have new items or --reprocess was requested
 	shouldProcess := totalNewItems > 0 || opts.Reprocess
 	if shouldProcess {
-		pending, err := store.GetPending(100)
+		limit := 100
+		if batcher != nil {
+			limit = batchPendingLimit
+		}
+		pending, err := store.GetPending(limit, batcher == nil)
 		if err != nil {
 			return fmt.Errorf("failed to get pending items: %w", err)
 		}
@@ -242,34 +410,18 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 				fmt.Printf("Processing %d pending items...\n", len(pending))
 			}
 
+			var toBatch []db.Bookmark
 			for i, b := range pending {
 				printProgress(i+1, len(pending), "Processing", opts.Silent)
 
-				// Scrape content
-				if b.RawContent == "" {
-					if opts.Verbose && !opts.Silent {
-						fmt.Printf("\n  Scraping: %s\n", b.URL)
-					}
-					content, err := scraper.Scrape(b.URL)
-					if err != nil {
-						if opts.Verbose && !opts.Silent {
-							fmt.Printf("  Scraping failed: %v\n", err)
-						}
-						b.ScrapeStatus = "failed"
-						store.Update(&b)
-						continue
-					}
-					b.RawContent = content
-					if opts.Verbose && !opts.Silent {
-						fmt.Printf("  Scraped %d characters\n", len(content))
-					}
+				if !scrapePending(store, scraper, validator, &b, opts) {
+					continue
 				}
 
-				if b.Source == "manual" && (b.Title == "" || b.Title == b.URL) {
-					b.Title = extractTitleFromContent(b.RawContent, b.Title)
-				}
-				if b.Source == "x" && isURLOnlyTitle(b.Title) {
-					b.Title = extractTitleFromContent(b.RawContent, b.Title)
+				// Batch mode defers summarizing and embedding to the provider
+				if batcher != nil {
+					toBatch = append(toBatch, b)
+					continue
 				}
 
 				// Summarize
@@ -277,16 +429,19 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 					if opts.Verbose && !opts.Silent {
 						fmt.Printf("  Summarizing...\n")
 					}
-					result, err := summarizer.Summarize(b.RawContent)
+					result, err := summarizer.Summarize(&b)
 					if err != nil {
 						if !opts.Silent {
 							fmt.Printf("Warning: summarization failed for %s: %v\n", b.URL, err)
 						}
-					} else if result != nil {
-						b.Summary = result.Summary
-						if b.Keywords == "" {
-							b.Keywords = result.Keywords
+					} else if reason := validator.CheckSummary(result); reason != "" {
+						if opts.Verbose && !opts.Silent {
+							fmt.Printf("  Rejected: %s\n", reason)
 						}
+						rejectBookmark(store, &b, reason)
+						continue
+					} else if result != nil {
+						ApplySummary(store, &b, result, false)
 						if opts.Verbose && !opts.Silent {
 							fmt.Printf("  Summary: %s\n", result.Summary)
 							fmt.Printf("  Keywords: %s\n", result.Keywords)
@@ -313,12 +468,23 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 				}
 
 				b.ScrapeStatus = "success"
+				b.StatusReason = ""
 				b.ScrapedAt = time.Now()
 				store.Update(&b)
 			}
 			if !opts.Silent {
 				fmt.Println()
 			}
+
+			if len(toBatch) > 0 {
+				if err := batcher.Submit(store, toBatch); err != nil {
+					if !opts.Silent {
+						fmt.Printf("Warning: batch submission failed: %v\n", err)
+					}
+				} else if !opts.Silent {
+					fmt.Printf("Submitted %d items to batch APIs; run fetch --batch again later to apply results\n", len(toBatch))
+				}
+			}
 		}
 	}
 
@@ -333,9 +499,100 @@ func Fetch(cfg *config.Config, opts FetchOptions) error {
 	return nil
 }
 
+// scrapePending fetches content for a pending bookmark and fixes up URL-only titles.
+// Returns false if scraping failed or the page was rejected as junk.
+func scrapePending(store *db.Store, scraper *Scraper, validator *Validator, b *db.Bookmark, opts FetchOptions) bool {
+	if b.RawContent == "" {
+		b.RawContent = tweetContent(store, b)
+	}
+	if b.RawContent == "" {
+		if opts.Verbose && !opts.Silent {
+			fmt.Printf("\n  Scraping: %s\n", b.URL)
+		}
+		content, err := scraper.Scrape(b.URL)
+		if err != nil {
+			if opts.Verbose && !opts.Silent {
+				fmt.Printf("  Scraping failed: %v\n", err)
+			}
+			b.ScrapeStatus = "failed"
+			store.Update(b)
+			return false
+		}
+		b.RawContent = content
+		if opts.Verbose && !opts.Silent {
+			fmt.Printf("  Scraped %d characters\n", len(content))
+		}
+		if reason := validator.CheckContent(content); reason != "" {
+			if opts.Verbose && !opts.Silent {
+				fmt.Printf("  Rejected: %s\n", reason)
+			}
+			rejectBookmark(store, b, reason)
+			return false
+		}
+	}
+
+	if b.Source == "manual" && (b.Title == "" || b.Title == b.URL) {
+		setScrapedTitle(b)
+	}
+	if b.Source == "x" && isURLOnlyTitle(b.Title) {
+		setTweetTitle(store, b)
+	}
+	return true
+}
+
+// tweetContent renders the stored metadata of a tweet bookmark, which stands in
+// for its scraped page. It returns "" when there is none.
+func tweetContent(store *db.Store, b *db.Bookmark) string {
+	if b.Source != "x" {
+		return ""
+	}
+	meta, err := store.GetTweetMetadata(b.ID)
+	if err != nil || meta == nil {
+		return ""
+	}
+	return meta.Document()
+}
+
+// setTweetTitle titles a tweet that was saved with only a link from its link
+// card, falling back to the content.
+func setTweetTitle(store *db.Store, b *db.Bookmark) {
+	if meta, _ := store.GetTweetMetadata(b.ID); meta != nil && !b.IsUserEdited(db.FieldTitle) {
+		if title := meta.Title(); !isURLOnlyTitle(title) {
+			b.Title = title
+			b.SetOrigin(db.FieldTitle, db.OriginSource)
+			return
+		}
+	}
+	setScrapedTitle(b)
+}
+
+// setScrapedTitle derives the title from raw content unless the user edited it.
+func setScrapedTitle(b *db.Bookmark) {
+	if b.IsUserEdited(db.FieldTitle) {
+		return
+	}
+	b.Title = extractTitleFromContent(b.RawContent, b.Title)
+	b.SetOrigin(db.FieldTitle, db.OriginSource)
+}
+
+// ApplySummary copies LLM output into the fields the user has not edited and
+// queues keywords outside the taxonomy for review.
+// Existing keywords are kept unless replaceKeywords is set.
+func ApplySummary(store *db.Store, b *db.Bookmark, result *SummaryResult, replaceKeywords bool) {
+	if !b.IsUserEdited(db.FieldSummary) {
+		b.Summary = result.Summary
+		b.SetOrigin(db.FieldSummary, db.OriginLLM)
+	}
+	if !b.IsUserEdited(db.FieldKeywords) && (replaceKeywords || b.Keywords == "") {
+		b.Keywords = result.Keywords
+		b.SetOrigin(db.FieldKeywords, db.OriginLLM)
+		store.ProposeTags(b.ID, result.ProposedTags)
+	}
+}
+
 // AddManualURL adds a manual URL to the index
 func AddManualURL(cfg *config.Config, url string) error {
-	store, err := db.NewStore(cfg.DataDir)
+	store, err := OpenStore(cfg)
 	if err != nil {
 		return err
 	}
@@ -367,17 +624,27 @@ func AddManualURL(cfg *config.Config, url string) error {
 
 	b.RawContent = content
 
+	validator := NewValidator(cfg)
+	if reason := validator.CheckContent(content); reason != "" {
+		fmt.Printf("Warning: scraped page rejected: %s\n", reason)
+		rejectBookmark(store, b, reason)
+		return nil
+	}
+
 	// Extract title from content (first line usually)
-	b.Title = extractTitleFromContent(content, b.Title)
+	setScrapedTitle(b)
 
 	// Summarize
 	summarizer := NewSummarizer(cfg)
-	result, err := summarizer.Summarize(content)
+	result, err := summarizer.Summarize(b)
 	if err != nil {
 		fmt.Printf("Warning: summarization failed: %v\n", err)
+	} else if reason := validator.CheckSummary(result); reason != "" {
+		fmt.Printf("Warning: summary rejected: %s\n", reason)
+		rejectBookmark(store, b, reason)
+		return nil
 	} else if result != nil {
-		b.Summary = result.Summary
-		b.Keywords = result.Keywords
+		ApplySummary(store, b, result, true)
 	}
 
 	// Embed
@@ -394,14 +661,21 @@ func AddManualURL(cfg *config.Config, url string) error {
 	}
 
 	b.ScrapeStatus = "success"
+	b.StatusReason = ""
 	b.ScrapedAt = time.Now()
 
 	return store.Update(b)
 }
 
+// ReprocessOptions configures single-bookmark reprocessing
+type ReprocessOptions struct {
+	Verbose        bool // Show warnings for embedding issues
+	OverwriteEdits bool // Regenerate fields the user edited by hand
+}
+
 // ReprocessByID re-scrapes and re-summarizes one bookmark by ID.
-func ReprocessByID(cfg *config.Config, id string, verbose bool) (*db.Bookmark, error) {
-	store, err := db.NewStore(cfg.DataDir)
+func ReprocessByID(cfg *config.Config, id string, opts ReprocessOptions) (*db.Bookmark, error) {
+	store, err := OpenStore(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -412,7 +686,7 @@ func ReprocessByID(cfg *config.Config, id string, verbose bool) (*db.Bookmark, e
 		return nil, err
 	}
 
-	if err := reprocessBookmark(store, cfg, b, verbose); err != nil {
+	if err := reprocessBookmark(store, cfg, b, opts); err != nil {
 		return nil, err
 	}
 
@@ -420,8 +694,8 @@ func ReprocessByID(cfg *config.Config, id string, verbose bool) (*db.Bookmark, e
 }
 
 // ReprocessByIDOrURL re-scrapes and re-summarizes one bookmark by ID or URL.
-func ReprocessByIDOrURL(cfg *config.Config, idOrURL string, verbose bool) (*db.Bookmark, error) {
-	store, err := db.NewStore(cfg.DataDir)
+func ReprocessByIDOrURL(cfg *config.Config, idOrURL string, opts ReprocessOptions) (*db.Bookmark, error) {
+	store, err := OpenStore(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -441,46 +715,70 @@ func ReprocessByIDOrURL(cfg *config.Config, idOrURL string, verbose bool) (*db.B
 		}
 	}
 
-	if err := reprocessBookmark(store, cfg, b, verbose); err != nil {
+	if err := reprocessBookmark(store, cfg, b, opts); err != nil {
 		return nil, err
 	}
 
 	return store.Get(b.ID)
 }
 
-func reprocessBookmark(store *db.Store, cfg *config.Config, b *db.Bookmark, verbose bool) error {
+func reprocessBookmark(store *db.Store, cfg *config.Config, b *db.Bookmark, opts ReprocessOptions) error {
+	if opts.OverwriteEdits {
+		for _, field := range []string{db.FieldTitle, db.FieldSummary, db.FieldKeywords} {
+			delete(b.Provenance, field)
+		}
+	}
+
 	// Reset fields to force re-scrape + re-summary.
 	b.ScrapeStatus = "pending"
 	b.RawContent = ""
-	b.Summary = ""
-	b.Keywords = ""
+	if !b.IsUserEdited(db.FieldSummary) {
+		b.Summary = ""
+	}
+	if !b.IsUserEdited(db.FieldKeywords) {
+		b.Keywords = ""
+	}
 	b.ScrapedAt = time.Time{}
 	if err := store.Update(b); err != nil {
 		return err
 	}
 
-	scraper := NewScraper()
-	content, err := scraper.Scrape(b.URL)
-	if err != nil {
-		b.ScrapeStatus = "failed"
-		_ = store.Update(b)
-		return fmt.Errorf("scrape failed: %w", err)
+	validator := NewValidator(cfg)
+	b.RawContent = tweetContent(store, b)
+	if b.RawContent == "" {
+		scraper := NewScraper()
+		content, err := scraper.Scrape(b.URL)
+		if err != nil {
+			b.ScrapeStatus = "failed"
+			_ = store.Update(b)
+			return fmt.Errorf("scrape failed: %w", err)
+		}
+		b.RawContent = content
+
+		if reason := validator.CheckContent(content); reason != "" {
+			rejectBookmark(store, b, reason)
+			return fmt.Errorf("scraped page rejected: %s", reason)
+		}
 	}
-	b.RawContent = content
 
 	// Refresh title from scraped content for non-X sources, and for X URL-only titles.
-	if b.Source != "x" || isURLOnlyTitle(b.Title) {
-		b.Title = extractTitleFromContent(content, b.Title)
+	if b.Source != "x" {
+		setScrapedTitle(b)
+	} else if isURLOnlyTitle(b.Title) {
+		setTweetTitle(store, b)
 	}
 
 	summarizer := NewSummarizer(cfg)
-	result, err := summarizer.Summarize(content)
+	result, err := summarizer.Summarize(b)
 	if err != nil {
 		return fmt.Errorf("summarization failed: %w", err)
 	}
+	if reason := validator.CheckSummary(result); reason != "" {
+		rejectBookmark(store, b, reason)
+		return fmt.Errorf("summary rejected: %s", reason)
+	}
 	if result != nil {
-		b.Summary = result.Summary
-		b.Keywords = result.Keywords
+		ApplySummary(store, b, result, true)
 	}
 
 	embedder, err := NewEmbedder(cfg)
@@ -488,14 +786,15 @@ func reprocessBookmark(store *db.Store, cfg *config.Config, b *db.Bookmark, verb
 		textToEmbed := b.Title + " " + b.Summary + " " + b.Keywords
 		if embedding, err := embedder.Embed(textToEmbed); err == nil {
 			store.UpdateEmbedding(b.ID, embedding)
-		} else if verbose {
+		} else if opts.Verbose {
 			fmt.Printf("Warning: embedding failed for %s: %v\n", b.URL, err)
 		}
-	} else if verbose {
+	} else if opts.Verbose {
 		fmt.Printf("Warning: embeddings disabled: %v\n", err)
 	}
 
 	b.ScrapeStatus = "success"
+	b.StatusReason = ""
 	b.ScrapedAt = time.Now()
 	return store.Update(b)
 }
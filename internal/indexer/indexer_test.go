@@ -52,7 +52,7 @@ func TestFetchOptionsReprocess(t *testing.T) {
     }
 
     // 4. GetPending should now return this item
-    pending, _ := store.GetPending(100)
+    pending, _ := store.GetPending(100, false)
     found := false
     for _, p := range pending {
         if p.ID == b.ID {
@@ -64,3 +64,31 @@ func TestFetchOptionsReprocess(t *testing.T) {
         t.Error("Expected item to appear in GetPending")
     }
 }
+
+func TestScrapePendingUsesTweetMetadata(t *testing.T) {
+    tmpDir, _ := os.MkdirTemp("", "xhub-test")
+    defer os.RemoveAll(tmpDir)
+
+    store, err := db.NewStore(tmpDir)
+    if err != nil {
+        t.Fatalf("Failed to create store: %v", err)
+    }
+    defer store.Close()
+
+    b := &db.Bookmark{
+        Source:       "x",
+        URL:          "https://x.com/dev/status/1",
+        Title:        "https://t.co/abc",
+        ScrapeStatus: "pending",
+        Tweet:        &db.TweetMetadata{TweetID: "1", AuthorHandle: "dev", Text: "https://t.co/abc", Cards: []db.LinkCard{{URL: "https://t.co/abc", Title: "Release notes"}}},
+    }
+    store.Upsert(b)
+
+    // A nil scraper would panic if the page were scraped
+    if !scrapePending(store, nil, nil, b, FetchOptions{Silent: true}) {
+        t.Fatal("expected the tweet to be processed")
+    }
+    if b.RawContent == "" || b.Title == "https://t.co/abc" {
+        t.Errorf("expected content and title from the metadata, got %q / %q", b.Title, b.RawContent)
+    }
+}
@@ -0,0 +1,129 @@
+package indexer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/user/xhub/internal/db"
+)
+
+// linkCacheKind keys resolved short links in the cache; where they lead doesn't change.
+const linkCacheKind = "link"
+
+// shorteners are hosts whose links only redirect to the page they share.
+var shorteners = map[string]bool{
+	"t.co": true, "bit.ly": true, "buff.ly": true, "ow.ly": true, "tinyurl.com": true,
+	"lnkd.in": true, "goo.gl": true, "dlvr.it": true, "trib.al": true, "is.gd": true,
+	"rebrand.ly": true, "cutt.ly": true, "tiny.cc": true, "shorturl.at": true, "amzn.to": true,
+}
+
+// mediaHosts serve the photos, videos and quoted tweets a tweet links to,
+// which aren't pages worth saving on their own.
+var mediaHosts = map[string]bool{
+	"x.com": true, "twitter.com": true, "pic.x.com": true, "pic.twitter.com": true,
+	"video.twimg.com": true, "pbs.twimg.com": true,
+}
+
+// LinkResolver expands shortened links, caching where each one leads.
+type LinkResolver struct {
+	client     *http.Client
+	store      *db.Store
+	shorteners map[string]bool
+}
+
+func NewLinkResolver(store *db.Store) *LinkResolver {
+	return &LinkResolver{
+		client:     &http.Client{Timeout: 10 * time.Second},
+		store:      store,
+		shorteners: shorteners,
+	}
+}
+
+// Resolve follows the redirects of a shortened link and returns the URL it
+// lands on. Other links are returned unchanged.
+func (r *LinkResolver) Resolve(link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil || !r.shorteners[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")] {
+		return link, nil
+	}
+	if cached, ok := r.store.GetCached(linkCacheKind, link, 0); ok {
+		return cached, nil
+	}
+
+	// Some shorteners don't answer HEAD
+	resolved, err := r.follow(http.MethodHead, link)
+	if err != nil {
+		resolved, err = r.follow(http.MethodGet, link)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.store.SetCached(linkCacheKind, link, resolved)
+	return resolved, nil
+}
+
+func (r *LinkResolver) follow(method, link string) (string, error) {
+	req, err := http.NewRequest(method, link, nil)
+	if err != nil {
+		return "", err
+	}
+	// t.co answers browsers with a script instead of a redirect
+	req.Header.Set("User-Agent", "xhub")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("%s returned status %d", link, resp.StatusCode)
+	}
+	return resp.Request.URL.String(), nil
+}
+
+// isMediaLink reports whether a resolved link points at tweet media or another tweet.
+func isMediaLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return true
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return mediaHosts[host] || strings.HasSuffix(host, ".twimg.com")
+}
+
+// indexLinks saves the pages a bookmark links to as its children, pending
+// scraping, and records the links. Pages already saved are linked as they are.
+// Links that can't be resolved are skipped. Returns how many pages are new.
+func indexLinks(store *db.Store, resolver *LinkResolver, parent *db.Bookmark) (int, error) {
+	var linkedIDs []string
+	newPages := 0
+	for _, link := range parent.Links {
+		resolved, err := resolver.Resolve(link)
+		if err != nil || isMediaLink(resolved) {
+			continue
+		}
+		if existing, err := store.GetByURL(resolved); err == nil {
+			linkedIDs = append(linkedIDs, existing.ID)
+			continue
+		}
+		child := &db.Bookmark{
+			Source:       parent.Source,
+			URL:          resolved,
+			Title:        resolved, // Replaced by the page title after scraping
+			CreatedAt:    parent.CreatedAt,
+			ScrapeStatus: "pending",
+			ParentID:     parent.ID,
+		}
+		isNew, err := store.UpsertReturningNew(child)
+		if err != nil {
+			return newPages, err
+		}
+		if isNew {
+			newPages++
+		}
+		linkedIDs = append(linkedIDs, child.ID)
+	}
+	return newPages, store.SetLinks(parent.ID, linkedIDs)
+}
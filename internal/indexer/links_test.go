@@ -0,0 +1,80 @@
+package indexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/user/xhub/internal/db"
+)
+
+func TestIndexLinks(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/short":
+			hits++
+			http.Redirect(w, r, "/article", http.StatusMovedPermanently)
+		case "/photo":
+			http.Redirect(w, r, "https://x.com/a/status/1/photo/1", http.StatusMovedPermanently)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	existing := &db.Bookmark{Source: "raindrop", URL: "https://blog.dev/post", Title: "Post", ScrapeStatus: "success"}
+	store.Upsert(existing)
+
+	resolver := NewLinkResolver(store)
+	u, _ := url.Parse(srv.URL)
+	resolver.shorteners = map[string]bool{u.Hostname(): true}
+
+	tweet := &db.Bookmark{Source: "x", URL: "https://x.com/a/status/1", Title: "Worth reading"}
+	store.Upsert(tweet)
+	tweet.Links = []string{srv.URL + "/short", srv.URL + "/photo", "https://blog.dev/post/"}
+
+	newPages, err := indexLinks(store, resolver, tweet)
+	if err != nil {
+		t.Fatalf("indexLinks failed: %v", err)
+	}
+	if newPages != 1 {
+		t.Errorf("expected 1 new page, got %d", newPages)
+	}
+
+	pages, err := store.LinkedPages(tweet.ID)
+	if err != nil {
+		t.Fatalf("LinkedPages failed: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("expected the article and the existing post, got %+v", pages)
+	}
+	child := pages[0]
+	if child.URL != "http://"+u.Host+"/article" {
+		t.Errorf("expected the canonical resolved article URL, got %s", child.URL)
+	}
+	if child.ParentID != tweet.ID || child.Source != "x" || child.ScrapeStatus != "pending" {
+		t.Errorf("expected a pending child of the tweet, got %+v", child)
+	}
+	if pages[1].ID != existing.ID || pages[1].ParentID != "" || pages[1].Source != "raindrop" {
+		t.Errorf("expected the existing bookmark linked as it was, got %+v", pages[1])
+	}
+
+	// Fetching the tweet again resolves from the cache
+	if _, err := indexLinks(store, resolver, tweet); err != nil {
+		t.Fatalf("indexLinks failed: %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("expected the short link resolved once, got %d requests", hits)
+	}
+}
@@ -0,0 +1,13 @@
+package indexer
+
+import (
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/raindrop"
+)
+
+// PushRaindrop writes notes and tags edited in xhub back to Raindrop.
+func PushRaindrop(cfg *config.Config, store *db.Store, opts raindrop.PushOptions) (*raindrop.PushStats, error) {
+	client := raindrop.NewClient(cfg.Raindrop.APIURL, raindrop.Token(cfg.Raindrop.Token))
+	return raindrop.NewPusher(store, client).Push(opts)
+}
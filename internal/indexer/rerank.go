@@ -0,0 +1,318 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+const (
+	rerankCacheKind      = "rerank"
+	defaultRerankTopN    = 50
+	defaultRerankBudget  = 2 * time.Second
+	rerankDocumentMaxLen = 1000 // Characters
+)
+
+const rerankPrompt = `Rate how well each bookmark matches a search in a personal bookmarks collection, from 0 (unrelated) to 10 (exactly what was searched for).
+
+Search: %s
+
+Bookmarks:
+%s
+Answer with only a JSON array of %d scores in the order given, e.g. [7, 0, 3].`
+
+// Reranker reorders the top search results by asking a rerank model or the LLM
+// how well each one matches the query. Scores are cached per query and bookmark
+// version, so repeating a search doesn't call the provider again.
+type Reranker struct {
+	cfg    *config.Config
+	store  *db.Store
+	client *http.Client
+	ctx    context.Context // Canceled by Close
+	cancel context.CancelFunc
+	mu     sync.Mutex // Guards closed against scores cached after the budget
+	closed bool
+}
+
+// NewReranker returns a reranker, or nil when search.rerank.provider is unset.
+// A nil Reranker leaves results unchanged. Close it before closing the store.
+func NewReranker(cfg *config.Config, store *db.Store) *Reranker {
+	if cfg.Search.Rerank.Provider == "" {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Reranker{
+		cfg:    cfg,
+		store:  store,
+		client: &http.Client{Timeout: 30 * time.Second},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Close abandons scoring still running past the budget; its scores are no
+// longer cached, so the store can be closed.
+func (r *Reranker) Close() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.cancel()
+}
+
+type rerankOutcome struct {
+	scores []float64
+	err    error
+}
+
+// Rerank reorders the top search.rerank.top_n results. When scoring takes longer
+// than search.rerank.budget_ms, the fused order is returned with an error; the
+// scores are still cached when they arrive, unless the reranker was closed, so
+// the next identical search uses them.
+func (r *Reranker) Rerank(query string, results []db.SearchResult) ([]db.SearchResult, error) {
+	if r == nil || len(results) < 2 {
+		return results, nil
+	}
+	q, err := db.ParseQuery(query)
+	if err != nil || !q.HasText() {
+		return results, nil
+	}
+	text := q.Text()
+
+	rc := r.cfg.Search.Rerank
+	n := min(r.topN(), len(results))
+	budget := defaultRerankBudget
+	if rc.BudgetMS > 0 {
+		budget = time.Duration(rc.BudgetMS) * time.Millisecond
+	}
+
+	// Use cached scores where possible, score the rest in one request
+	scores := make([]float64, n)
+	keys := make([]string, n)
+	var missing []int
+	for i := 0; i < n; i++ {
+		keys[i] = r.cacheKey(text, &results[i].Bookmark)
+		cached, ok := r.store.GetCached(rerankCacheKind, keys[i], 0)
+		if score, err := strconv.ParseFloat(cached, 64); ok && err == nil {
+			scores[i] = score
+		} else {
+			missing = append(missing, i)
+		}
+	}
+
+	if len(missing) > 0 {
+		docs := make([]string, len(missing))
+		for j, i := range missing {
+			docs[j] = rerankDocument(&results[i].Bookmark)
+		}
+		done := make(chan rerankOutcome, 1)
+		go func() {
+			fresh, err := r.score(text, docs)
+			if err == nil {
+				r.mu.Lock()
+				if !r.closed {
+					for j, i := range missing {
+						r.store.SetCached(rerankCacheKind, keys[i], strconv.FormatFloat(fresh[j], 'g', -1, 64))
+					}
+				}
+				r.mu.Unlock()
+			}
+			done <- rerankOutcome{fresh, err}
+		}()
+
+		select {
+		case out := <-done:
+			if out.err != nil {
+				return results, fmt.Errorf("reranking failed: %w", out.err)
+			}
+			for j, i := range missing {
+				scores[i] = out.scores[j]
+			}
+		case <-time.After(budget):
+			return results, fmt.Errorf("reranking took longer than %s; showing fused order", budget)
+		}
+	}
+
+	reranked := make([]db.SearchResult, len(results))
+	copy(reranked, results)
+	for i := 0; i < n; i++ {
+		var explain db.Explanation
+		if reranked[i].Explain != nil {
+			explain = *reranked[i].Explain
+		}
+		explain.Rerank = &db.RerankScore{Score: scores[i], FusedRank: i + 1}
+		reranked[i].Explain = &explain
+	}
+	top := reranked[:n]
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Explain.Rerank.Score > top[j].Explain.Rerank.Score
+	})
+	return reranked, nil
+}
+
+// topN is how many of the top results are reranked.
+func (r *Reranker) topN() int {
+	if r.cfg.Search.Rerank.TopN > 0 {
+		return r.cfg.Search.Rerank.TopN
+	}
+	return defaultRerankTopN
+}
+
+// Candidates returns how many results to search for so that limit of them can
+// be shown and every one the reranker would reorder is fetched: the larger of
+// limit and search.rerank.top_n. Trim the reranked results to limit.
+func (r *Reranker) Candidates(limit int) int {
+	if r == nil {
+		return limit
+	}
+	return max(limit, r.topN())
+}
+
+// cacheKey identifies a score by reranker, query and bookmark version.
+func (r *Reranker) cacheKey(query string, b *db.Bookmark) string {
+	rc := r.cfg.Search.Rerank
+	model := rc.Model
+	if rc.Provider == "llm" {
+		model = r.cfg.LLM.Provider + "/" + r.cfg.LLM.Model
+	}
+	h := sha256.Sum256([]byte(strings.Join([]string{
+		rc.Provider, model, strings.ToLower(query), b.ID, b.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}, "\x00")))
+	return hex.EncodeToString(h[:])
+}
+
+// rerankDocument is the text a reranker judges a bookmark by.
+func rerankDocument(b *db.Bookmark) string {
+	parts := []string{strings.TrimSpace(b.Title)}
+	if b.Summary != "" {
+		parts = append(parts, strings.TrimSpace(b.Summary))
+	}
+	if b.Keywords != "" {
+		parts = append(parts, "Tags: "+b.Keywords)
+	}
+	doc := strings.Join(parts, "\n")
+	if utf8.RuneCountInString(doc) > rerankDocumentMaxLen {
+		doc = string([]rune(doc)[:rerankDocumentMaxLen])
+	}
+	return doc
+}
+
+func (r *Reranker) score(query string, docs []string) ([]float64, error) {
+	switch r.cfg.Search.Rerank.Provider {
+	case "api":
+		return r.scoreWithAPI(query, docs)
+	case "llm":
+		return r.scoreWithLLM(query, docs)
+	default:
+		return nil, fmt.Errorf("unsupported rerank provider: %s", r.cfg.Search.Rerank.Provider)
+	}
+}
+
+type rerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// scoreWithAPI calls a Cohere/Jina-compatible /rerank endpoint.
+func (r *Reranker) scoreWithAPI(query string, docs []string) ([]float64, error) {
+	rc := r.cfg.Search.Rerank
+	if rc.URL == "" {
+		return nil, fmt.Errorf("search.rerank.url not set")
+	}
+	body, err := json.Marshal(rerankRequest{Model: rc.Model, Query: query, Documents: docs, TopN: len(docs)})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodPost, rc.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	apiKey := os.Getenv("RERANK_API_KEY")
+	if apiKey == "" {
+		apiKey = rc.APIKey
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank API returned %s", resp.Status)
+	}
+
+	var out rerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid rerank response: %w", err)
+	}
+	scores := make([]float64, len(docs))
+	for _, res := range out.Results {
+		if res.Index < 0 || res.Index >= len(docs) {
+			return nil, fmt.Errorf("rerank response has out-of-range index %d", res.Index)
+		}
+		scores[res.Index] = res.RelevanceScore
+	}
+	return scores, nil
+}
+
+// scoreWithLLM asks the configured LLM to rate the documents, scaled to [0, 1].
+func (r *Reranker) scoreWithLLM(query string, docs []string) ([]float64, error) {
+	var list strings.Builder
+	for i, doc := range docs {
+		fmt.Fprintf(&list, "[%d] %s\n\n", i+1, strings.ReplaceAll(doc, "\n", " — "))
+	}
+	response, err := NewSummarizer(r.cfg).Complete(fmt.Sprintf(rerankPrompt, query, list.String(), len(docs)))
+	if err != nil {
+		return nil, err
+	}
+	return parseRerankScores(response, len(docs))
+}
+
+// parseRerankScores reads the JSON array of 0-10 ratings from an LLM response.
+func parseRerankScores(response string, n int) ([]float64, error) {
+	start, end := strings.Index(response, "["), strings.LastIndex(response, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no scores in rerank response: %q", response)
+	}
+	var ratings []float64
+	if err := json.Unmarshal([]byte(response[start:end+1]), &ratings); err != nil {
+		return nil, fmt.Errorf("invalid rerank scores: %w", err)
+	}
+	if len(ratings) != n {
+		return nil, fmt.Errorf("expected %d rerank scores, got %d", n, len(ratings))
+	}
+	scores := make([]float64, n)
+	for i, rating := range ratings {
+		scores[i] = min(max(rating, 0), 10) / 10
+	}
+	return scores, nil
+}
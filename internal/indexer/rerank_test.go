@@ -0,0 +1,159 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+func TestParseRerankScores(t *testing.T) {
+	scores, err := parseRerankScores("Scores: [10, 0, 4.5, 12]", 4)
+	if err != nil {
+		t.Fatalf("parseRerankScores failed: %v", err)
+	}
+	want := []float64{1, 0, 0.45, 1}
+	for i := range want {
+		if scores[i] != want[i] {
+			t.Errorf("score %d = %f, want %f", i, scores[i], want[i])
+		}
+	}
+
+	if _, err := parseRerankScores("[1, 2]", 3); err == nil {
+		t.Error("expected error for the wrong number of scores")
+	}
+	if _, err := parseRerankScores("no idea", 1); err == nil {
+		t.Error("expected error for a response without scores")
+	}
+}
+
+func TestRerankWithAPI(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	calls := 0
+	delay := time.Duration(0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		time.Sleep(delay)
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("missing API key, got %q", r.Header.Get("Authorization"))
+		}
+		var req rerankRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.Query, "source:") || req.Model != "test-rerank" {
+			t.Errorf("unexpected request %+v", req)
+		}
+		// Prefer documents mentioning tokio
+		var results []string
+		for i, doc := range req.Documents {
+			score := 0.1
+			if strings.Contains(doc, "tokio") {
+				score = 0.9
+			}
+			results = append(results, fmt.Sprintf(`{"index":%d,"relevance_score":%g}`, i, score))
+		}
+		fmt.Fprintf(w, `{"results":[%s]}`, strings.Join(results, ","))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		DataDir: tmpDir,
+		Search: config.SearchConfig{Rerank: config.RerankConfig{
+			Provider: "api", URL: srv.URL, Model: "test-rerank", APIKey: "test-key", TopN: 2, BudgetMS: 500,
+		}},
+	}
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	results := []db.SearchResult{
+		{Bookmark: db.Bookmark{ID: "a", Title: "Async runtime comparison"}},
+		{Bookmark: db.Bookmark{ID: "b", Title: "Async in Rust", Summary: "Notes on tokio"}},
+		{Bookmark: db.Bookmark{ID: "c", Title: "tokio docs"}},
+	}
+
+	reranker := NewReranker(cfg, store)
+	for i := 0; i < 2; i++ {
+		got, err := reranker.Rerank("async runtime source:github", results)
+		if err != nil {
+			t.Fatalf("Rerank failed: %v", err)
+		}
+		// Only the top 2 are reranked; the rest keep their place
+		if got[0].ID != "b" || got[1].ID != "a" || got[2].ID != "c" {
+			t.Fatalf("unexpected order %s %s %s", got[0].ID, got[1].ID, got[2].ID)
+		}
+		if r := got[0].Explain.Rerank; r == nil || r.FusedRank != 2 || r.Score != 0.9 {
+			t.Errorf("unexpected rerank explanation %+v", r)
+		}
+		if got[2].Explain != nil {
+			t.Errorf("expected results past top_n untouched")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected cached scores on the second search, got %d API calls", calls)
+	}
+	if results[0].ID != "a" {
+		t.Error("Rerank modified its input")
+	}
+
+	// Over budget: keep the fused order
+	delay = 200 * time.Millisecond
+	cfg.Search.Rerank.BudgetMS = 20
+	got, err := reranker.Rerank("tokio", results)
+	if err == nil {
+		t.Error("expected an over-budget error")
+	}
+	if got[0].ID != "a" {
+		t.Errorf("expected fused order when over budget, got %s first", got[0].ID)
+	}
+
+	// Closing before the late scores arrive keeps them out of the store
+	reranker.Close()
+	time.Sleep(2 * delay)
+	if _, ok := store.GetCached(rerankCacheKind, reranker.cacheKey("tokio", &results[0].Bookmark), 0); ok {
+		t.Error("expected no scores cached after Close")
+	}
+
+	// Disabled
+	if got, err := NewReranker(&config.Config{}, store).Rerank("tokio", results); err != nil || got[0].ID != "a" {
+		t.Errorf("expected a nil reranker to leave results unchanged")
+	}
+}
+
+func TestRerankDocumentTruncatesByCharacter(t *testing.T) {
+	doc := rerankDocument(&db.Bookmark{Title: strings.Repeat("é", rerankDocumentMaxLen+10)})
+	if !utf8.ValidString(doc) || utf8.RuneCountInString(doc) != rerankDocumentMaxLen {
+		t.Errorf("expected %d whole characters, got %d (valid: %v)", rerankDocumentMaxLen, utf8.RuneCountInString(doc), utf8.ValidString(doc))
+	}
+}
+
+func TestRerankCandidates(t *testing.T) {
+	var none *Reranker
+	if got := none.Candidates(20); got != 20 {
+		t.Errorf("nil reranker: expected 20 candidates, got %d", got)
+	}
+
+	cfg := &config.Config{Search: config.SearchConfig{Rerank: config.RerankConfig{Provider: "api"}}}
+	if got := NewReranker(cfg, nil).Candidates(20); got != defaultRerankTopN {
+		t.Errorf("default top_n: expected %d candidates, got %d", defaultRerankTopN, got)
+	}
+	cfg.Search.Rerank.TopN = 100
+	if got := NewReranker(cfg, nil).Candidates(20); got != 100 {
+		t.Errorf("top_n above limit: expected 100 candidates, got %d", got)
+	}
+	cfg.Search.Rerank.TopN = 5
+	if got := NewReranker(cfg, nil).Candidates(20); got != 20 {
+		t.Errorf("top_n below limit: expected 20 candidates, got %d", got)
+	}
+}
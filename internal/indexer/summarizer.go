@@ -10,6 +10,9 @@ import (
 	"github.com/liushuangls/go-anthropic/v2"
 	"github.com/sashabaranov/go-openai"
 	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/prompts"
+	"github.com/user/xhub/internal/taxonomy"
 )
 
 var debugMode bool
@@ -21,75 +24,115 @@ func SetDebugMode(enabled bool) {
 
 // SummaryResult contains the LLM-generated summary and keywords
 type SummaryResult struct {
-	Summary  string
-	Keywords string
-	RawResponse string // For debugging purposes
+	Summary      string
+	Keywords     string
+	ProposedTags []string // Keywords outside the taxonomy, dropped from Keywords
+	RawResponse  string   // For debugging purposes
 }
 
 // Summarizer generates summaries using LLM
 type Summarizer struct {
-	cfg *config.Config
+	cfg       *config.Config
+	prompts   *prompts.Set
+	taxonomy  *taxonomy.Taxonomy // nil = unconstrained keywords
+	configErr error              // Reported on use; config.Load validates prompts and taxonomy up front
 }
 
 func NewSummarizer(cfg *config.Config) *Summarizer {
-	return &Summarizer{cfg: cfg}
+	s := &Summarizer{cfg: cfg}
+	s.prompts, s.configErr = cfg.LLM.PromptSet()
+	if s.configErr == nil {
+		s.taxonomy, s.configErr = taxonomy.Load(cfg.TaxonomyPath())
+	}
+	return s
 }
 
-const defaultSummaryPrompt = `Analyze this content and provide:
-1. A short summary of what this is about. The goal is to provide semantic content to improve retrieval when searching for this resource in a bookmarks database
-2. 3-5 relevant keywords separated by commas
-
-Format your response exactly as:
-SUMMARY: <your summary>
-KEYWORDS: <keyword1>, <keyword2>, <keyword3>
-
-Content:
-%s`
+// buildPrompt renders the summary prompt selected for the bookmark.
+func (s *Summarizer) buildPrompt(b *db.Bookmark) (string, error) {
+	if s.configErr != nil {
+		return "", s.configErr
+	}
 
-func (s *Summarizer) Summarize(content string) (*SummaryResult, error) {
 	// Truncate content for LLM
 	const maxContentLen = 10000
+	content := b.RawContent
 	if len(content) > maxContentLen {
 		content = content[:maxContentLen]
 	}
 
-	promptTemplate := defaultSummaryPrompt
-	if s.cfg.LLM.SummaryPrompt != "" {
-		promptTemplate = s.cfg.LLM.SummaryPrompt
-	}
-	prompt := fmt.Sprintf(promptTemplate, content)
-
-	var response string
-	var err error
+	return s.prompts.Render(prompts.Data{
+		URL:        b.URL,
+		Source:     b.Source,
+		Title:      b.Title,
+		Notes:      b.Notes,
+		Keywords:   b.Keywords,
+		Vocabulary: strings.Join(s.taxonomy.Names(), ", "),
+		Content:    content,
+	})
+}
 
-	switch s.cfg.LLM.Provider {
-	case "anthropic":
-		response, err = s.summarizeWithAnthropic(prompt)
-	case "openai", "openrouter", "cerebras", "zai", "gemini":
-		response, err = s.summarizeWithOpenAI(prompt)
-	default:
-		return nil, fmt.Errorf("unsupported LLM provider: %s", s.cfg.LLM.Provider)
+// Summarize generates a summary and keywords from the bookmark's raw content.
+func (s *Summarizer) Summarize(b *db.Bookmark) (*SummaryResult, error) {
+	prompt, err := s.buildPrompt(b)
+	if err != nil {
+		return nil, err
 	}
 
+	response, err := s.Complete(prompt)
 	if err != nil {
 		return nil, err
 	}
 
-	result := parseResponse(response)
+	result := s.parse(response)
 	result.RawResponse = response
 	return result, nil
 }
 
-func (s *Summarizer) summarizeWithAnthropic(prompt string) (string, error) {
+// parse extracts the summary and keywords, constraining keywords to the taxonomy.
+func (s *Summarizer) parse(response string) *SummaryResult {
+	result := parseResponse(response)
+	if s.taxonomy != nil {
+		tags, unknown := s.taxonomy.Normalize(result.Keywords)
+		result.Keywords = strings.Join(tags, ", ")
+		result.ProposedTags = unknown
+	}
+	return result
+}
+
+// Complete sends a single-turn prompt to the configured LLM provider.
+func (s *Summarizer) Complete(prompt string) (string, error) {
+	switch s.cfg.LLM.Provider {
+	case "anthropic":
+		return s.summarizeWithAnthropic(prompt)
+	case "openai", "openrouter", "cerebras", "zai", "gemini":
+		return s.summarizeWithOpenAI(prompt)
+	default:
+		return "", fmt.Errorf("unsupported LLM provider: %s", s.cfg.LLM.Provider)
+	}
+}
+
+// anthropicClient builds an Anthropic client from the LLM config.
+func (s *Summarizer) anthropicClient() (*anthropic.Client, error) {
 	apiKey := os.Getenv("ANTHROPIC_API_KEY")
 	if apiKey == "" {
 		apiKey = s.cfg.LLM.APIKey
 	}
 	if apiKey == "" {
-		return "", fmt.Errorf("ANTHROPIC_API_KEY not set (set in config.yaml or environment)")
+		return nil, fmt.Errorf("ANTHROPIC_API_KEY not set (set in config.yaml or environment)")
 	}
 
-	client := anthropic.NewClient(apiKey)
+	var opts []anthropic.ClientOption
+	if s.cfg.LLM.BaseURL != "" {
+		opts = append(opts, anthropic.WithBaseURL(s.cfg.LLM.BaseURL))
+	}
+	return anthropic.NewClient(apiKey, opts...), nil
+}
+
+func (s *Summarizer) summarizeWithAnthropic(prompt string) (string, error) {
+	client, err := s.anthropicClient()
+	if err != nil {
+		return "", err
+	}
 
 	if debugMode {
 		log.Printf("[DEBUG] Sending request to Anthropic with model %s", s.cfg.LLM.Model)
@@ -128,7 +171,8 @@ func (s *Summarizer) summarizeWithAnthropic(prompt string) (string, error) {
 	return resp.Content[0].GetText(), nil
 }
 
-func (s *Summarizer) summarizeWithOpenAI(prompt string) (string, error) {
+// openAIClient builds an OpenAI-compatible client for the configured provider.
+func (s *Summarizer) openAIClient() (*openai.Client, string, error) {
 	var apiKey string
 	var baseURL string
 
@@ -168,10 +212,11 @@ func (s *Summarizer) summarizeWithOpenAI(prompt string) (string, error) {
 		if apiKey == "" {
 			apiKey = s.cfg.LLM.APIKey
 		}
+		baseURL = s.cfg.LLM.BaseURL
 	}
 
 	if apiKey == "" {
-		return "", fmt.Errorf("API key not set for provider %s (set in config.yaml or environment)", s.cfg.LLM.Provider)
+		return nil, "", fmt.Errorf("API key not set for provider %s (set in config.yaml or environment)", s.cfg.LLM.Provider)
 	}
 
 	config := openai.DefaultConfig(apiKey)
@@ -179,7 +224,14 @@ func (s *Summarizer) summarizeWithOpenAI(prompt string) (string, error) {
 		config.BaseURL = baseURL
 	}
 
-	client := openai.NewClientWithConfig(config)
+	return openai.NewClientWithConfig(config), baseURL, nil
+}
+
+func (s *Summarizer) summarizeWithOpenAI(prompt string) (string, error) {
+	client, baseURL, err := s.openAIClient()
+	if err != nil {
+		return "", err
+	}
 
 	if debugMode {
 		log.Printf("[DEBUG] Sending request to %s with model %s", baseURL, s.cfg.LLM.Model)
@@ -0,0 +1,216 @@
+package indexer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/taxonomy"
+)
+
+// tagEmbedChunk stays under the embeddings API's per-request input limit.
+const tagEmbedChunk = 1000
+
+// NormalizeOptions configures keyword normalization
+type NormalizeOptions struct {
+	DryRun      bool // Report changes without writing them
+	DropUnknown bool // Remove keywords outside the taxonomy (hand-edited keywords are kept)
+}
+
+// KeywordChange is one bookmark's keywords before and after normalization.
+type KeywordChange struct {
+	ID     string
+	URL    string
+	Before string
+	After  string
+}
+
+func loadTaxonomy(cfg *config.Config) (*taxonomy.Taxonomy, error) {
+	tax, err := taxonomy.Load(cfg.TaxonomyPath())
+	if err != nil {
+		return nil, err
+	}
+	if tax == nil {
+		return nil, fmt.Errorf("no taxonomy at %s", cfg.TaxonomyPath())
+	}
+	return tax, nil
+}
+
+// NormalizeKeywords rewrites existing keywords onto canonical taxonomy tags and
+// queues the keywords it doesn't know for review.
+func NormalizeKeywords(cfg *config.Config, opts NormalizeOptions) ([]KeywordChange, error) {
+	tax, err := loadTaxonomy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	bookmarks, err := store.ListWithKeywords()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []KeywordChange
+	for _, b := range bookmarks {
+		tags, unknown := tax.Normalize(b.Keywords)
+		userEdited := b.IsUserEdited(db.FieldKeywords)
+		if !opts.DropUnknown || userEdited {
+			tags = append(tags, unknown...)
+		}
+
+		after := strings.Join(tags, ", ")
+		if after == b.Keywords {
+			continue
+		}
+		changes = append(changes, KeywordChange{ID: b.ID, URL: b.URL, Before: b.Keywords, After: after})
+		if opts.DryRun {
+			continue
+		}
+		if err := store.SetKeywords(b.ID, after); err != nil {
+			return changes, err
+		}
+		if !userEdited {
+			store.ProposeTags(b.ID, unknown)
+		}
+	}
+	return changes, nil
+}
+
+// SuggestTagMerges embeds every tag in use or in the taxonomy and returns pairs
+// that are at least threshold similar, e.g. "golang" -> "go".
+func SuggestTagMerges(cfg *config.Config, threshold float64) ([]taxonomy.Merge, error) {
+	tax, err := taxonomy.Load(cfg.TaxonomyPath())
+	if err != nil {
+		return nil, err
+	}
+
+	embedder, err := NewEmbedder(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	bookmarks, err := store.ListWithKeywords()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := tagCounts(tax, bookmarks)
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var vectors [][]float32
+	for start := 0; start < len(names); start += tagEmbedChunk {
+		end := min(start+tagEmbedChunk, len(names))
+		chunk, err := embedder.EmbedBatch(names[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("embedding tag names: %w", err)
+		}
+		vectors = append(vectors, chunk...)
+	}
+
+	return tax.SuggestMerges(names, vectors, counts, threshold), nil
+}
+
+// tagCounts counts the bookmarks using each keyword. Keywords that are a
+// taxonomy tag spelled differently count under the taxonomy's name, so "Go" in
+// the taxonomy and "go" in keywords aren't suggested as a merge; other
+// keywords count under their lowercase spelling.
+func tagCounts(tax *taxonomy.Taxonomy, bookmarks []db.Bookmark) map[string]int {
+	counts := make(map[string]int)
+	names := make(map[string]string)
+	for _, name := range tax.Names() {
+		names[taxonomy.Key(name)] = name
+		counts[name] = 0
+	}
+	for _, b := range bookmarks {
+		for _, kw := range taxonomy.Split(b.Keywords) {
+			name, ok := names[taxonomy.Key(kw)]
+			if !ok {
+				name = strings.ToLower(kw)
+			}
+			counts[name]++
+		}
+	}
+	return counts
+}
+
+// AcceptTag adds a proposed tag to the taxonomy, as an alias of aliasOf if set,
+// and tags the bookmarks that proposed it. Returns the number of bookmarks updated.
+func AcceptTag(cfg *config.Config, tag, aliasOf string) (int, error) {
+	tax, err := taxonomy.Load(cfg.TaxonomyPath())
+	if err != nil {
+		return 0, err
+	}
+	if tax == nil {
+		tax, _ = taxonomy.New(nil)
+	}
+	if err := tax.Add(tag, aliasOf); err != nil {
+		return 0, err
+	}
+	if err := tax.Save(cfg.TaxonomyPath()); err != nil {
+		return 0, err
+	}
+	canonical, _ := tax.Canonical(tag)
+
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer store.Close()
+
+	ids, err := store.ResolveTagProposal(tag, "accepted")
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, id := range ids {
+		b, err := store.Get(id)
+		if err != nil || b.IsUserEdited(db.FieldKeywords) {
+			continue
+		}
+		tags, _ := tax.Normalize(b.Keywords)
+		if slices.Contains(tags, canonical) {
+			continue
+		}
+		keywords := canonical
+		if b.Keywords != "" {
+			keywords = b.Keywords + ", " + canonical
+		}
+		if err := store.SetKeywords(id, keywords); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// RejectTag drops a proposed tag; it won't be proposed again.
+func RejectTag(cfg *config.Config, tag string) error {
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	_, err = store.ResolveTagProposal(tag, "rejected")
+	return err
+}
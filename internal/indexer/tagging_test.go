@@ -0,0 +1,31 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/taxonomy"
+)
+
+func TestTagCounts(t *testing.T) {
+	tax, err := taxonomy.New([]taxonomy.Tag{{Name: "Go"}, {Name: "machine learning"}, {Name: "unused"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	bookmarks := []db.Bookmark{
+		{Keywords: "go, Machine-Learning, WebGPU"},
+		{Keywords: "GO, webgpu"},
+		{Keywords: "web-gpu"},
+	}
+
+	counts := tagCounts(tax, bookmarks)
+	want := map[string]int{"Go": 2, "machine learning": 1, "unused": 0, "webgpu": 2, "web-gpu": 1}
+	if len(counts) != len(want) {
+		t.Fatalf("got %v, want %v", counts, want)
+	}
+	for name, n := range want {
+		if counts[name] != n {
+			t.Errorf("%s: got %d, want %d (%v)", name, counts[name], n, counts)
+		}
+	}
+}
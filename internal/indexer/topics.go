@@ -0,0 +1,181 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/user/xhub/internal/cluster"
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/taxonomy"
+)
+
+const (
+	defaultTopicMinSize = 3  // Smaller clusters are left out
+	topicNameSamples    = 12 // Most central members shown to the LLM to name a cluster
+	topicSeed           = 1  // Fixed so rebuilding unchanged data gives the same topics
+)
+
+const topicPrompt = `These bookmarks were grouped together because they are about the same topic:
+
+%s
+Name the topic they share. Answer with only a JSON object:
+{"name": "<2-4 word topic name>", "description": "<one sentence describing what the bookmarks cover>"}`
+
+// TopicOptions configures BuildTopics.
+type TopicOptions struct {
+	K       int  // Number of clusters (default: scaled to the number of bookmarks)
+	MinSize int  // Clusters with fewer bookmarks are left out (default 3)
+	Silent  bool // Suppress progress output
+}
+
+// BuildTopics clusters the embeddings of visible bookmarks, has the LLM name
+// each cluster from its most central members, and stores the clusters as topic
+// collections, replacing the previous ones.
+func BuildTopics(cfg *config.Config, store *db.Store, opts TopicOptions) ([]db.Collection, error) {
+	minSize := opts.MinSize
+	if minSize <= 0 {
+		minSize = defaultTopicMinSize
+	}
+
+	embeddings, err := store.VisibleEmbeddings()
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) < 2*minSize {
+		return nil, fmt.Errorf("only %d bookmarks have embeddings, not enough to find topics (embeddings are computed by xhub fetch when an embeddings provider is configured)", len(embeddings))
+	}
+
+	// Cluster in a stable order so the seed gives reproducible topics
+	ids := make([]string, 0, len(embeddings))
+	for id := range embeddings {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	vectors := make([][]float32, len(ids))
+	for i, id := range ids {
+		vectors[i] = embeddings[id]
+	}
+
+	k := opts.K
+	if k <= 0 {
+		k = cluster.DefaultK(len(ids))
+	}
+	if !opts.Silent {
+		fmt.Printf("Clustering %d bookmarks into %d groups...\n", len(ids), k)
+	}
+	res := cluster.KMeans(vectors, k, topicSeed)
+
+	summarizer := NewSummarizer(cfg)
+	var topics []db.CollectionMembers
+	names := make(map[string]int)
+	leftOut := 0
+	for c := 0; c < res.K; c++ {
+		members := res.Members(c)
+		if len(members) < minSize {
+			leftOut += len(members)
+			continue
+		}
+		topic := db.CollectionMembers{}
+		for _, i := range members {
+			topic.BookmarkIDs = append(topic.BookmarkIDs, ids[i])
+		}
+
+		var samples []db.Bookmark
+		for _, id := range topic.BookmarkIDs[:min(topicNameSamples, len(topic.BookmarkIDs))] {
+			if b, err := store.Get(id); err == nil {
+				samples = append(samples, *b)
+			}
+		}
+		topic.Name, topic.Description, err = nameTopic(summarizer, samples)
+		if err != nil {
+			topic.Name = fallbackTopicName(samples)
+			if !opts.Silent {
+				fmt.Printf("  Naming failed, using %q: %v\n", topic.Name, err)
+			}
+		}
+		// Keep names unique so topics can be looked up by name
+		names[strings.ToLower(topic.Name)]++
+		if n := names[strings.ToLower(topic.Name)]; n > 1 {
+			topic.Name = fmt.Sprintf("%s (%d)", topic.Name, n)
+		}
+		if !opts.Silent {
+			fmt.Printf("  %4d  %s\n", len(topic.BookmarkIDs), topic.Name)
+		}
+		topics = append(topics, topic)
+	}
+	if leftOut > 0 && !opts.Silent {
+		fmt.Printf("%d bookmarks in clusters smaller than %d were left out\n", leftOut, minSize)
+	}
+
+	if err := store.ReplaceCollections(db.CollectionTopic, topics); err != nil {
+		return nil, err
+	}
+	return store.ListCollections(db.CollectionTopic)
+}
+
+// nameTopic asks the LLM for a name and description of a cluster.
+func nameTopic(s *Summarizer, samples []db.Bookmark) (string, string, error) {
+	var list strings.Builder
+	for _, b := range samples {
+		fmt.Fprintf(&list, "- %s", strings.Join(strings.Fields(b.Title), " "))
+		if b.Summary != "" {
+			fmt.Fprintf(&list, ": %s", strings.Join(strings.Fields(b.Summary), " "))
+		}
+		if b.Keywords != "" {
+			fmt.Fprintf(&list, " (tags: %s)", b.Keywords)
+		}
+		list.WriteString("\n")
+	}
+
+	response, err := s.Complete(fmt.Sprintf(topicPrompt, list.String()))
+	if err != nil {
+		return "", "", err
+	}
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return "", "", fmt.Errorf("no JSON object in response")
+	}
+	var named struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &named); err != nil {
+		return "", "", fmt.Errorf("invalid topic JSON: %w", err)
+	}
+	named.Name = strings.TrimSpace(named.Name)
+	if named.Name == "" {
+		return "", "", fmt.Errorf("empty topic name")
+	}
+	return named.Name, strings.TrimSpace(named.Description), nil
+}
+
+// fallbackTopicName names a cluster after its most common tags, or its most
+// central bookmark when none are tagged.
+func fallbackTopicName(samples []db.Bookmark) string {
+	counts := make(map[string]int)
+	for _, b := range samples {
+		for _, tag := range taxonomy.Split(b.Keywords) {
+			counts[strings.ToLower(tag)]++
+		}
+	}
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) > 0 {
+		return strings.Join(tags[:min(3, len(tags))], ", ")
+	}
+	if len(samples) > 0 && samples[0].Title != "" {
+		return strings.Join(strings.Fields(samples[0].Title), " ")
+	}
+	return "Untitled topic"
+}
@@ -0,0 +1,81 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+func TestBuildTopics(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		answer := "I can't name this"
+		if strings.Contains(req.Messages[0].Content[0].Text, "Rust crate") {
+			answer = `{"name": "Rust async", "description": "Async runtimes for Rust."}`
+		}
+		fmt.Fprintf(w, `{"type":"message","role":"assistant","content":[{"type":"text","text":%q}]}`, answer)
+	}))
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test")
+	cfg := &config.Config{
+		DataDir: tmpDir,
+		LLM:     config.LLMConfig{Provider: "anthropic", Model: "test-model", BaseURL: srv.URL + "/v1"},
+	}
+
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	for i := 0; i < 4; i++ {
+		rust := &db.Bookmark{Source: "github", URL: fmt.Sprintf("https://github.com/r/%d", i), Title: fmt.Sprintf("Rust crate %d", i)}
+		store.Upsert(rust)
+		store.UpdateEmbedding(rust.ID, []float32{1, 0.01 * float32(i), 0})
+		bread := &db.Bookmark{Source: "x", URL: fmt.Sprintf("https://x.com/b/status/%d", i), Title: "Bread", Keywords: "baking, sourdough"}
+		store.Upsert(bread)
+		store.UpdateEmbedding(bread.ID, []float32{0, 0.01 * float32(i), 1})
+	}
+	lone := &db.Bookmark{Source: "manual", URL: "https://example.com", Title: "Lone"}
+	store.Upsert(lone)
+	store.UpdateEmbedding(lone.ID, []float32{0, 1, 0})
+
+	topics, err := BuildTopics(cfg, store, TopicOptions{K: 3, Silent: true})
+	if err != nil {
+		t.Fatalf("BuildTopics failed: %v", err)
+	}
+	if len(topics) != 2 {
+		t.Fatalf("expected 2 topics with the lone bookmark left out, got %+v", topics)
+	}
+	names := map[string]db.Collection{}
+	for _, topic := range topics {
+		names[topic.Name] = topic
+	}
+	if c, ok := names["Rust async"]; !ok || c.Count != 4 || c.Description != "Async runtimes for Rust." {
+		t.Errorf("expected the LLM-named Rust topic, got %+v", topics)
+	}
+	if c, ok := names["baking, sourdough"]; !ok || c.Count != 4 {
+		t.Errorf("expected a topic named after shared tags when naming fails, got %+v", topics)
+	}
+
+	if _, err := BuildTopics(cfg, store, TopicOptions{MinSize: 10, Silent: true}); err == nil {
+		t.Error("expected an error with too few embeddings for the minimum size")
+	}
+}
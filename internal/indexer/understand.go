@@ -0,0 +1,152 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+// queryCacheKind keys interpreted queries in the LLM cache. Entries expire daily
+// because relative dates ("last spring") depend on today.
+const (
+	queryCacheKind   = "query"
+	queryCacheMaxAge = 24 * time.Hour
+	maxPromptTags    = 200
+)
+
+const interpretPrompt = `You turn requests for a personal bookmarks search engine into structured filters.
+Today is %s.
+Sources: x (X/Twitter bookmarks), raindrop (Raindrop.io bookmarks), github (starred GitHub repositories), manual (added by hand).
+Known tags: %s
+
+Answer with only a JSON object:
+{"text": "<topic words for semantic search>", "sources": [], "tags": [], "after": "YYYY-MM-DD", "before": "YYYY-MM-DD"}
+
+Rules:
+- "after" is the first day and "before" the last day of any time period mentioned; leave them "" otherwise.
+- Only use sources and tags from the lists above, and only when the request clearly asks for them.
+- "text" keeps the subject of the request without the words used for filters.
+
+Request: %s`
+
+// Interpretation is the structured form of a natural-language query.
+type Interpretation struct {
+	Text    string   `json:"text"`
+	Sources []string `json:"sources"`
+	Tags    []string `json:"tags"`
+	After   string   `json:"after"`
+	Before  string   `json:"before"`
+}
+
+// Query renders the interpretation in search syntax, so it can be shown and edited.
+func (in *Interpretation) Query() string {
+	var parts []string
+	if text := strings.NewReplacer(`"`, "", ":", " ").Replace(in.Text); strings.TrimSpace(text) != "" {
+		parts = append(parts, strings.Join(strings.Fields(text), " "))
+	}
+	for _, s := range in.Sources {
+		parts = append(parts, "source:"+s)
+	}
+	for _, t := range in.Tags {
+		if strings.ContainsAny(t, " \t") {
+			t = `"` + t + `"`
+		}
+		parts = append(parts, "tag:"+t)
+	}
+	if in.After != "" {
+		parts = append(parts, "after:"+in.After)
+	}
+	if in.Before != "" {
+		parts = append(parts, "before:"+in.Before)
+	}
+	return strings.Join(parts, " ")
+}
+
+// InterpretQuery uses the LLM to rewrite a free-text query into search syntax,
+// e.g. "rust repos I starred last spring about async" becomes
+// "async source:github tag:rust after:2025-03-01 before:2025-05-31".
+// Results are cached per query string. The original query is returned when the
+// LLM finds nothing to filter on.
+func InterpretQuery(cfg *config.Config, store *db.Store, query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return query, nil
+	}
+	key := strings.ToLower(query)
+	if cached, ok := store.GetCached(queryCacheKind, key, queryCacheMaxAge); ok {
+		return cached, nil
+	}
+
+	tags, err := store.ListTags()
+	if err != nil {
+		return "", err
+	}
+	known := make(map[string]string, len(tags))
+	names := make([]string, 0, maxPromptTags)
+	for i, t := range tags {
+		known[strings.ToLower(t.Name)] = t.Name
+		if i < maxPromptTags {
+			names = append(names, t.Name)
+		}
+	}
+
+	prompt := fmt.Sprintf(interpretPrompt, time.Now().Format("2006-01-02 (Monday)"), strings.Join(names, ", "), query)
+	response, err := NewSummarizer(cfg).Complete(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	in, err := parseInterpretation(response, known)
+	if err != nil {
+		return "", err
+	}
+	result := in.Query()
+	if q, err := db.ParseQuery(result); err != nil || (!q.HasText() && !q.HasFilters()) {
+		result = query
+	}
+
+	store.SetCached(queryCacheKind, key, result)
+	return result, nil
+}
+
+// parseInterpretation decodes the LLM's JSON and drops anything it made up:
+// unknown sources or tags and malformed dates.
+func parseInterpretation(response string, knownTags map[string]string) (*Interpretation, error) {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON in query interpretation: %q", response)
+	}
+	var raw Interpretation
+	if err := json.Unmarshal([]byte(response[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("invalid query interpretation: %w", err)
+	}
+
+	in := &Interpretation{Text: raw.Text}
+	for _, s := range raw.Sources {
+		switch s = strings.ToLower(strings.TrimSpace(s)); s {
+		case "x", "raindrop", "github", "manual":
+			in.Sources = append(in.Sources, s)
+		}
+	}
+	for _, t := range raw.Tags {
+		if name, ok := knownTags[strings.ToLower(strings.TrimSpace(t))]; ok {
+			in.Tags = append(in.Tags, name)
+		}
+	}
+	if validDate(raw.After) {
+		in.After = raw.After
+	}
+	if validDate(raw.Before) {
+		in.Before = raw.Before
+	}
+	return in, nil
+}
+
+func validDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
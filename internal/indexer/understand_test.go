@@ -0,0 +1,86 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+func TestParseInterpretation(t *testing.T) {
+	known := map[string]string{"rust": "rust", "machine learning": "machine learning"}
+	response := "Here you go:\n" + `{"text": "async: runtimes", "sources": ["GitHub", "mastodon"], "tags": ["Rust", "made-up", "Machine Learning"], "after": "2025-03-01", "before": "spring"}`
+
+	in, err := parseInterpretation(response, known)
+	if err != nil {
+		t.Fatalf("parseInterpretation failed: %v", err)
+	}
+	want := `async runtimes source:github tag:rust tag:"machine learning" after:2025-03-01`
+	if got := in.Query(); got != want {
+		t.Errorf("Query() = %s, want %s", got, want)
+	}
+	if _, err := db.ParseQuery(in.Query()); err != nil {
+		t.Errorf("rendered query doesn't parse: %v", err)
+	}
+
+	if _, err := parseInterpretation("I can't help with that", known); err == nil {
+		t.Error("expected error for response without JSON")
+	}
+}
+
+func TestInterpretQueryCaches(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req struct {
+			Messages []struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Messages[0].Content[0].Text, "Known tags: rust") {
+			t.Errorf("expected known tags in prompt")
+		}
+		answer := `{"text": "async", "sources": ["github"], "tags": ["rust"], "after": "2025-03-01", "before": "2025-05-31"}`
+		fmt.Fprintf(w, `{"type":"message","role":"assistant","content":[{"type":"text","text":%q}]}`, answer)
+	}))
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test")
+	cfg := &config.Config{
+		DataDir: tmpDir,
+		LLM:     config.LLMConfig{Provider: "anthropic", Model: "test-model", BaseURL: srv.URL + "/v1"},
+	}
+
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	store.Upsert(&db.Bookmark{Source: "github", URL: "https://github.com/tokio-rs/tokio", Title: "tokio", Keywords: "rust"})
+
+	want := "async source:github tag:rust after:2025-03-01 before:2025-05-31"
+	for i := 0; i < 2; i++ {
+		got, err := InterpretQuery(cfg, store, "rust repos I starred last spring about async")
+		if err != nil {
+			t.Fatalf("InterpretQuery failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 LLM call thanks to the cache, got %d", calls)
+	}
+}
@@ -0,0 +1,175 @@
+package indexer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+)
+
+// minContentLen is the shortest scrape treated as real content.
+const minContentLen = 50
+
+// maxBlockerLen bounds the pages checked for blocker phrases; real articles
+// that merely mention "captcha" or "cookies" are much longer than interstitials.
+const maxBlockerLen = 4000
+
+// blockerPatterns maps a rejection reason to lowercase phrases that identify it.
+var blockerPatterns = []struct {
+	reason  string
+	phrases []string
+}{
+	{"captcha or bot check", []string{
+		"captcha", "verify you are human", "are you a robot", "checking your browser",
+		"unusual traffic", "just a moment...", "attention required! | cloudflare",
+	}},
+	{"javascript required", []string{
+		"enable javascript", "javascript is required", "javascript is disabled",
+		"javascript is not available", "please turn on javascript", "requires javascript",
+	}},
+	{"cookie wall", []string{
+		"accept all cookies", "cookie consent", "we use cookies", "manage cookie preferences",
+	}},
+	{"login wall", []string{
+		"sign in to continue", "log in to continue", "login to continue", "please log in",
+		"please sign in", "you must be logged in", "login required", "sign in to view",
+	}},
+	{"access denied", []string{
+		"access denied", "403 forbidden", "you don't have permission to access",
+	}},
+}
+
+// maxRefusalLen bounds the responses searched throughout for refusal phrases;
+// longer ones only count as refusals when they open with one, since real
+// summaries may quote or discuss such phrases.
+const maxRefusalLen = 200
+
+// refusalPatterns are lowercase phrases that mark an LLM response as a refusal.
+var refusalPatterns = []string{
+	"i cannot access", "i can't access", "i'm unable to access", "i am unable to access",
+	"i don't have access", "i do not have access", "i cannot browse", "i can't browse",
+	"unable to view the content", "i cannot view", "i can't view", "as an ai",
+	"no content was provided", "the content provided is empty", "there is no content",
+	"appears to be a captcha", "appears to be a login page", "i'm sorry, but i",
+}
+
+const classifierPrompt = `You are checking scraped web pages before they are summarized for a bookmarks database.
+Decide whether the text below is real page content, or a blocker such as a captcha, bot check,
+cookie wall, "JavaScript required" notice, login screen, paywall, or error page.
+
+Answer with exactly one line:
+VALID
+or
+JUNK: <short reason>
+
+Text:
+%s`
+
+// Validator flags scraped pages that are not real content and summaries that are LLM refusals.
+type Validator struct {
+	summarizer *Summarizer // Optional LLM classifier (nil = heuristics only)
+}
+
+// NewValidator returns nil when validation is disabled; a nil Validator accepts everything.
+func NewValidator(cfg *config.Config) *Validator {
+	if !cfg.Validation.Enabled {
+		return nil
+	}
+	v := &Validator{}
+	if cfg.Validation.LLMClassifier {
+		v.summarizer = NewSummarizer(cfg)
+	}
+	return v
+}
+
+// CheckContent returns a rejection reason for junk scraped content, or "" if it looks real.
+func (v *Validator) CheckContent(content string) string {
+	if v == nil {
+		return ""
+	}
+	if reason := detectJunkContent(content); reason != "" {
+		return reason
+	}
+	// Only short pages can be blockers; see detectJunkContent
+	if v.summarizer == nil || len(strings.TrimSpace(stripReaderHeader(content))) > maxBlockerLen {
+		return ""
+	}
+
+	response, err := v.summarizer.Complete(fmt.Sprintf(classifierPrompt, content))
+	if err != nil {
+		return "" // Classifier is best effort
+	}
+	response = strings.TrimSpace(response)
+	if strings.HasPrefix(strings.ToUpper(response), "JUNK") {
+		reason := strings.TrimSpace(strings.TrimLeft(response[len("JUNK"):], ":"))
+		if reason == "" {
+			reason = "not page content"
+		}
+		return "classifier: " + reason
+	}
+	return ""
+}
+
+// CheckSummary returns a rejection reason when the LLM refused or produced no summary.
+func (v *Validator) CheckSummary(result *SummaryResult) string {
+	if v == nil || result == nil {
+		return ""
+	}
+	if result.Summary == "" {
+		return "empty LLM summary"
+	}
+	if detectRefusal(result.Summary) {
+		return "LLM refusal"
+	}
+	return ""
+}
+
+func detectJunkContent(content string) string {
+	body := strings.TrimSpace(stripReaderHeader(content))
+	if len(body) < minContentLen {
+		return "empty page"
+	}
+	if len(body) > maxBlockerLen {
+		return ""
+	}
+	lower := strings.ToLower(body)
+	for _, p := range blockerPatterns {
+		for _, phrase := range p.phrases {
+			if strings.Contains(lower, phrase) {
+				return p.reason
+			}
+		}
+	}
+	return ""
+}
+
+func detectRefusal(text string) bool {
+	lower := strings.TrimLeft(strings.ToLower(strings.TrimSpace(text)), `"'*`)
+	short := len(lower) <= maxRefusalLen
+	for _, phrase := range refusalPatterns {
+		if strings.HasPrefix(lower, phrase) || short && strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripReaderHeader drops the "Title:/URL Source:" preamble Jina Reader adds,
+// so an empty page isn't mistaken for content.
+func stripReaderHeader(content string) string {
+	const marker = "Markdown Content:"
+	if i := strings.Index(content, marker); i >= 0 {
+		return content[i+len(marker):]
+	}
+	return content
+}
+
+// rejectBookmark records why a bookmark was not stored as a successful scrape.
+func rejectBookmark(store *db.Store, b *db.Bookmark, reason string) {
+	b.ScrapeStatus = "rejected"
+	b.StatusReason = reason
+	b.ScrapedAt = time.Now()
+	store.Update(b)
+}
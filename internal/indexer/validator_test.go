@@ -0,0 +1,96 @@
+package indexer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/user/xhub/internal/config"
+)
+
+func TestDetectJunkContent(t *testing.T) {
+	article := "Title: Understanding Go interfaces\nURL Source: https://example.com\nMarkdown Content:\n" +
+		strings.Repeat("Interfaces in Go are satisfied implicitly. ", 20)
+
+	cases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"real article", article, ""},
+		{"long article mentioning cookies", article + strings.Repeat("We use cookies to track state in this example. ", 100), ""},
+		{"empty after reader header", "Title: x\nURL Source: https://x.com\nMarkdown Content:\n  ", "empty page"},
+		{"cloudflare", "Just a moment...\nChecking your browser before accessing example.com. This process is automatic.", "captcha or bot check"},
+		{"x.com javascript", "JavaScript is not available. We've detected that JavaScript is disabled in this browser.", "javascript required"},
+		{"cookie wall", "Before you continue, we use cookies and data to deliver services. Accept all cookies or reject.", "cookie wall"},
+		{"login wall", "Please sign in to continue to your account. Forgot password? Create an account instead.", "login wall"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := detectJunkContent(tc.content); got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidatorCheckSummary(t *testing.T) {
+	v := &Validator{}
+	cases := []struct {
+		name    string
+		summary string
+		want    string
+	}{
+		{"normal", "A library for building terminal UIs in Go.", ""},
+		{"empty", "", "empty LLM summary"},
+		{"cannot access", "I cannot access this URL, but based on the title it may be about Go.", "LLM refusal"},
+		{"as an ai", "As an AI language model, I can't browse the web.", "LLM refusal"},
+		{"quoted refusal", "\"I'm sorry, but I can't help with that.\"", "LLM refusal"},
+		{"summary discussing refusals", "A study of how chatbots answer. Many replies open with \"as an AI\" or \"I'm sorry, but I\", " +
+			"which the author argues erodes trust; the post compares refusal rates across models and suggests prompt changes that reduce them.", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := v.CheckSummary(&SummaryResult{Summary: tc.summary}); got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	var disabled *Validator
+	if got := disabled.CheckSummary(&SummaryResult{}); got != "" {
+		t.Errorf("nil validator should accept everything, got %q", got)
+	}
+}
+
+func TestValidatorClassifiesShortPagesOnly(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"type":"message","role":"assistant","content":[{"type":"text","text":"JUNK: paywall"}]}`)
+	}))
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test")
+	cfg := &config.Config{
+		DataDir:    tmpDir,
+		LLM:        config.LLMConfig{Provider: "anthropic", Model: "test-model", BaseURL: srv.URL + "/v1"},
+		Validation: config.ValidationConfig{Enabled: true, LLMClassifier: true},
+	}
+	v := NewValidator(cfg)
+
+	teaser := "Subscribe to keep reading. This article is for subscribers only, starting at $1 a week."
+	if got := v.CheckContent(teaser); got != "classifier: paywall" {
+		t.Errorf("expected the short page classified, got %q", got)
+	}
+	if got := v.CheckContent(strings.Repeat("Interfaces in Go are satisfied implicitly. ", 200)); got != "" || calls != 1 {
+		t.Errorf("expected long pages accepted without asking the LLM, got %q after %d calls", got, calls)
+	}
+}
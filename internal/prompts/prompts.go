@@ -0,0 +1,163 @@
+package prompts
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Default is the built-in summary prompt.
+const Default = `Analyze this content and provide:
+1. A short summary of what this is about. The goal is to provide semantic content to improve retrieval when searching for this resource in a bookmarks database
+2. 3-5 relevant keywords separated by commas{{if .Vocabulary}}, chosen from this tag vocabulary where possible: {{.Vocabulary}}. Only use a keyword outside the vocabulary for a central topic it does not cover{{end}}
+
+Format your response exactly as:
+SUMMARY: <your summary>
+KEYWORDS: <keyword1>, <keyword2>, <keyword3>
+
+Content:
+{{.Content}}`
+
+// DefaultName is the prompt name used when no rule matches.
+const DefaultName = "default"
+
+// Data holds the fields available to prompt templates.
+type Data struct {
+	URL        string
+	Source     string
+	Title      string
+	Notes      string
+	Keywords   string // Existing keywords, if any
+	Vocabulary string // Comma-separated taxonomy tags, empty when tagging is unconstrained
+	Content    string
+}
+
+// RuleSpec selects a named prompt for bookmarks from a source and/or matching a URL pattern.
+type RuleSpec struct {
+	Source     string
+	URLPattern string
+	Prompt     string
+}
+
+type rule struct {
+	source  string
+	pattern *regexp.Regexp
+	prompt  string
+}
+
+// Set holds parsed prompt templates and the rules choosing between them.
+type Set struct {
+	templates map[string]*template.Template
+	rules     []rule
+}
+
+// NewSet parses the named templates and rules. The "default" prompt falls back to
+// legacy (a summary_prompt, which may still use %s) and then to the built-in Default.
+func NewSet(legacy string, named map[string]string, specs []RuleSpec) (*Set, error) {
+	s := &Set{templates: make(map[string]*template.Template)}
+
+	defaultText := Default
+	if legacy != "" {
+		defaultText = FromLegacy(legacy)
+	}
+	tmpl, err := Parse(DefaultName, defaultText)
+	if err != nil {
+		return nil, err
+	}
+	s.templates[DefaultName] = tmpl
+
+	for name, text := range named {
+		tmpl, err := Parse(name, text)
+		if err != nil {
+			return nil, err
+		}
+		s.templates[name] = tmpl
+	}
+
+	for i, spec := range specs {
+		if spec.Source == "" && spec.URLPattern == "" {
+			return nil, fmt.Errorf("prompt rule %d: needs a source or url_pattern", i+1)
+		}
+		if _, ok := s.templates[spec.Prompt]; !ok {
+			return nil, fmt.Errorf("prompt rule %d: unknown prompt %q", i+1, spec.Prompt)
+		}
+		r := rule{source: spec.Source, prompt: spec.Prompt}
+		if spec.URLPattern != "" {
+			r.pattern, err = regexp.Compile(spec.URLPattern)
+			if err != nil {
+				return nil, fmt.Errorf("prompt rule %d: invalid url_pattern: %w", i+1, err)
+			}
+		}
+		s.rules = append(s.rules, r)
+	}
+
+	return s, nil
+}
+
+// Parse compiles a prompt template and checks it only references known fields.
+func Parse(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("prompt %q: %w", name, err)
+	}
+	if err := tmpl.Execute(io.Discard, Data{}); err != nil {
+		return nil, fmt.Errorf("prompt %q: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// FromLegacy converts a fmt-style prompt into a template: the first %s becomes
+// the content and %% a percent sign. Without a %s the content follows the
+// prompt. Prompts that already use template actions are returned unchanged.
+func FromLegacy(text string) string {
+	if strings.Contains(text, "{{") {
+		return text
+	}
+	var b strings.Builder
+	placed := false
+	for i := 0; i < len(text); i++ {
+		if text[i] == '%' && i+1 < len(text) {
+			switch {
+			case text[i+1] == '%':
+				b.WriteByte('%')
+				i++
+				continue
+			case text[i+1] == 's' && !placed:
+				b.WriteString("{{.Content}}")
+				placed = true
+				i++
+				continue
+			}
+		}
+		b.WriteByte(text[i])
+	}
+	if !placed {
+		b.WriteString("\n\n{{.Content}}")
+	}
+	return b.String()
+}
+
+// Select returns the name of the first prompt whose rule matches, or "default".
+func (s *Set) Select(d Data) string {
+	for _, r := range s.rules {
+		if r.source != "" && r.source != d.Source {
+			continue
+		}
+		if r.pattern != nil && !r.pattern.MatchString(d.URL) {
+			continue
+		}
+		return r.prompt
+	}
+	return DefaultName
+}
+
+// Render selects and executes the prompt for the given data.
+func (s *Set) Render(d Data) (string, error) {
+	var b strings.Builder
+	if err := s.templates[s.Select(d)].Execute(&b, d); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
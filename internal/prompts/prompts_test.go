@@ -0,0 +1,99 @@
+package prompts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetSelectsPromptByRule(t *testing.T) {
+	set, err := NewSet("", map[string]string{
+		"tweet": "Tweet by {{.URL}}: {{.Content}}",
+		"paper": "Paper {{.Title}} ({{.Keywords}}): {{.Content}}",
+	}, []RuleSpec{
+		{Source: "x", Prompt: "tweet"},
+		{URLPattern: `arxiv\.org`, Prompt: "paper"},
+	})
+	if err != nil {
+		t.Fatalf("NewSet failed: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		data Data
+		want string
+	}{
+		{"source rule", Data{Source: "x", URL: "https://x.com/a/status/1", Content: "hi"}, "Tweet by https://x.com/a/status/1: hi"},
+		{"url rule", Data{Source: "raindrop", URL: "https://arxiv.org/abs/1", Title: "T", Keywords: "ml", Content: "c"}, "Paper T (ml): c"},
+		{"default", Data{Source: "github", URL: "https://github.com/a/b", Content: "repo"}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := set.Render(tc.data)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if tc.want == "" {
+				if !strings.HasPrefix(got, "Analyze this content") || !strings.HasSuffix(got, "repo") {
+					t.Fatalf("expected default prompt, got %q", got)
+				}
+				return
+			}
+			if got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLegacyPromptWithStrayPercent(t *testing.T) {
+	set, err := NewSet("Summarize (100% concise):\n%s", nil, nil)
+	if err != nil {
+		t.Fatalf("NewSet failed: %v", err)
+	}
+	got, err := set.Render(Data{Content: "body"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if got != "Summarize (100% concise):\nbody" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestNewSetValidation(t *testing.T) {
+	cases := []struct {
+		name  string
+		named map[string]string
+		rules []RuleSpec
+	}{
+		{"parse error", map[string]string{"bad": "{{.Content"}, nil},
+		{"unknown field", map[string]string{"bad": "{{.Body}}"}, nil},
+		{"unknown prompt", nil, []RuleSpec{{Source: "x", Prompt: "missing"}}},
+		{"empty rule", nil, []RuleSpec{{Prompt: "default"}}},
+		{"bad pattern", nil, []RuleSpec{{URLPattern: "(", Prompt: "default"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewSet("", tc.named, tc.rules); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestFromLegacy(t *testing.T) {
+	cases := []struct {
+		name, legacy, want string
+	}{
+		{"escaped percent", "Keep it under 100%% of the length:\n%s", "Keep it under 100% of the length:\n{{.Content}}"},
+		{"no placeholder", "Summarize this page.", "Summarize this page.\n\n{{.Content}}"},
+		{"escaped placeholder", "Write %%s literally, then:\n%s", "Write %s literally, then:\n{{.Content}}"},
+		{"template", "{{.Title}}: %s", "{{.Title}}: %s"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FromLegacy(tc.legacy); got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package raindrop writes notes and tags edited in xhub back to Raindrop.
+package raindrop
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the Raindrop REST API.
+const DefaultAPIURL = "https://api.raindrop.io/rest/v1"
+
+// Client reads and updates raindrops through the Raindrop API.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Token returns the Raindrop API token from RAINDROP_TOKEN, or the configured one.
+func Token(configured string) string {
+	if token := os.Getenv("RAINDROP_TOKEN"); token != "" {
+		return token
+	}
+	return configured
+}
+
+// Item is the part of a raindrop that write-back reads and changes.
+type Item struct {
+	ID         int64     `json:"_id"`
+	Note       string    `json:"note"`
+	Tags       []string  `json:"tags"`
+	LastUpdate time.Time `json:"lastUpdate"`
+}
+
+// Update is a change to a raindrop; nil fields are left as they are.
+type Update struct {
+	Note *string   `json:"note,omitempty"`
+	Tags *[]string `json:"tags,omitempty"`
+}
+
+// Get fetches a raindrop.
+func (c *Client) Get(id int64) (*Item, error) {
+	return c.do(http.MethodGet, fmt.Sprintf("/raindrop/%d", id), nil)
+}
+
+// Put changes a raindrop and returns it as updated.
+func (c *Client) Put(id int64, u Update) (*Item, error) {
+	return c.do(http.MethodPut, fmt.Sprintf("/raindrop/%d", id), u)
+}
+
+func (c *Client) do(method, path string, body interface{}) (*Item, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("no Raindrop API token (set RAINDROP_TOKEN or raindrop.token)")
+	}
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, &reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("User-Agent", "xhub")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Result       bool   `json:"result"`
+		Item         *Item  `json:"item"`
+		ErrorMessage string `json:"errorMessage"`
+	}
+	if resp.StatusCode >= 400 {
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.ErrorMessage != "" {
+			return nil, fmt.Errorf("%s %s: %s (%s)", method, path, resp.Status, result.ErrorMessage)
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	if !result.Result || result.Item == nil {
+		return nil, fmt.Errorf("%s %s: no item in response", method, path)
+	}
+	return result.Item, nil
+}
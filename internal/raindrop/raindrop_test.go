@@ -0,0 +1,176 @@
+package raindrop
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/user/xhub/internal/db"
+)
+
+// fakeRaindrop serves raindrops from memory, bumping lastUpdate on each change.
+type fakeRaindrop struct {
+	mu    sync.Mutex
+	items map[int64]*Item
+	puts  int
+}
+
+func (f *fakeRaindrop) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		http.Error(w, `{"result":false,"errorMessage":"Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/raindrop/"), 10, 64)
+	item, ok := f.items[id]
+	if !ok {
+		http.Error(w, `{"result":false,"errorMessage":"Not found"}`, http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodPut {
+		var u Update
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if u.Note != nil {
+			item.Note = *u.Note
+		}
+		if u.Tags != nil {
+			item.Tags = *u.Tags
+		}
+		item.LastUpdate = item.LastUpdate.Add(time.Minute)
+		f.puts++
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": true, "item": item})
+}
+
+func TestPush(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	synced := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake := &fakeRaindrop{items: make(map[int64]*Item)}
+	imported := make(map[int64]*db.Bookmark)
+	for id, item := range map[int64]Item{
+		1: {Note: "first read", Tags: []string{"go"}},
+		2: {Note: "a CLI", Tags: []string{"go"}},
+		3: {Note: "old note"},
+		4: {Note: "unchanged", Tags: []string{"web"}},
+		5: {Note: "untouched"},
+	} {
+		item.ID, item.LastUpdate = id, synced
+		fake.items[id] = &item
+		b := &db.Bookmark{Source: "raindrop", URL: "https://example.com/" + strconv.FormatInt(id, 10), Title: "Page " + strconv.FormatInt(id, 10),
+			Notes: item.Note, Keywords: strings.Join(item.Tags, ","), Raindrop: &db.RaindropMetadata{
+				RaindropID: id, LastUpdate: synced, Note: item.Note, Tags: item.Tags,
+			}}
+		if err := store.Upsert(b); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+		imported[id] = b
+	}
+	edit := func(id int64, field, value string) {
+		b, _ := store.Get(imported[id].ID)
+		if field == db.FieldNotes {
+			b.Notes = value
+		} else {
+			b.Keywords = value
+		}
+		b.SetOrigin(field, db.OriginUser)
+		if err := store.Update(b); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+	edit(1, db.FieldNotes, "read twice")
+	edit(2, db.FieldKeywords, "go, cli")
+	edit(3, db.FieldNotes, "my note")
+	edit(4, db.FieldNotes, "my take")
+	// Raindrop changed the note edited in xhub, and an unrelated field
+	fake.items[3].Note, fake.items[3].LastUpdate = "their note", synced.Add(time.Hour)
+	fake.items[4].Tags, fake.items[4].LastUpdate = []string{"web", "css"}, synced.Add(time.Hour)
+
+	// A sync that fetches the edited bookmark keeps the edit pending against the old sync point
+	b3 := *imported[3]
+	b3.ID, b3.Notes = "", "their note"
+	b3.Raindrop = &db.RaindropMetadata{RaindropID: 3, LastUpdate: synced.Add(time.Hour), Note: "their note"}
+	if err := store.Upsert(&b3); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	pusher := NewPusher(store, NewClient(server.URL, "secret"))
+
+	actions := func(stats *PushStats) map[int64]string {
+		got := make(map[int64]string)
+		for _, r := range stats.Results {
+			got[r.Edit.RaindropID] = r.Action
+		}
+		return got
+	}
+	want := map[int64]string{1: db.SyncPushed, 2: db.SyncPushed, 3: db.SyncConflict, 4: db.SyncPushed}
+
+	stats, err := pusher.Push(PushOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if got := actions(stats); len(got) != len(want) || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] || got[4] != want[4] {
+		t.Errorf("dry run: got %v, want %v", got, want)
+	}
+	if log, _ := store.RaindropSyncLog(10); fake.puts != 0 || len(log) != 0 {
+		t.Errorf("dry run changed something: %d puts, %d log entries", fake.puts, len(log))
+	}
+
+	stats, err = pusher.Push(PushOptions{})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if stats.Pushed != 3 || stats.Conflicts != 1 || stats.Failed != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if fake.items[1].Note != "read twice" || !db.SameTags(fake.items[2].Tags, []string{"go", "cli"}) || fake.items[3].Note != "their note" {
+		t.Errorf("unexpected remote state: %+v %+v %+v", fake.items[1], fake.items[2], fake.items[3])
+	}
+	if fake.items[4].Note != "my take" || len(fake.items[4].Tags) != 2 {
+		t.Errorf("expected the note pushed and Raindrop's tags kept, got %+v", fake.items[4])
+	}
+	log, err := store.RaindropSyncLog(10)
+	if err != nil || len(log) != 4 {
+		t.Fatalf("expected 4 log entries, got %d (%v)", len(log), err)
+	}
+	for _, e := range log {
+		if e.RaindropID == 3 && (e.Action != db.SyncConflict || !strings.Contains(e.Detail, "note changed in Raindrop")) {
+			t.Errorf("unexpected conflict entry: %+v", e)
+		}
+	}
+
+	// Only the conflict is left, until forced
+	edits, _ := store.RaindropEdits(-1)
+	if len(edits) != 1 || edits[0].RaindropID != 3 {
+		t.Errorf("expected only the conflicting edit pending, got %+v", edits)
+	}
+	if stats, _ := pusher.Push(PushOptions{Force: true}); stats.Pushed != 1 || fake.items[3].Note != "my note" {
+		t.Errorf("forced push: %+v, remote %+v", stats, fake.items[3])
+	}
+	if edits, _ := store.RaindropEdits(-1); len(edits) != 0 {
+		t.Errorf("expected nothing pending, got %+v", edits)
+	}
+	puts := fake.puts
+	if stats, _ := pusher.Push(PushOptions{}); len(stats.Results) != 0 || fake.puts != puts {
+		t.Errorf("expected nothing to push, got %+v", stats)
+	}
+}
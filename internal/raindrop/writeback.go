@@ -0,0 +1,146 @@
+package raindrop
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/user/xhub/internal/db"
+)
+
+// PushOptions controls a write-back.
+type PushOptions struct {
+	DryRun bool // Check for conflicts but write nothing, to Raindrop or the sync log
+	Force  bool // Overwrite fields Raindrop changed since the last sync
+	Limit  int  // Bookmarks to push, 0 for all
+}
+
+// PushResult is what happened to one edited bookmark.
+type PushResult struct {
+	Edit   db.RaindropEdit
+	Action string // db.SyncPushed, db.SyncConflict or db.SyncFailed; "" if Raindrop already had the edit
+	Detail string
+}
+
+// PushStats is the outcome of a write-back.
+type PushStats struct {
+	Results   []PushResult
+	Pushed    int // Would have been pushed, in a dry run
+	Conflicts int
+	Failed    int
+}
+
+// Pusher writes notes and tags edited in xhub back to Raindrop.
+type Pusher struct {
+	client *Client
+	store  *db.Store
+}
+
+func NewPusher(store *db.Store, client *Client) *Pusher {
+	return &Pusher{client: client, store: store}
+}
+
+// Push writes each edited bookmark's notes and tags to its raindrop. A field
+// Raindrop changed since the last sync (its lastUpdate moved on and its value
+// is no longer the one both sides had) is a conflict: the bookmark is left
+// alone in both places until the conflict is resolved by editing either side,
+// or overwritten with opts.Force.
+func (p *Pusher) Push(opts PushOptions) (*PushStats, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	edits, err := p.store.RaindropEdits(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &PushStats{}
+	for _, edit := range edits {
+		res := p.push(edit, opts)
+		switch res.Action {
+		case db.SyncPushed:
+			stats.Pushed++
+		case db.SyncConflict:
+			stats.Conflicts++
+		case db.SyncFailed:
+			stats.Failed++
+		}
+		stats.Results = append(stats.Results, res)
+		if opts.DryRun || res.Action == "" {
+			continue
+		}
+		err := p.store.LogRaindropSync(db.RaindropSyncEntry{
+			BookmarkID: edit.BookmarkID,
+			RaindropID: edit.RaindropID,
+			Action:     res.Action,
+			Detail:     res.Detail,
+		})
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+func (p *Pusher) push(edit db.RaindropEdit, opts PushOptions) PushResult {
+	res := PushResult{Edit: edit}
+	fail := func(err error) PushResult {
+		res.Action, res.Detail = db.SyncFailed, err.Error()
+		return res
+	}
+
+	remote, err := p.client.Get(edit.RaindropID)
+	if err != nil {
+		return fail(err)
+	}
+	changed := edit.LastUpdate.IsZero() || remote.LastUpdate.After(edit.LastUpdate)
+
+	var update Update
+	var fields, conflicts []string
+	if edit.NoteEdited && remote.Note != edit.Note {
+		if changed && remote.Note != edit.SyncedNote {
+			conflicts = append(conflicts, "note")
+		}
+		update.Note = &edit.Note
+		fields = append(fields, "note")
+	}
+	if edit.TagsEdited && !db.SameTags(remote.Tags, edit.Tags) {
+		if changed && !db.SameTags(remote.Tags, edit.SyncedTags) {
+			conflicts = append(conflicts, "tags")
+		}
+		update.Tags = &edit.Tags
+		fields = append(fields, "tags")
+	}
+
+	if len(fields) == 0 {
+		// Raindrop already has the edits: both sides agree again
+		if !opts.DryRun {
+			if err := p.store.SetRaindropSynced(edit.BookmarkID, remote.Note, remote.Tags, remote.LastUpdate); err != nil {
+				return fail(err)
+			}
+		}
+		return res
+	}
+	if len(conflicts) > 0 && !opts.Force {
+		res.Action = db.SyncConflict
+		res.Detail = fmt.Sprintf("%s changed in Raindrop since the last sync (%s)", strings.Join(conflicts, " and "), remote.LastUpdate.Local().Format("2006-01-02 15:04"))
+		return res
+	}
+
+	res.Action = db.SyncPushed
+	res.Detail = strings.Join(fields, ", ")
+	if len(conflicts) > 0 {
+		res.Detail += " (overwrote changes in Raindrop)"
+	}
+	if opts.DryRun {
+		return res
+	}
+	updated, err := p.client.Put(edit.RaindropID, update)
+	if err != nil {
+		return fail(err)
+	}
+	if err := p.store.SetRaindropSynced(edit.BookmarkID, updated.Note, updated.Tags, updated.LastUpdate); err != nil {
+		return fail(err)
+	}
+	return res
+}
@@ -0,0 +1,129 @@
+// Package releases polls starred GitHub repositories for new releases and
+// publishes them as an Atom feed.
+package releases
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the GitHub REST API.
+const DefaultAPIURL = "https://api.github.com"
+
+// RateLimitError reports that the API allows no more requests until Reset.
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("GitHub API rate limit reached, resets at %s", e.Reset.Local().Format("15:04"))
+}
+
+// Client makes conditional requests to the GitHub API and tracks the rate limit.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+
+	Remaining int       // Requests left in the rate limit window, -1 if unknown
+	Reset     time.Time // When the window resets
+}
+
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		token:     token,
+		http:      &http.Client{Timeout: 30 * time.Second},
+		Remaining: -1,
+	}
+}
+
+// Token returns the GitHub token from GITHUB_TOKEN or GH_TOKEN, the configured
+// one, or the gh CLI's login, in that order. Without one the API allows 60
+// requests an hour.
+func Token(configured string) string {
+	for _, env := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token
+		}
+	}
+	if configured != "" {
+		return configured
+	}
+	if out, err := exec.Command("gh", "auth", "token").Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	return ""
+}
+
+// ghRelease is a release in the GitHub API.
+type ghRelease struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	HTMLURL     string `json:"html_url"`
+	Body        string `json:"body"`
+	Draft       bool   `json:"draft"`
+	Prerelease  bool   `json:"prerelease"`
+	PublishedAt string `json:"published_at"`
+}
+
+// ghTag is a tag in the GitHub API.
+type ghTag struct {
+	Name string `json:"name"`
+}
+
+// get fetches path into v unless it hasn't changed since etag. It returns the
+// response's ETag, and whether the API answered 304 Not Modified (which
+// doesn't count against the rate limit).
+func (c *Client) get(path, etag string, v interface{}) (string, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "xhub")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	c.trackRateLimit(resp.Header)
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return etag, true, nil
+	case (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) && c.Remaining == 0:
+		return "", false, &RateLimitError{Reset: c.Reset}
+	case resp.StatusCode >= 400:
+		return "", false, fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", false, fmt.Errorf("GET %s: %w", path, err)
+	}
+	return resp.Header.Get("ETag"), false, nil
+}
+
+func (c *Client) trackRateLimit(h http.Header) {
+	if remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		c.Remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		c.Reset = time.Unix(reset, 0)
+	}
+}
@@ -0,0 +1,94 @@
+package releases
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/user/xhub/internal/db"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string       `xml:"title"`
+	ID      string       `xml:"id"`
+	Link    atomLink     `xml:"link"`
+	Updated string       `xml:"updated"`
+	Content *atomContent `xml:"content,omitempty"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// WriteFeed writes releases, newest first, as an Atom feed.
+func WriteFeed(w io.Writer, releases []db.Release) error {
+	feed := atomFeed{
+		Title:   "Releases of starred repositories",
+		ID:      "urn:xhub:releases",
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: "xhub"},
+	}
+	if len(releases) > 0 {
+		feed.Updated = releases[0].PublishedAt.UTC().Format(time.RFC3339)
+	}
+	for _, r := range releases {
+		entry := atomEntry{
+			Title:   r.Repo + " " + r.Title(),
+			ID:      r.URL,
+			Link:    atomLink{Href: r.URL},
+			Updated: r.PublishedAt.UTC().Format(time.RFC3339),
+		}
+		if r.Body != "" {
+			entry.Content = &atomContent{Type: "text", Body: r.Body}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteFeedFile replaces the feed at path, so feed readers never see it half written.
+func WriteFeedFile(path string, releases []db.Release) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".releases-*.xml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := WriteFeed(tmp, releases); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,142 @@
+package releases
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/user/xhub/internal/db"
+)
+
+// perPage is how many of the latest releases or tags one poll looks at.
+const perPage = 10
+
+// PollOptions limits a poll.
+type PollOptions struct {
+	MaxRepos     int // Repositories to check, least recently checked first
+	MinRemaining int // Stop when fewer API requests than this are left
+}
+
+// PollStats is the outcome of a poll.
+type PollStats struct {
+	Checked     int
+	Unchanged   int          // Answered 304 Not Modified
+	New         []db.Release // Found in repositories checked before
+	Failed      int
+	RateLimited *RateLimitError // Set when the poll stopped early
+}
+
+// Poller checks starred repositories for releases, or for tags in
+// repositories that don't publish releases.
+type Poller struct {
+	client *Client
+	store  *db.Store
+}
+
+func NewPoller(store *db.Store, client *Client) *Poller {
+	return &Poller{client: client, store: store}
+}
+
+// Poll checks up to opts.MaxRepos repositories. Releases in a repository
+// checked for the first time are stored without counting as new, and its
+// existing tags get no date, so they stay out of the recent releases.
+func (p *Poller) Poll(opts PollOptions) (*PollStats, error) {
+	targets, err := p.store.ReleaseTargets(opts.MaxRepos)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &PollStats{}
+	for _, target := range targets {
+		if p.client.Remaining >= 0 && p.client.Remaining < opts.MinRemaining {
+			stats.RateLimited = &RateLimitError{Reset: p.client.Reset}
+			break
+		}
+		found, unchanged, err := p.check(&target)
+		if rl, ok := err.(*RateLimitError); ok {
+			stats.RateLimited = rl
+			break
+		}
+		if err != nil {
+			stats.Failed++
+			continue
+		}
+
+		added, err := p.store.AddReleases(found)
+		if err != nil {
+			return stats, err
+		}
+		if !target.CheckedAt.IsZero() {
+			stats.New = append(stats.New, added...)
+		}
+		target.CheckedAt = time.Now()
+		if err := p.store.SetReleaseCheck(target); err != nil {
+			return stats, err
+		}
+		stats.Checked++
+		if unchanged {
+			stats.Unchanged++
+		}
+	}
+	return stats, nil
+}
+
+// check fetches the releases of a repository, falling back to its tags when
+// it has none, and updates the ETags in c.
+func (p *Poller) check(c *db.ReleaseCheck) ([]db.Release, bool, error) {
+	now := time.Now()
+	var releases []ghRelease
+	etag, notModified, err := p.client.get(fmt.Sprintf("/repos/%s/releases?per_page=%d", c.Repo, perPage), c.ReleasesETag, &releases)
+	if err != nil {
+		return nil, false, err
+	}
+	// Unchanged releases and no tags to watch: the repository publishes releases
+	if notModified && c.TagsETag == "" {
+		return nil, true, nil
+	}
+
+	var found []db.Release
+	if !notModified {
+		c.ReleasesETag = etag
+		for _, r := range releases {
+			if r.Draft {
+				continue
+			}
+			release := db.Release{BookmarkID: c.BookmarkID, Repo: c.Repo, Tag: r.TagName, Name: r.Name, URL: r.HTMLURL, Body: r.Body, Prerelease: r.Prerelease}
+			if t, err := time.Parse(time.RFC3339, r.PublishedAt); err == nil {
+				release.PublishedAt = t
+			} else {
+				release.PublishedAt = now
+			}
+			found = append(found, release)
+		}
+		if len(releases) > 0 {
+			c.TagsETag = ""
+			return found, false, nil
+		}
+	}
+
+	var tags []ghTag
+	etag, tagsNotModified, err := p.client.get(fmt.Sprintf("/repos/%s/tags?per_page=%d", c.Repo, perPage), c.TagsETag, &tags)
+	if err != nil {
+		return nil, false, err
+	}
+	if tagsNotModified {
+		return found, notModified, nil
+	}
+	c.TagsETag = etag
+	for _, t := range tags {
+		release := db.Release{
+			BookmarkID: c.BookmarkID,
+			Repo:       c.Repo,
+			Tag:        t.Name,
+			URL:        fmt.Sprintf("https://github.com/%s/releases/tag/%s", c.Repo, url.PathEscape(t.Name)),
+		}
+		// Tags carry no date; one seen on a later check is new
+		if !c.CheckedAt.IsZero() {
+			release.PublishedAt = now
+		}
+		found = append(found, release)
+	}
+	return found, false, nil
+}
@@ -0,0 +1,142 @@
+package releases
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/user/xhub/internal/db"
+)
+
+// fakeGitHub serves releases and tags from memory, with ETags and a rate limit.
+type fakeGitHub struct {
+	mu        sync.Mutex
+	releases  map[string][]ghRelease
+	tags      map[string][]ghTag
+	remaining int
+	requests  int
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body interface{}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/repos/"), "/")
+	repo := parts[0] + "/" + parts[1]
+	switch parts[2] {
+	case "releases":
+		body = f.releases[repo]
+	case "tags":
+		body = f.tags[repo]
+	}
+	data, _ := json.Marshal(body)
+	etag := `"` + strconv.Itoa(len(data)) + `"`
+
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(f.remaining))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	f.requests++
+	f.remaining--
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(f.remaining))
+	w.Header().Set("ETag", etag)
+	w.Write(data)
+}
+
+func TestPoll(t *testing.T) {
+	fake := &fakeGitHub{
+		releases: map[string][]ghRelease{
+			"acme/app": {
+				{TagName: "v1.0.0", Name: "First", HTMLURL: "https://github.com/acme/app/releases/tag/v1.0.0", PublishedAt: "2025-01-10T00:00:00Z"},
+				{TagName: "v1.1.0-draft", Draft: true},
+			},
+		},
+		tags:      map[string][]ghTag{"acme/lib": {{Name: "v0.1"}, {Name: "v0.2"}}},
+		remaining: 100,
+	}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	tmpDir, _ := os.MkdirTemp("", "xhub-test")
+	defer os.RemoveAll(tmpDir)
+
+	store, err := db.NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	for _, repo := range []string{"acme/app", "acme/lib"} {
+		store.Upsert(&db.Bookmark{Source: "github", URL: "https://github.com/" + repo, Title: repo})
+	}
+
+	poller := NewPoller(store, NewClient(srv.URL, "secret"))
+	opts := PollOptions{MaxRepos: 10, MinRemaining: 5}
+
+	// First check: existing releases are stored but not new
+	stats, err := poller.Poll(opts)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if stats.Checked != 2 || len(stats.New) != 0 || stats.Failed != 0 {
+		t.Fatalf("unexpected first poll: %+v", stats)
+	}
+	recent, _ := store.RecentReleases(time.Time{}, 10)
+	if len(recent) != 1 || recent[0].Tag != "v1.0.0" {
+		t.Fatalf("expected only the dated release as recent, got %+v", recent)
+	}
+
+	// Nothing changed: every request is answered 304
+	before := fake.requests
+	stats, _ = poller.Poll(opts)
+	if stats.Unchanged != 2 || fake.requests != before {
+		t.Errorf("expected conditional requests only, got %+v and %d requests", stats, fake.requests-before)
+	}
+
+	fake.releases["acme/app"] = append([]ghRelease{{TagName: "v1.1.0", HTMLURL: "https://github.com/acme/app/releases/tag/v1.1.0", Body: "Fixes", PublishedAt: time.Now().UTC().Format(time.RFC3339)}}, fake.releases["acme/app"]...)
+	fake.tags["acme/lib"] = append([]ghTag{{Name: "v0.3"}}, fake.tags["acme/lib"]...)
+	stats, _ = poller.Poll(opts)
+	got := map[string]bool{}
+	for _, r := range stats.New {
+		got[r.Repo+" "+r.Tag] = true
+	}
+	if len(stats.New) != 2 || !got["acme/app v1.1.0"] || !got["acme/lib v0.3"] {
+		t.Errorf("expected the new release and tag, got %+v", stats.New)
+	}
+
+	// Polling stops before the rate limit runs out
+	fake.remaining = 5
+	fake.releases["acme/app"] = fake.releases["acme/app"][:1]
+	fake.releases["acme/lib"] = []ghRelease{{TagName: "v1.0", HTMLURL: "https://github.com/acme/lib/releases/tag/v1.0"}}
+	stats, _ = poller.Poll(opts)
+	if stats.RateLimited == nil || stats.Checked != 1 {
+		t.Errorf("expected to stop after one repository, got %+v", stats)
+	}
+
+	recent, _ = store.RecentReleases(time.Time{}, 10)
+	var feed bytes.Buffer
+	if err := WriteFeed(&feed, recent); err != nil {
+		t.Fatalf("WriteFeed failed: %v", err)
+	}
+	var parsed atomFeed
+	if err := xml.Unmarshal(feed.Bytes(), &parsed); err != nil {
+		t.Fatalf("feed isn't valid XML: %v\n%s", err, feed.String())
+	}
+	if len(parsed.Entries) != len(recent) || !strings.Contains(feed.String(), `<content type="text">Fixes</content>`) || !strings.HasPrefix(parsed.Entries[0].Title, "acme/") {
+		t.Errorf("unexpected feed:\n%s", feed.String())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/user/xhub/internal/db"
@@ -31,13 +32,26 @@ func (g *GitHubSource) Available() bool {
 
 type ghStar struct {
 	StarredAt string `json:"starred_at"`
-	Repo      struct {
-		FullName    string `json:"full_name"`
-		HTMLURL     string `json:"html_url"`
-		Description string `json:"description"`
-	} `json:"repo"`
+	Repo      ghRepo `json:"repo"`
 }
 
+type ghRepo struct {
+	FullName        string   `json:"full_name"`
+	HTMLURL         string   `json:"html_url"`
+	Description     string   `json:"description"`
+	Language        string   `json:"language"`
+	Topics          []string `json:"topics"`
+	StargazersCount int      `json:"stargazers_count"`
+	License         *struct {
+		SPDXID string `json:"spdx_id"`
+	} `json:"license"`
+	Archived bool   `json:"archived"`
+	PushedAt string `json:"pushed_at"`
+}
+
+// readmeExcerptLen caps the README text kept per repository.
+const readmeExcerptLen = 1000
+
 func (g *GitHubSource) Fetch(incremental bool) ([]db.Bookmark, error) {
 	// Get last sync timestamp for incremental fetch
 	var lastSyncTime time.Time
@@ -139,12 +153,79 @@ func (g *GitHubSource) Fetch(incremental bool) ([]db.Bookmark, error) {
 			Summary:      star.Repo.Description,
 			CreatedAt:    createdAt,
 			ScrapeStatus: "pending",
+			Repo:         star.Repo.metadata(),
 		})
 	}
 
 	return bookmarks, nil
 }
 
+func (r ghRepo) metadata() *db.RepoMetadata {
+	meta := &db.RepoMetadata{
+		FullName: r.FullName,
+		Language: r.Language,
+		Topics:   r.Topics,
+		Stars:    r.StargazersCount,
+		Archived: r.Archived,
+	}
+	if r.License != nil && r.License.SPDXID != "NOASSERTION" {
+		meta.License = r.License.SPDXID
+	}
+	if t, err := time.Parse(time.RFC3339, r.PushedAt); err == nil {
+		meta.PushedAt = t
+	}
+	return meta
+}
+
+// FetchRepo fetches a repository's current metadata and README excerpt with gh.
+func FetchRepo(fullName string) (*db.RepoMetadata, error) {
+	output, err := exec.Command("gh", "api", "repos/"+fullName).Output()
+	if err != nil {
+		return nil, fmt.Errorf("gh api repos/%s failed: %w", fullName, err)
+	}
+	var repo ghRepo
+	if err := json.Unmarshal(output, &repo); err != nil {
+		return nil, fmt.Errorf("failed to parse repository %s: %w", fullName, err)
+	}
+	meta := repo.metadata()
+
+	// Not every repository has a README
+	readme, err := exec.Command("gh", "api", "repos/"+fullName+"/readme", "-H", "Accept: application/vnd.github.raw").Output()
+	if err == nil {
+		meta.ReadmeExcerpt = readmeExcerpt(string(readme))
+	}
+	meta.CheckedAt = time.Now()
+	return meta, nil
+}
+
+// readmeExcerpt returns the start of a README's prose, leaving out badges,
+// images, HTML and code blocks.
+func readmeExcerpt(readme string) string {
+	var lines []string
+	inCode := false
+	for _, line := range strings.Split(readme, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || line == "" || strings.HasPrefix(line, "<") || strings.HasPrefix(line, "![") || strings.HasPrefix(line, "[![") {
+			continue
+		}
+		lines = append(lines, strings.TrimLeft(line, "# "))
+	}
+	text := strings.Join(lines, "\n")
+	runes := []rune(text)
+	if len(runes) <= readmeExcerptLen {
+		return text
+	}
+	cut := string(runes[:readmeExcerptLen])
+	if i := strings.LastIndexAny(cut, " \n"); i > readmeExcerptLen/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
 // parseMultipleArrays handles gh paginate output which can be concatenated arrays
 func parseMultipleArrays(data []byte) ([]ghStar, error) {
 	var result []ghStar
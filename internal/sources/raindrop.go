@@ -29,15 +29,135 @@ func (r *RaindropSource) Available() bool {
 }
 
 type raindropItem struct {
-	ID      int    `json:"_id"`
-	Title   string `json:"title"`
-	Link    string `json:"link"`
-	Excerpt string `json:"excerpt"`
+	ID         int      `json:"_id"`
+	Title      string   `json:"title"`
+	Link       string   `json:"link"`
+	Excerpt    string   `json:"excerpt"`
+	Note       string   `json:"note"`
+	Created    string   `json:"created"`
+	Tags       []string `json:"tags"`
+	Collection struct {
+		ID int64 `json:"$id"`
+	} `json:"collection"`
+	CollectionID int64               `json:"collectionId"`
+	Highlights   []raindropHighlight `json:"highlights"`
+	Cover        string              `json:"cover"`
+	Important    bool                `json:"important"`
+	LastUpdate   string              `json:"lastUpdate"`
+}
+
+type raindropHighlight struct {
+	ID      string `json:"_id"`
+	Text    string `json:"text"`
 	Note    string `json:"note"`
+	Color   string `json:"color"`
 	Created string `json:"created"`
-	Tags    []string `json:"tags"`
 }
 
+type raindropCollection struct {
+	ID    int64  `json:"_id"`
+	Title string `json:"title"`
+}
+
+// updatedAt is when the raindrop last changed, or when it was created for
+// items without a lastUpdate.
+func (item raindropItem) updatedAt() time.Time {
+	for _, ts := range []string{item.LastUpdate, item.Created} {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// metadata returns what xhub keeps of the item beyond the bookmark fields.
+func (item raindropItem) metadata(collections map[int64]string) *db.RaindropMetadata {
+	collectionID := item.Collection.ID
+	if collectionID == 0 {
+		collectionID = item.CollectionID
+	}
+	m := &db.RaindropMetadata{
+		RaindropID:     int64(item.ID),
+		CollectionID:   collectionID,
+		CollectionName: collections[collectionID],
+		Cover:          item.Cover,
+		Important:      item.Important,
+		Note:           item.Note,
+		Tags:           item.Tags,
+	}
+	m.LastUpdate, _ = time.Parse(time.RFC3339, item.LastUpdate)
+	for _, h := range item.Highlights {
+		created, _ := time.Parse(time.RFC3339, h.Created)
+		m.Highlights = append(m.Highlights, db.Annotation{
+			Source:     "raindrop",
+			ExternalID: h.ID,
+			Text:       h.Text,
+			Note:       h.Note,
+			Color:      h.Color,
+			CreatedAt:  created,
+		})
+	}
+	return m
+}
+
+// bookmark returns the item as a bookmark to store.
+func (item raindropItem) bookmark(collections map[int64]string) db.Bookmark {
+	createdAt := time.Now()
+	if item.Created != "" {
+		if t, err := time.Parse(time.RFC3339, item.Created); err == nil {
+			createdAt = t
+		}
+	}
+
+	keywords := ""
+	for i, tag := range item.Tags {
+		if i > 0 {
+			keywords += ","
+		}
+		keywords += tag
+	}
+
+	return db.Bookmark{
+		Source:       "raindrop",
+		URL:          item.Link,
+		Title:        item.Title,
+		Summary:      item.Excerpt,
+		Keywords:     keywords,
+		Notes:        item.Note,
+		CreatedAt:    createdAt,
+		ScrapeStatus: "pending",
+		Raindrop:     item.metadata(collections),
+	}
+}
+
+// fetchCollections returns collection names by ID. The names are a nicety:
+// without them collections are imported as "Collection <id>".
+func fetchCollections() map[int64]string {
+	names := make(map[int64]string)
+	output, err := exec.Command("raindrop", "collections", "--json").Output()
+	if err != nil {
+		return names
+	}
+	var collections []raindropCollection
+	if err := json.Unmarshal(output, &collections); err != nil {
+		var resp struct {
+			Items []raindropCollection `json:"items"`
+		}
+		if err := json.Unmarshal(output, &resp); err != nil {
+			return names
+		}
+		collections = resp.Items
+	}
+	for _, c := range collections {
+		names[c.ID] = c.Title
+	}
+	return names
+}
+
+// Fetch returns raindrops updated since the last sync, newest first, or every
+// raindrop when not incremental. In an incremental sync, raindrops already
+// stored keep their content and summary: their title, tags and note are
+// refreshed unless edited in xhub, along with their metadata.
 func (r *RaindropSource) Fetch(incremental bool) ([]db.Bookmark, error) {
 	// Get last sync timestamp for incremental fetch
 	var lastSyncTime time.Time
@@ -54,8 +174,8 @@ func (r *RaindropSource) Fetch(incremental bool) ([]db.Bookmark, error) {
 	reachedOld := false
 
 	for {
-		// Raindrop CLI sorts by -created (newest first) by default
-		cmd := exec.Command("raindrop", "list", "--json", "--limit", "50", "--page", itoa(page))
+		// Most recently changed first, so older raindrops edited since the last sync are seen too
+		cmd := exec.Command("raindrop", "list", "--json", "--sort", "-lastUpdate", "--limit", "50", "--page", itoa(page))
 		output, err := cmd.Output()
 		if err != nil {
 			if page == 0 {
@@ -83,21 +203,15 @@ func (r *RaindropSource) Fetch(incremental bool) ([]db.Bookmark, error) {
 		}
 
 		for _, item := range items {
-			itemTime := time.Now()
-			if item.Created != "" {
-				if t, err := time.Parse(time.RFC3339, item.Created); err == nil {
-					itemTime = t
-				}
-			}
 			// Truncate to seconds for consistent comparison (RFC3339 loses sub-second precision)
-			itemTimeSec := itemTime.Truncate(time.Second)
+			itemTimeSec := item.updatedAt().Truncate(time.Second)
 
 			// Track newest item for next sync
 			if newestTime.IsZero() || itemTimeSec.After(newestTime) {
 				newestTime = itemTimeSec
 			}
 
-			// Stop if we've reached items from before last sync
+			// Stop if we've reached items unchanged since last sync
 			if !lastSyncTime.IsZero() && !itemTimeSec.After(lastSyncTime) {
 				reachedOld = true
 				break
@@ -116,38 +230,33 @@ func (r *RaindropSource) Fetch(incremental bool) ([]db.Bookmark, error) {
 		page++
 	}
 
-	// Update last sync timestamp
-	if r.store != nil && !newestTime.IsZero() {
-		r.store.SetMetadata(raindropLastSyncKey, newestTime.Format(time.RFC3339))
+	var collections map[int64]string
+	if len(allItems) > 0 {
+		collections = fetchCollections()
 	}
 
 	bookmarks := make([]db.Bookmark, 0, len(allItems))
 	for _, item := range allItems {
-		createdAt := time.Now()
-		if item.Created != "" {
-			if t, err := time.Parse(time.RFC3339, item.Created); err == nil {
-				createdAt = t
-			}
-		}
-
-		keywords := ""
-		for i, tag := range item.Tags {
-			if i > 0 {
-				keywords += ","
+		b := item.bookmark(collections)
+		if incremental && r.store != nil {
+			if id, err := r.store.RaindropBookmarkID(int64(item.ID)); err != nil {
+				return nil, err
+			} else if id != "" {
+				if err := r.store.RefreshFromSource(id, &b); err != nil {
+					return nil, err
+				}
+				if err := r.store.SetRaindropMetadata(id, b.Raindrop); err != nil {
+					return nil, err
+				}
+				continue
 			}
-			keywords += tag
 		}
+		bookmarks = append(bookmarks, b)
+	}
 
-		bookmarks = append(bookmarks, db.Bookmark{
-			Source:       "raindrop",
-			URL:          item.Link,
-			Title:        item.Title,
-			Summary:      item.Excerpt,
-			Keywords:     keywords,
-			Notes:        item.Note,
-			CreatedAt:    createdAt,
-			ScrapeStatus: "pending",
-		})
+	// Update last sync timestamp once the changes are stored
+	if r.store != nil && !newestTime.IsZero() {
+		r.store.SetMetadata(raindropLastSyncKey, newestTime.Format(time.RFC3339))
 	}
 
 	return bookmarks, nil
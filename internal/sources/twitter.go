@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/user/xhub/internal/db"
@@ -12,6 +15,12 @@ import (
 
 const xLastSyncKey = "x_last_sync_ts"
 
+// linkPattern finds URLs in tweet text, which X rewrites to t.co links.
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// threadMarker spots the first tweet of a thread, which isn't a reply.
+var threadMarker = regexp.MustCompile(`🧵|(^|\s)1/\d*(\s|$)|\(1/\d+\)`)
+
 type TwitterSource struct {
 	store *db.Store
 }
@@ -38,6 +47,19 @@ type birdBookmark struct {
 		Username string `json:"username"`
 		Name     string `json:"name"`
 	} `json:"author"`
+	ConversationID    string        `json:"conversationId"`
+	InReplyToStatusID string        `json:"inReplyToStatusId"`
+	QuotedTweet       *birdBookmark `json:"quotedTweet"`
+	Media             []struct {
+		Type    string `json:"type"`
+		URL     string `json:"url"`
+		AltText string `json:"altText"`
+	} `json:"media"`
+	Card *struct {
+		URL         string `json:"url"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	} `json:"card"`
 }
 
 // birdResponse handles paginated response: { tweets: [...], nextCursor: "..." }
@@ -70,36 +92,11 @@ func (t *TwitterSource) Fetch(incremental bool) ([]db.Bookmark, error) {
 			cmdStr += fmt.Sprintf(" --cursor %q", cursor)
 		}
 
-		// Use temp file to avoid output truncation
-		tmpFile, err := os.CreateTemp("", "bird-*.json")
+		resp, err := runBird(cmdStr)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create temp file: %w", err)
-		}
-		tmpPath := tmpFile.Name()
-		tmpFile.Close()
-
-		cmd := exec.Command("sh", "-c", fmt.Sprintf("%s > %s", cmdStr, tmpPath))
-		if err := cmd.Run(); err != nil {
-			os.Remove(tmpPath)
 			return nil, fmt.Errorf("bird bookmarks failed: %w", err)
 		}
 
-		output, err := os.ReadFile(tmpPath)
-		os.Remove(tmpPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read bird output: %w", err)
-		}
-
-		var resp birdResponse
-		if err := json.Unmarshal(output, &resp); err != nil {
-			// Try parsing as direct array (fallback for older versions)
-			var tweets []birdBookmark
-			if arrErr := json.Unmarshal(output, &tweets); arrErr != nil {
-				return nil, fmt.Errorf("failed to parse bird output: %w", err)
-			}
-			resp.Tweets = tweets
-		}
-
 		if len(resp.Tweets) == 0 {
 			break
 		}
@@ -156,22 +153,136 @@ func (t *TwitterSource) Fetch(incremental bool) ([]db.Bookmark, error) {
 			}
 		}
 
-		title := tweet.Text
-		if len(title) > 100 {
-			title = title[:100] + "..."
-		}
-
 		url := fmt.Sprintf("https://x.com/%s/status/%s", tweet.Author.Username, tweet.ID)
+		meta := tweetMetadata(tweet)
+		if tweet.InReplyToStatusID != "" || threadMarker.MatchString(tweet.Text) {
+			meta.Thread = t.thread(tweet, url, incremental)
+		}
 
 		bookmarks = append(bookmarks, db.Bookmark{
 			Source:       "x",
 			URL:          url,
-			Title:        title,
+			Title:        meta.Title(),
 			RawContent:   "",
 			CreatedAt:    createdAt,
 			ScrapeStatus: "pending",
+			Links:        tweetLinks(tweet.Text),
+			Tweet:        meta,
 		})
 	}
 
 	return bookmarks, nil
 }
+
+// runBird runs a bird command with --json output and parses the tweets it prints.
+func runBird(cmdStr string) (*birdResponse, error) {
+	// Use temp file to avoid output truncation
+	tmpFile, err := os.CreateTemp("", "bird-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	cmd := exec.Command("sh", "-c", fmt.Sprintf("%s > %s", cmdStr, tmpPath))
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	output, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bird output: %w", err)
+	}
+
+	var resp birdResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		// Try parsing as direct array (fallback for older versions)
+		var tweets []birdBookmark
+		if arrErr := json.Unmarshal(output, &tweets); arrErr != nil {
+			return nil, fmt.Errorf("failed to parse bird output: %w", err)
+		}
+		resp.Tweets = tweets
+	}
+	return &resp, nil
+}
+
+// thread returns the thread stored with the tweet, so bird isn't run again
+// for tweets fetched before, or fetches it. Stored tweets without a thread are
+// only looked up again in a full fetch.
+func (t *TwitterSource) thread(tweet birdBookmark, url string, incremental bool) []db.ThreadTweet {
+	if t.store != nil {
+		if b, err := t.store.GetByURL(url); err == nil {
+			if stored, _ := t.store.GetTweetMetadata(b.ID); stored != nil && stored.TweetID == tweet.ID {
+				if len(stored.Thread) > 0 || incremental {
+					return stored.Thread
+				}
+			}
+		}
+	}
+	return fetchThread(tweet)
+}
+
+// fetchThread returns the tweets the author posted in the tweet's conversation,
+// oldest first. Replies by others are left out. The thread is best effort: nil
+// if bird can't fetch it.
+func fetchThread(tweet birdBookmark) []db.ThreadTweet {
+	resp, err := runBird(fmt.Sprintf("bird thread %q --json", tweet.ID))
+	if err != nil {
+		return nil
+	}
+	var thread []db.ThreadTweet
+	for _, t := range resp.Tweets {
+		if strings.EqualFold(t.Author.Username, tweet.Author.Username) {
+			thread = append(thread, db.ThreadTweet{ID: t.ID, Text: t.Text})
+		}
+	}
+	// Tweet IDs grow over time
+	sort.Slice(thread, func(i, j int) bool {
+		a, b := thread[i].ID, thread[j].ID
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	if len(thread) < 2 {
+		return nil
+	}
+	return thread
+}
+
+func tweetMetadata(tweet birdBookmark) *db.TweetMetadata {
+	meta := &db.TweetMetadata{
+		TweetID:        tweet.ID,
+		AuthorHandle:   tweet.Author.Username,
+		AuthorName:     tweet.Author.Name,
+		Text:           tweet.Text,
+		ConversationID: tweet.ConversationID,
+		InReplyTo:      tweet.InReplyToStatusID,
+	}
+	if q := tweet.QuotedTweet; q != nil {
+		meta.Quoted = &db.QuotedTweet{ID: q.ID, AuthorHandle: q.Author.Username, Text: q.Text}
+	}
+	for _, m := range tweet.Media {
+		meta.Media = append(meta.Media, db.TweetMedia{Type: m.Type, URL: m.URL, AltText: m.AltText})
+	}
+	if c := tweet.Card; c != nil && c.URL != "" {
+		meta.Cards = append(meta.Cards, db.LinkCard{URL: c.URL, Title: c.Title, Description: c.Description})
+	}
+	return meta
+}
+
+// tweetLinks returns the distinct URLs in tweet text, without the punctuation
+// that often follows them.
+func tweetLinks(text string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, link := range linkPattern.FindAllString(text, -1) {
+		link = strings.TrimRight(link, ".,;:!?)]}…")
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
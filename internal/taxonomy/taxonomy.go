@@ -0,0 +1,219 @@
+package taxonomy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tag is a canonical tag and the spellings that map to it.
+type Tag struct {
+	Name        string   `yaml:"name"`
+	Aliases     []string `yaml:"aliases,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+}
+
+// Taxonomy is the controlled vocabulary bookmarks are tagged against.
+type Taxonomy struct {
+	Tags  []Tag             `yaml:"tags"`
+	index map[string]string // Key(name or alias) -> canonical name
+}
+
+// Key folds a tag spelling for comparison: "Go-Lang", "go_lang" and "golang" share a key.
+func Key(tag string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(tag)) {
+		switch r {
+		case ' ', '-', '_', '.', '/':
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// New builds a taxonomy, rejecting spellings claimed by two different tags.
+func New(tags []Tag) (*Taxonomy, error) {
+	t := &Taxonomy{Tags: tags, index: make(map[string]string)}
+	for _, tag := range tags {
+		if strings.TrimSpace(tag.Name) == "" {
+			return nil, errors.New("taxonomy: tag with empty name")
+		}
+		for _, spelling := range append([]string{tag.Name}, tag.Aliases...) {
+			k := Key(spelling)
+			if existing, ok := t.index[k]; ok && existing != tag.Name {
+				return nil, fmt.Errorf("taxonomy: %q is used by both %q and %q", spelling, existing, tag.Name)
+			}
+			t.index[k] = tag.Name
+		}
+	}
+	return t, nil
+}
+
+// Load reads a taxonomy file. A missing file returns nil, nil: tagging is unconstrained.
+func Load(path string) (*Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file Taxonomy
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("taxonomy %s: %w", path, err)
+	}
+	return New(file.Tags)
+}
+
+// Save writes the taxonomy back to path, sorted by tag name.
+func (t *Taxonomy) Save(path string) error {
+	sort.Slice(t.Tags, func(i, j int) bool { return t.Tags[i].Name < t.Tags[j].Name })
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Canonical returns the canonical name for a tag spelling.
+func (t *Taxonomy) Canonical(tag string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	name, ok := t.index[Key(tag)]
+	return name, ok
+}
+
+// Names returns the canonical tag names in file order.
+func (t *Taxonomy) Names() []string {
+	if t == nil {
+		return nil
+	}
+	names := make([]string, len(t.Tags))
+	for i, tag := range t.Tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+// Add adds a new canonical tag, or an alias when aliasOf names an existing tag.
+func (t *Taxonomy) Add(tag, aliasOf string) error {
+	if name, ok := t.Canonical(tag); ok {
+		return fmt.Errorf("%q is already in the taxonomy as %q", tag, name)
+	}
+	if aliasOf == "" {
+		t.Tags = append(t.Tags, Tag{Name: tag})
+		t.index[Key(tag)] = tag
+		return nil
+	}
+	name, ok := t.Canonical(aliasOf)
+	if !ok {
+		return fmt.Errorf("unknown tag %q", aliasOf)
+	}
+	for i := range t.Tags {
+		if t.Tags[i].Name == name {
+			t.Tags[i].Aliases = append(t.Tags[i].Aliases, tag)
+		}
+	}
+	t.index[Key(tag)] = name
+	return nil
+}
+
+// Split parses a comma-separated keywords string, dropping blanks and duplicate spellings.
+func Split(keywords string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, kw := range strings.Split(keywords, ",") {
+		kw = strings.TrimSpace(kw)
+		if kw == "" || seen[Key(kw)] {
+			continue
+		}
+		seen[Key(kw)] = true
+		tags = append(tags, kw)
+	}
+	return tags
+}
+
+// Normalize maps keywords onto canonical tags. Keywords outside the vocabulary
+// are returned separately, lowercased, so they can be proposed for review.
+func (t *Taxonomy) Normalize(keywords string) (tags, unknown []string) {
+	seen := make(map[string]bool)
+	for _, kw := range Split(keywords) {
+		name, ok := t.Canonical(kw)
+		if !ok {
+			unknown = append(unknown, strings.ToLower(kw))
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	return tags, unknown
+}
+
+// Merge suggests folding From into To.
+type Merge struct {
+	From       string
+	To         string
+	Similarity float64
+}
+
+// SuggestMerges pairs tags whose name embeddings are at least threshold similar.
+// Each pair merges into the tag in the taxonomy, or else the one used more often.
+func (t *Taxonomy) SuggestMerges(names []string, vectors [][]float32, counts map[string]int, threshold float64) []Merge {
+	var merges []Merge
+	for i := 0; i < len(names); i++ {
+		for j := i + 1; j < len(names); j++ {
+			sim := 1.0
+			if Key(names[i]) != Key(names[j]) {
+				sim = cosine(vectors[i], vectors[j])
+			}
+			if sim < threshold {
+				continue
+			}
+			from, to := names[i], names[j]
+			if t.preferred(from, to, counts) {
+				from, to = to, from
+			}
+			merges = append(merges, Merge{From: from, To: to, Similarity: sim})
+		}
+	}
+	sort.Slice(merges, func(i, j int) bool { return merges[i].Similarity > merges[j].Similarity })
+	return merges
+}
+
+// preferred reports whether a should be kept over b.
+func (t *Taxonomy) preferred(a, b string, counts map[string]int) bool {
+	_, aKnown := t.Canonical(a)
+	_, bKnown := t.Canonical(b)
+	if aKnown != bKnown {
+		return aKnown
+	}
+	if counts[a] != counts[b] {
+		return counts[a] > counts[b]
+	}
+	return a < b
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
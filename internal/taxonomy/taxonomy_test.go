@@ -0,0 +1,87 @@
+package taxonomy
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testTaxonomy(t *testing.T) *Taxonomy {
+	t.Helper()
+	tax, err := New([]Tag{
+		{Name: "go", Aliases: []string{"golang"}},
+		{Name: "machine-learning", Aliases: []string{"ml"}},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return tax
+}
+
+func TestNormalize(t *testing.T) {
+	tax := testTaxonomy(t)
+
+	tags, unknown := tax.Normalize("Go, go-lang, Golang, ML, Machine Learning, WebGPU, ")
+	if want := []string{"go", "machine-learning"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+	if want := []string{"webgpu"}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("unknown = %v, want %v", unknown, want)
+	}
+}
+
+func TestNewRejectsConflictingAliases(t *testing.T) {
+	_, err := New([]Tag{{Name: "go", Aliases: []string{"golang"}}, {Name: "golang"}})
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+}
+
+func TestAddSaveLoad(t *testing.T) {
+	tax := testTaxonomy(t)
+	if err := tax.Add("webgpu", ""); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := tax.Add("gopher", "golang"); err != nil {
+		t.Fatalf("Add alias failed: %v", err)
+	}
+	if err := tax.Add("GoLang", ""); err == nil {
+		t.Error("expected error adding an existing spelling")
+	}
+
+	path := filepath.Join(t.TempDir(), "taxonomy.yaml")
+	if err := tax.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if name, ok := loaded.Canonical("Gopher"); !ok || name != "go" {
+		t.Errorf("Canonical(Gopher) = %q, %v", name, ok)
+	}
+	if _, ok := loaded.Canonical("webgpu"); !ok {
+		t.Error("expected webgpu in saved taxonomy")
+	}
+
+	missing, err := Load(filepath.Join(t.TempDir(), "none.yaml"))
+	if missing != nil || err != nil {
+		t.Errorf("expected nil taxonomy for missing file, got %v, %v", missing, err)
+	}
+}
+
+func TestSuggestMerges(t *testing.T) {
+	tax := testTaxonomy(t)
+	names := []string{"go", "golang-dev", "rust"}
+	vectors := [][]float32{{1, 0}, {0.95, 0.1}, {0, 1}}
+	counts := map[string]int{"go": 2, "golang-dev": 10, "rust": 4}
+
+	merges := tax.SuggestMerges(names, vectors, counts, 0.9)
+	if len(merges) != 1 {
+		t.Fatalf("expected 1 merge, got %+v", merges)
+	}
+	// The taxonomy tag wins even though the other is used more
+	if merges[0].From != "golang-dev" || merges[0].To != "go" {
+		t.Errorf("unexpected merge direction: %+v", merges[0])
+	}
+}
@@ -1,9 +1,11 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,21 +23,32 @@ import (
 type model struct {
 	cfg          *config.Config
 	store        *db.Store
+	reranker     *indexer.Reranker // nil unless search.rerank is configured
 	searchInput  textinput.Model
 	list         list.Model
-	allBookmarks []db.Bookmark   // Unfiltered search results
-	sources      map[string]bool // Source filter toggles
+	allBookmarks []db.Bookmark                // Unfiltered search results
+	highlights   map[string]map[string]string // Bookmark ID -> field -> marked matches from the last search
+	scores       map[string]float64           // Bookmark ID -> fused score from the last search
+	explanations map[string]*db.Explanation   // Bookmark ID -> score breakdown from the last search
+	linkedFrom   map[string][]db.LinkedFrom   // Bookmark ID -> tweets linking to it
+	sources      map[string]bool              // Source filter toggles
 	width        int
 	height       int
 	searching    bool
+	queryErr     string // Malformed search filter, shown under the search box
+	searchNote   string // Non-fatal search problem, e.g. reranking over budget
+	interpreted  string // Natural-language query the search box was rewritten from
 	err          error
 
 	// Edit modal state
-	editing       bool
-	editBookmark  *db.Bookmark
-	editInputs    []textinput.Model // 0=title, 2=keywords
-	editTextareas []textarea.Model  // 1=summary, 3=notes
-	editFocusIdx  int
+	editing         bool
+	editBookmark    *db.Bookmark
+	editRepo        *db.RepoMetadata     // GitHub metadata of editBookmark, if any
+	editRaindrop    *db.RaindropMetadata // Raindrop metadata of editBookmark, if any
+	editAnnotations []db.Annotation      // Highlights in editBookmark's page
+	editInputs      []textinput.Model    // 0=title, 2=keywords
+	editTextareas   []textarea.Model     // 1=summary, 3=notes
+	editFocusIdx    int
 
 	// Delete confirmation state
 	deleting       bool
@@ -44,16 +57,78 @@ type model struct {
 	// Reprocess state
 	reprocessing   bool
 	reprocessingID string
+
+	// Ranking debug overlay
+	explaining bool
+
+	// Related view: results for "more like this", a topic's members or the
+	// bookmarks cited in chat, and the list to return to
+	relatedTo     *db.Bookmark
+	viewHeader    string // Header for views other than "more like this"
+	beforeRelated *resultSet
+
+	// Topics overlay
+	browsingTopics bool
+	topics         []db.Collection
+	topicSel       int
+
+	// Recent releases overlay
+	browsingReleases bool
+	releases         []db.Release
+	releaseSel       int
+
+	// Chat pane: a conversation answered from the bookmarks
+	chatting     bool
+	chatInput    textinput.Model
+	chatTurns    []*indexer.Answer
+	chatSel      int // Selected citation across the conversation, -1 for none
+	chatPending  bool
+	chatQuestion string // Question being answered
+	chatStreamed string // Answer so far
+	chatNote     string
+	chatCh       chan tea.Msg
+}
+
+// resultSet is what the list shows, saved while viewing related bookmarks.
+type resultSet struct {
+	bookmarks    []db.Bookmark
+	highlights   map[string]map[string]string
+	scores       map[string]float64
+	explanations map[string]*db.Explanation
+	linkedFrom   map[string][]db.LinkedFrom
 }
 
 type bookmarkItem struct {
 	bookmark     db.Bookmark
+	highlights   map[string]string // Field -> text with db.HighlightOpen/Close markers
+	linkedFrom   []db.LinkedFrom
 	reprocessing bool
 }
 
+var highlightStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+
+var viaStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+// highlight renders a marked field on one line with matches emphasized.
+func highlight(marked string) string {
+	return db.RenderHighlights(sanitizeLine(marked), func(s string) string { return highlightStyle.Render(s) })
+}
+
 func (b bookmarkItem) Title() string {
 	icon := sourceIcon(b.bookmark.Source)
 	title := sanitizeLine(b.bookmark.Title)
+	if h, ok := b.highlights[db.FieldTitle]; ok {
+		title = highlight(h)
+	}
+	if b.bookmark.HasUserEdits() {
+		title += " ✎"
+	}
+	if b.bookmark.Starred {
+		title += highlightStyle.Render(" ★")
+	}
+	if len(b.linkedFrom) > 0 {
+		title += viaStyle.Render(" via " + b.linkedFrom[0].Author())
+	}
 	return fmt.Sprintf("%s %s", icon, title)
 }
 
@@ -72,6 +147,25 @@ func (b bookmarkItem) Description() string {
 	if b.reprocessing {
 		return "Reprocessing..."
 	}
+	if b.bookmark.ScrapeStatus == "rejected" && b.bookmark.Summary == "" {
+		return "Rejected: " + b.bookmark.StatusReason
+	}
+	// Show why a search result matched
+	if h, ok := b.highlights[db.FieldSummary]; ok {
+		return highlight(h)
+	}
+	if h, ok := b.highlights[db.FieldNotes]; ok {
+		return "Notes: " + highlight(h)
+	}
+	if h, ok := b.highlights[db.FieldKeywords]; ok {
+		return "Tags: " + highlight(h)
+	}
+	if h, ok := b.highlights[db.FieldAnnotations]; ok {
+		return "Highlight: " + highlight(h)
+	}
+	if h, ok := b.highlights[db.HighlightContent]; ok {
+		return "Content: " + highlight(h)
+	}
 	if b.bookmark.Summary != "" {
 		summary := sanitizeLine(b.bookmark.Summary)
 		if len(summary) > 80 {
@@ -129,18 +223,34 @@ func initialModel(cfg *config.Config) model {
 			"manual":   true,
 		},
 		searching: false, // Start with list focused
+		chatInput: newChatInput(),
+		chatSel:   -1,
 	}
 }
 
 type initMsg struct {
-	store     *db.Store
-	bookmarks []db.Bookmark
-	err       error
+	store      *db.Store
+	bookmarks  []db.Bookmark
+	linkedFrom map[string][]db.LinkedFrom
+	err        error
 }
 
 type searchMsg struct {
-	bookmarks []db.Bookmark
-	err       error
+	results []db.SearchResult
+	note    string // Shown dimmed under the search box
+	err     error
+}
+
+type relatedMsg struct {
+	bookmark db.Bookmark
+	results  []db.SearchResult
+	err      error
+}
+
+type interpretMsg struct {
+	original string
+	query    string
+	err      error
 }
 
 type refreshMsg struct {
@@ -157,6 +267,12 @@ type deleteMsg struct {
 	err error
 }
 
+type starMsg struct {
+	id      string
+	starred bool
+	err     error
+}
+
 type reprocessMsg struct {
 	bookmark *db.Bookmark
 	err      error
@@ -167,7 +283,7 @@ func (m model) Init() tea.Cmd {
 }
 
 func (m model) initStore() tea.Msg {
-	store, err := db.NewStore(m.cfg.DataDir)
+	store, err := indexer.OpenStore(m.cfg)
 	if err != nil {
 		return initMsg{err: err}
 	}
@@ -184,7 +300,7 @@ func (m model) initStore() tea.Msg {
 	if needsRefresh {
 		// Run refresh in background (incremental, silent to avoid corrupting TUI)
 		go func() {
-			indexer.Fetch(m.cfg, indexer.FetchOptions{Silent: true})
+			indexer.Fetch(m.cfg, indexer.FetchOptions{Silent: true, Batch: m.cfg.LLM.Batch})
 		}()
 	}
 
@@ -193,7 +309,12 @@ func (m model) initStore() tea.Msg {
 		return initMsg{store: store, err: err}
 	}
 
-	return initMsg{store: store, bookmarks: bookmarks}
+	ids := make([]string, len(bookmarks))
+	for i, b := range bookmarks {
+		ids[i] = b.ID
+	}
+	linkedFrom, _ := store.LinkedFrom(ids)
+	return initMsg{store: store, bookmarks: bookmarks, linkedFrom: linkedFrom}
 }
 
 func (m model) doSearch(query string) tea.Cmd {
@@ -202,8 +323,58 @@ func (m model) doSearch(query string) tea.Cmd {
 			return searchMsg{err: fmt.Errorf("store not initialized")}
 		}
 
-		bookmarks, err := m.store.Search(query, 50)
-		return searchMsg{bookmarks: bookmarks, err: err}
+		results, err := m.store.Search(query, 50)
+		return searchMsg{results: results, err: err}
+	}
+}
+
+func (m model) doRelated(b db.Bookmark) tea.Cmd {
+	return func() tea.Msg {
+		results, err := m.store.Related(b.ID, 50)
+		return relatedMsg{bookmark: b, results: results, err: err}
+	}
+}
+
+// doSubmittedSearch searches and reranks the results when search.rerank is
+// configured. Live search while typing skips reranking to stay responsive.
+func (m model) doSubmittedSearch(query string) tea.Cmd {
+	return func() tea.Msg {
+		if m.store == nil {
+			return searchMsg{err: fmt.Errorf("store not initialized")}
+		}
+
+		results, err := m.store.Search(query, m.reranker.Candidates(50))
+		if err != nil {
+			return searchMsg{err: err}
+		}
+		msg := searchMsg{}
+		msg.results, err = m.reranker.Rerank(query, results)
+		if err != nil {
+			msg.note = err.Error()
+		}
+		msg.results = msg.results[:min(len(msg.results), 50)]
+		return msg
+	}
+}
+
+// shouldInterpret reports whether a query submitted with Enter goes through LLM
+// query understanding: always with a leading "?", otherwise when search.understand
+// is set and the query has no filters of its own.
+func (m model) shouldInterpret(query string) (string, bool) {
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(query), "?"); ok {
+		return strings.TrimSpace(rest), true
+	}
+	if !m.cfg.Search.Understand || strings.TrimSpace(query) == "" {
+		return query, false
+	}
+	q, err := db.ParseQuery(query)
+	return query, err == nil && !q.HasFilters()
+}
+
+func (m model) doInterpret(query string) tea.Cmd {
+	return func() tea.Msg {
+		interpreted, err := indexer.InterpretQuery(m.cfg, m.store, query)
+		return interpretMsg{original: query, query: interpreted, err: err}
 	}
 }
 
@@ -219,6 +390,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		}
+		if m.chatting {
+			return m.updateChat(msg)
+		}
+		if m.browsingTopics {
+			return m.updateTopics(msg)
+		}
+		if m.browsingReleases {
+			return m.updateReleases(msg)
+		}
 
 		switch msg.String() {
 		case "ctrl+c", "q":
@@ -226,9 +406,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			}
 		case "esc":
+			if m.explaining {
+				m.explaining = false
+				return m, nil
+			}
 			if m.editing {
 				m.editing = false
 				m.editBookmark = nil
+				m.editRepo, m.editRaindrop, m.editAnnotations = nil, nil, nil
 				m.editInputs = nil
 				m.editTextareas = nil
 				return m, nil
@@ -238,6 +423,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.searchInput.Blur()
 				return m, nil
 			}
+			if m.beforeRelated != nil && !m.deleting {
+				m.restoreResults(*m.beforeRelated)
+				m.relatedTo = nil
+				m.viewHeader = ""
+				m.beforeRelated = nil
+				return m, nil
+			}
 		case "tab":
 			if m.editing {
 				m.blurFocusedField()
@@ -266,13 +458,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.searching {
 				m.searching = false
 				m.searchInput.Blur()
-				return m, m.doSearch(m.searchInput.Value())
+				if query, ok := m.shouldInterpret(m.searchInput.Value()); ok {
+					return m, m.doInterpret(query)
+				}
+				return m, m.doSubmittedSearch(m.searchInput.Value())
 			}
 			// Open edit modal for selected bookmark
 			if item, ok := m.list.SelectedItem().(bookmarkItem); ok {
 				m.editing = true
 				bm := item.bookmark
 				m.editBookmark = &bm
+				m.editRepo, m.editRaindrop, m.editAnnotations = nil, nil, nil
+				if m.store != nil {
+					m.editRepo, _ = m.store.GetRepoMetadata(bm.ID)
+					m.editRaindrop, _ = m.store.GetRaindropMetadata(bm.ID)
+					m.editAnnotations, _ = m.store.Annotations(bm.ID)
+				}
 				m.createEditFields(&bm)
 				m.editFocusIdx = 0
 				m.focusField()
@@ -324,6 +525,42 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, m.doReprocess(item.bookmark.ID)
 				}
 			}
+		case "e":
+			if m.explaining {
+				m.explaining = false
+				return m, nil
+			}
+			if !m.searching && !m.editing && !m.deleting {
+				if _, ok := m.list.SelectedItem().(bookmarkItem); ok {
+					m.explaining = true
+				}
+				return m, nil
+			}
+		case "m":
+			if !m.searching && !m.editing && !m.deleting && m.store != nil {
+				if item, ok := m.list.SelectedItem().(bookmarkItem); ok {
+					return m, m.doRelated(item.bookmark)
+				}
+			}
+		case "s":
+			if !m.searching && !m.editing && !m.deleting && m.store != nil {
+				if item, ok := m.list.SelectedItem().(bookmarkItem); ok {
+					return m, m.doStar(item.bookmark.ID, !item.bookmark.Starred)
+				}
+			}
+		case "t":
+			if !m.searching && !m.editing && !m.deleting && m.store != nil {
+				return m, m.doListTopics()
+			}
+		case "R":
+			if !m.searching && !m.editing && !m.deleting && m.store != nil {
+				return m, m.doListReleases()
+			}
+		case "c":
+			if !m.searching && !m.editing && !m.deleting && m.store != nil {
+				m.openChat()
+				return m, nil
+			}
 		case "y":
 			if m.deleting && m.deleteBookmark != nil {
 				return m, m.doDelete(m.deleteBookmark.ID)
@@ -367,19 +604,135 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.store = msg.store
+		m.reranker = indexer.NewReranker(m.cfg, msg.store)
 		m.allBookmarks = msg.bookmarks
+		m.linkedFrom = msg.linkedFrom
 		m.list.SetItems(m.bookmarksToItems(msg.bookmarks))
 		return m, nil
 
 	case searchMsg:
+		var qe *db.QueryError
+		if errors.As(msg.err, &qe) {
+			// Usually a filter still being typed; keep the current results
+			m.queryErr = qe.Error()
+			return m, nil
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
-		m.allBookmarks = msg.bookmarks
-		m.list.SetItems(m.bookmarksToItems(msg.bookmarks))
+		m.queryErr = ""
+		m.searchNote = msg.note
+		m.relatedTo = nil
+		m.viewHeader = ""
+		m.beforeRelated = nil
+		m.setResults(msg.results)
+		return m, nil
+
+	case relatedMsg:
+		if msg.err != nil {
+			m.searchNote = "Finding related bookmarks failed: " + msg.err.Error()
+			return m, nil
+		}
+		if m.beforeRelated == nil {
+			saved := m.currentResults()
+			m.beforeRelated = &saved
+		}
+		m.relatedTo = &msg.bookmark
+		m.viewHeader = ""
+		m.setResults(msg.results)
+		m.list.Select(0)
+		return m, nil
+
+	case topicsMsg:
+		if msg.err != nil {
+			m.searchNote = "Listing topics failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.topics = msg.topics
+		m.topicSel = min(m.topicSel, max(len(m.topics)-1, 0))
+		m.browsingTopics = true
+		return m, nil
+
+	case releasesMsg:
+		if msg.err != nil {
+			m.searchNote = "Listing releases failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.releases = msg.releases
+		m.releaseSel = 0
+		m.browsingReleases = true
+		return m, nil
+
+	case topicMembersMsg:
+		if msg.err != nil {
+			m.searchNote = "Listing the topic failed: " + msg.err.Error()
+			return m, nil
+		}
+		if m.beforeRelated == nil {
+			saved := m.currentResults()
+			m.beforeRelated = &saved
+		}
+		m.relatedTo = nil
+		m.viewHeader = fmt.Sprintf("Topic %q — press Esc to go back, t for topics", msg.topic.Name)
+		m.setResults(db.NewSearchResults(msg.bookmarks))
+		m.list.Select(0)
+		return m, nil
+
+	case chatChunkMsg:
+		m.chatStreamed += msg.text
+		return m, waitForChat(m.chatCh)
+
+	case chatDoneMsg:
+		m.chatPending = false
+		m.chatCh = nil
+		if msg.err != nil {
+			m.chatNote = "Ask failed: " + msg.err.Error()
+			m.chatInput.SetValue(m.chatQuestion) // Ready to retry
+			return m, nil
+		}
+		m.chatTurns = append(m.chatTurns, msg.answer)
+		m.chatSel = -1
 		return m, nil
 
+	case chatSavedMsg:
+		for _, b := range msg.bookmarks {
+			m.replaceBookmark(*b)
+		}
+		if msg.err != nil {
+			m.chatNote = "Saving the conversation failed: " + msg.err.Error()
+		} else {
+			m.chatNote = fmt.Sprintf("Saved to the notes of %d cited bookmark(s)", len(msg.bookmarks))
+		}
+		return m, nil
+
+	case citedMsg:
+		if msg.err != nil {
+			m.searchNote = "Showing cited bookmarks failed: " + msg.err.Error()
+			return m, nil
+		}
+		if m.beforeRelated == nil {
+			saved := m.currentResults()
+			m.beforeRelated = &saved
+		}
+		m.relatedTo = nil
+		m.viewHeader = "Bookmarks cited in chat — press Esc to go back, c to return to the chat"
+		m.setResults(db.NewSearchResults(msg.bookmarks))
+		m.selectBookmark(msg.selectID)
+		return m, nil
+
+	case interpretMsg:
+		if msg.err != nil {
+			// Live search already shows results for the text as typed
+			m.queryErr = "Query understanding failed: " + msg.err.Error()
+			return m, nil
+		}
+		if msg.query != msg.original {
+			m.interpreted = msg.original
+			m.searchInput.SetValue(msg.query)
+		}
+		return m, m.doSubmittedSearch(msg.query)
+
 	case refreshMsg:
 		if msg.err != nil {
 			m.err = msg.err
@@ -394,6 +747,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case editSaveMsg:
 		m.editing = false
 		m.editBookmark = nil
+		m.editRepo, m.editRaindrop, m.editAnnotations = nil, nil, nil
 		m.editInputs = nil
 		m.editTextareas = nil
 		if msg.err != nil {
@@ -407,6 +761,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 		}
+		delete(m.highlights, msg.bookmark.ID) // Stale after the edit
 		m.list.SetItems(m.bookmarksToItems(m.allBookmarks))
 		return m, nil
 
@@ -425,6 +780,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.allBookmarks = newBookmarks
+		if m.beforeRelated != nil {
+			m.beforeRelated.bookmarks = slices.DeleteFunc(slices.Clone(m.beforeRelated.bookmarks), func(b db.Bookmark) bool {
+				return b.ID == msg.id
+			})
+		}
+		m.list.SetItems(m.bookmarksToItems(m.allBookmarks))
+		return m, nil
+
+	case starMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		for i := range m.allBookmarks {
+			if m.allBookmarks[i].ID == msg.id {
+				m.allBookmarks[i].Starred = msg.starred
+			}
+		}
 		m.list.SetItems(m.bookmarksToItems(m.allBookmarks))
 		return m, nil
 
@@ -442,6 +815,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 			}
+			delete(m.highlights, msg.bookmark.ID)
 			m.list.SetItems(m.bookmarksToItems(m.allBookmarks))
 		}
 		return m, nil
@@ -464,8 +838,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	} else if m.searching {
 		var cmd tea.Cmd
+		before := m.searchInput.Value()
 		m.searchInput, cmd = m.searchInput.Update(msg)
 		cmds = append(cmds, cmd)
+		if m.searchInput.Value() != before {
+			m.interpreted = ""
+		}
 
 		// Live search on input change (including when empty to restore full list)
 		cmds = append(cmds, m.doSearch(m.searchInput.Value()))
@@ -478,6 +856,48 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// setResults shows search results in the list, keeping their highlights and scores.
+func (m *model) setResults(results []db.SearchResult) {
+	m.allBookmarks = make([]db.Bookmark, len(results))
+	m.highlights = make(map[string]map[string]string)
+	m.scores = make(map[string]float64)
+	m.explanations = make(map[string]*db.Explanation)
+	m.linkedFrom = make(map[string][]db.LinkedFrom)
+	for i, r := range results {
+		m.allBookmarks[i] = r.Bookmark
+		if len(r.LinkedFrom) > 0 {
+			m.linkedFrom[r.ID] = r.LinkedFrom
+		}
+		if len(r.Highlights) > 0 {
+			m.highlights[r.ID] = r.Highlights
+		}
+		if r.Explain != nil {
+			m.scores[r.ID] = r.Score
+			m.explanations[r.ID] = r.Explain
+		}
+	}
+	m.list.SetItems(m.bookmarksToItems(m.allBookmarks))
+}
+
+func (m model) currentResults() resultSet {
+	return resultSet{
+		bookmarks:    m.allBookmarks,
+		highlights:   m.highlights,
+		scores:       m.scores,
+		explanations: m.explanations,
+		linkedFrom:   m.linkedFrom,
+	}
+}
+
+func (m *model) restoreResults(rs resultSet) {
+	m.allBookmarks = rs.bookmarks
+	m.highlights = rs.highlights
+	m.scores = rs.scores
+	m.explanations = rs.explanations
+	m.linkedFrom = rs.linkedFrom
+	m.list.SetItems(m.bookmarksToItems(m.allBookmarks))
+}
+
 type filterMsg struct{}
 
 func (m model) filterResults() tea.Msg {
@@ -544,7 +964,7 @@ func (m *model) createEditFields(b *db.Bookmark) {
 	m.editTextareas[1] = textarea.New()
 	m.editTextareas[1].Placeholder = "Notes"
 	m.editTextareas[1].SetValue(b.Notes)
-	m.editTextareas[1].CharLimit = 500
+	m.editTextareas[1].CharLimit = 0 // Saved chats can be long
 	m.editTextareas[1].SetWidth(fieldWidth)
 	m.editTextareas[1].SetHeight(notesLines)
 	m.editTextareas[1].ShowLineNumbers = false
@@ -602,10 +1022,26 @@ func (m model) saveEdit() tea.Cmd {
 
 	// Copy values from fields
 	bm := *m.editBookmark
+	bm.Provenance = make(db.Provenance, len(m.editBookmark.Provenance))
+	for field, origin := range m.editBookmark.Provenance {
+		bm.Provenance[field] = origin
+	}
 	bm.Title = m.editInputs[0].Value()
 	bm.Summary = m.editTextareas[0].Value()
 	bm.Keywords = m.editInputs[1].Value()
 	bm.Notes = m.editTextareas[1].Value()
+
+	// Changed fields become user-authored so reprocessing keeps them
+	for field, values := range map[string][2]string{
+		db.FieldTitle:    {m.editBookmark.Title, bm.Title},
+		db.FieldSummary:  {m.editBookmark.Summary, bm.Summary},
+		db.FieldKeywords: {m.editBookmark.Keywords, bm.Keywords},
+		db.FieldNotes:    {m.editBookmark.Notes, bm.Notes},
+	} {
+		if values[0] != values[1] {
+			bm.SetOrigin(field, db.OriginUser)
+		}
+	}
 	store := m.store
 
 	return func() tea.Msg {
@@ -631,10 +1067,20 @@ func (m model) doDelete(id string) tea.Cmd {
 	}
 }
 
+func (m model) doStar(id string, starred bool) tea.Cmd {
+	store := m.store
+	return func() tea.Msg {
+		if err := store.SetStarred(id, starred); err != nil {
+			return starMsg{err: err}
+		}
+		return starMsg{id: id, starred: starred}
+	}
+}
+
 func (m model) doReprocess(id string) tea.Cmd {
 	cfg := m.cfg
 	return func() tea.Msg {
-		b, err := indexer.ReprocessByID(cfg, id, false)
+		b, err := indexer.ReprocessByID(cfg, id, indexer.ReprocessOptions{})
 		return reprocessMsg{bookmark: b, err: err}
 	}
 }
@@ -643,7 +1089,12 @@ func (m model) bookmarksToItems(bookmarks []db.Bookmark) []list.Item {
 	items := make([]list.Item, 0, len(bookmarks))
 	for _, b := range bookmarks {
 		if m.sources[b.Source] {
-			items = append(items, bookmarkItem{bookmark: b, reprocessing: m.reprocessing && m.reprocessingID == b.ID})
+			items = append(items, bookmarkItem{
+				bookmark:     b,
+				highlights:   m.highlights[b.ID],
+				linkedFrom:   m.linkedFrom[b.ID],
+				reprocessing: m.reprocessing && m.reprocessingID == b.ID,
+			})
 		}
 	}
 	return items
@@ -664,6 +1115,26 @@ func (m model) View() string {
 		return m.renderDeleteConfirm()
 	}
 
+	// Chat pane
+	if m.chatting {
+		return m.renderChat()
+	}
+
+	// Topics overlay
+	if m.browsingReleases {
+		return m.renderReleases()
+	}
+	if m.browsingTopics {
+		return m.renderTopics()
+	}
+
+	// Ranking debug overlay
+	if m.explaining {
+		if item, ok := m.list.SelectedItem().(bookmarkItem); ok {
+			return m.renderExplain(item.bookmark)
+		}
+	}
+
 	var b strings.Builder
 
 	// Header with search and filters
@@ -702,6 +1173,24 @@ func (m model) View() string {
 	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, searchBox, "  ", filterBar))
 	b.WriteString("\n")
 
+	if m.queryErr != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Render(m.queryErr))
+		b.WriteString("\n")
+	} else if m.interpreted != "" {
+		b.WriteString(filterStyle.Render(fmt.Sprintf("Interpreted from %q — press / to edit the filters", m.interpreted)))
+		b.WriteString("\n")
+	} else if m.searchNote != "" {
+		b.WriteString(filterStyle.Render(m.searchNote))
+		b.WriteString("\n")
+	}
+	if m.relatedTo != nil {
+		b.WriteString(filterStyle.Render(fmt.Sprintf("Related to %q — press Esc to go back", sanitizeLine(m.relatedTo.Title))))
+		b.WriteString("\n")
+	} else if m.viewHeader != "" {
+		b.WriteString(filterStyle.Render(m.viewHeader))
+		b.WriteString("\n")
+	}
+
 	if m.reprocessing {
 		statusStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("229")).
@@ -721,7 +1210,7 @@ func (m model) View() string {
 		Foreground(lipgloss.Color("240")).
 		MarginTop(1)
 
-	help := "[j/k]nav [g/G]top/end [/]search [o]pen [Enter]edit [r]reprocess [d]delete [1-4]filters [q]uit"
+	help := "[j/k]nav [g/G]top/end [/]search [o]pen [Enter]edit [r]reprocess [d]delete [m]ore like this [s]tar [c]hat [t]opics [R]eleases [e]xplain [1-4]filters [q]uit"
 	b.WriteString(helpStyle.Render(help))
 
 	return b.String()
@@ -788,8 +1277,52 @@ func (m model) renderEditModal() string {
 	wrappedURL := lipgloss.NewStyle().Width(m.width - 12).Render(m.editBookmark.URL)
 	content.WriteString(urlStyle.Render(wrappedURL))
 	content.WriteString("\n")
+	for _, l := range m.linkedFrom[m.editBookmark.ID] {
+		content.WriteString(viaStyle.Width(m.width - 12).Render("Via " + l.String()))
+		content.WriteString("\n\n")
+	}
+	if r := m.editRepo; r != nil {
+		repoStyle := viaStyle
+		if r.Archived || r.Abandoned() {
+			repoStyle = repoStyle.Foreground(lipgloss.Color("214"))
+		}
+		content.WriteString(repoStyle.Width(m.width - 12).Render(r.String()))
+		content.WriteString("\n")
+		if len(r.Topics) > 0 {
+			content.WriteString(viaStyle.Width(m.width - 12).Render("Topics: " + strings.Join(r.Topics, ", ")))
+			content.WriteString("\n")
+		}
+		if r.ReadmeExcerpt != "" {
+			excerpt := strings.Join(strings.Fields(r.ReadmeExcerpt), " ")
+			if runes := []rune(excerpt); len(runes) > 240 {
+				excerpt = string(runes[:240]) + "…"
+			}
+			content.WriteString(viaStyle.Width(m.width - 12).Render("README: " + excerpt))
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+	}
+	if r := m.editRaindrop; r != nil && r.CollectionName != "" {
+		content.WriteString(viaStyle.Width(m.width - 12).Render("Raindrop collection: " + r.CollectionName))
+		content.WriteString("\n\n")
+	}
+	for _, a := range m.editAnnotations {
+		text := "“" + sanitizeLine(a.Text) + "”"
+		if a.Note != "" {
+			text += " — " + sanitizeLine(a.Note)
+		}
+		content.WriteString(viaStyle.Width(m.width - 12).Render(text))
+		content.WriteString("\n")
+	}
+	if len(m.editAnnotations) > 0 {
+		content.WriteString("\n")
+	}
+
+	editedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214"))
 
 	labels := []string{"Title:", "Summary:", "Keywords:", "Notes:"}
+	fields := []string{db.FieldTitle, db.FieldSummary, db.FieldKeywords, db.FieldNotes}
 	for i := 0; i < 4; i++ {
 		var label string
 
@@ -798,6 +1331,9 @@ func (m model) renderEditModal() string {
 		} else {
 			label = labelStyle.Render(labels[i])
 		}
+		if m.editBookmark.IsUserEdited(fields[i]) {
+			label += editedStyle.Render("✎ edited by hand")
+		}
 
 		// Get appropriate field view based on index
 		var fieldView string
@@ -867,6 +1403,59 @@ func (m model) renderDeleteConfirm() string {
 	return modalStyle.Render(content.String())
 }
 
+// renderExplain shows how the selected result's search score was computed.
+func (m model) renderExplain(b db.Bookmark) string {
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2).
+		Width(80)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("86")).
+		MarginBottom(1)
+
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		MarginTop(1)
+
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("Ranking"))
+	content.WriteString("\n\n")
+
+	title := sanitizeLine(b.Title)
+	if len(title) > 70 {
+		title = title[:70] + "..."
+	}
+	content.WriteString(title)
+	content.WriteString("\n")
+	content.WriteString(dimStyle.Render(b.URL))
+	content.WriteString("\n\n")
+
+	if explain, ok := m.explanations[b.ID]; ok {
+		content.WriteString(fmt.Sprintf("Score %.4f (%s)\n", m.scores[b.ID], explain.Fusion))
+		for _, s := range explain.Signals {
+			content.WriteString("  " + s.String() + "\n")
+		}
+		for _, boost := range explain.Boosts {
+			content.WriteString("  " + boost.String() + "\n")
+		}
+		if explain.Rerank != nil {
+			content.WriteString("  " + explain.Rerank.String() + "\n")
+		}
+	} else {
+		content.WriteString("Not ranked: search for some text to see how results score.\n")
+	}
+
+	content.WriteString(helpStyle.Render("[e/Esc]close"))
+
+	return modalStyle.Render(content.String())
+}
+
 func openBrowser(url string) {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
@@ -1,10 +1,13 @@
 package tui
 
 import (
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/user/xhub/internal/config"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/indexer"
 )
 
 func TestInitialModel_ListFocused(t *testing.T) {
@@ -90,3 +93,180 @@ func TestUpdate_JKNavigatesInListMode(t *testing.T) {
 	_, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
 	_, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
 }
+
+func TestShouldInterpret(t *testing.T) {
+	cfg := &config.Config{DataDir: "/tmp/xhub-test"}
+	m := initialModel(cfg)
+
+	if _, ok := m.shouldInterpret("rust repos from last spring"); ok {
+		t.Error("expected no interpretation when search.understand is off")
+	}
+	if q, ok := m.shouldInterpret("?rust repos from last spring"); !ok || q != "rust repos from last spring" {
+		t.Errorf("expected leading ? to force interpretation, got %q, %v", q, ok)
+	}
+
+	m.cfg.Search.Understand = true
+	if _, ok := m.shouldInterpret("rust repos from last spring"); !ok {
+		t.Error("expected interpretation when search.understand is on")
+	}
+	if _, ok := m.shouldInterpret("async source:github"); ok {
+		t.Error("expected queries with filters to be searched as typed")
+	}
+}
+
+func TestBookmarkItem_ShowsMatchSnippet(t *testing.T) {
+	item := bookmarkItem{
+		bookmark: db.Bookmark{Title: "Tokio", Summary: "A runtime", Notes: "compare with smol"},
+		highlights: map[string]string{
+			db.FieldNotes: "compare with\n<mark>smol</mark>",
+		},
+	}
+	desc := item.Description()
+	if !strings.HasPrefix(desc, "Notes: compare with ") || !strings.Contains(desc, "smol") || strings.Contains(desc, "<mark>") {
+		t.Errorf("expected rendered notes snippet, got %q", desc)
+	}
+
+	item.highlights = nil
+	if desc := item.Description(); desc != "A runtime" {
+		t.Errorf("expected summary without a match, got %q", desc)
+	}
+}
+
+func TestUpdate_EShowsRanking(t *testing.T) {
+	cfg := &config.Config{DataDir: "/tmp/xhub-test"}
+	m := initialModel(cfg)
+
+	newModel, _ := m.Update(searchMsg{results: []db.SearchResult{{
+		Bookmark: db.Bookmark{ID: "a", Source: "x", Title: "Tokio", URL: "https://tokio.rs"},
+		Score:    0.0164,
+		Explain: &db.Explanation{Signals: []db.SignalScore{
+			{Signal: db.SignalFTS, Rank: 1, Raw: 3.2, Weight: 1, Contribution: 0.0164},
+		}},
+	}}})
+	m = newModel.(model)
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m = newModel.(model)
+	if !m.explaining {
+		t.Fatal("expected ranking overlay after pressing e")
+	}
+	if view := m.View(); !strings.Contains(view, "Score 0.0164") || !strings.Contains(view, "fts #1") {
+		t.Errorf("expected score breakdown in overlay, got %q", view)
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(model)
+	if m.explaining {
+		t.Error("expected esc to close the ranking overlay")
+	}
+}
+
+func TestUpdate_RelatedViewRestoresResults(t *testing.T) {
+	cfg := &config.Config{DataDir: "/tmp/xhub-test"}
+	m := initialModel(cfg)
+
+	newModel, _ := m.Update(searchMsg{results: []db.SearchResult{
+		{Bookmark: db.Bookmark{ID: "a", Source: "x", Title: "Tokio"}},
+		{Bookmark: db.Bookmark{ID: "b", Source: "x", Title: "Smol"}},
+	}})
+	m = newModel.(model)
+
+	newModel, _ = m.Update(relatedMsg{
+		bookmark: db.Bookmark{ID: "a", Source: "x", Title: "Tokio"},
+		results:  []db.SearchResult{{Bookmark: db.Bookmark{ID: "c", Source: "x", Title: "Mio"}}},
+	})
+	m = newModel.(model)
+	if m.relatedTo == nil || len(m.list.Items()) != 1 {
+		t.Fatalf("expected the related view with 1 item, got %d items", len(m.list.Items()))
+	}
+	if !strings.Contains(m.View(), `Related to "Tokio"`) {
+		t.Error("expected the related view header")
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(model)
+	if m.relatedTo != nil || len(m.list.Items()) != 2 {
+		t.Errorf("expected esc to restore the 2 search results, got %d items", len(m.list.Items()))
+	}
+}
+
+func TestUpdate_ChatCitationJumpsToBookmark(t *testing.T) {
+	cfg := &config.Config{DataDir: "/tmp/xhub-test"}
+	m := initialModel(cfg)
+
+	newModel, _ := m.Update(searchMsg{results: []db.SearchResult{
+		{Bookmark: db.Bookmark{ID: "a", Source: "x", Title: "Tokio"}},
+		{Bookmark: db.Bookmark{ID: "b", Source: "github", Title: "Smol"}},
+	}})
+	m = newModel.(model)
+	m.sources["github"] = false
+	m.openChat()
+
+	newModel, _ = m.Update(chatDoneMsg{answer: &indexer.Answer{
+		Question: "which runtime is smaller?",
+		Answer:   "Smol [2].",
+		Sources: []indexer.AskSource{
+			{N: 1, ID: "a", Title: "Tokio"},
+			{N: 2, ID: "b", Title: "Smol", Cited: true},
+		},
+	}})
+	m = newModel.(model)
+	if view := m.View(); !strings.Contains(view, "You: which runtime is smaller?") || !strings.Contains(view, "[2] Smol") {
+		t.Errorf("expected the answer and its citation in the chat pane, got %q", view)
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = newModel.(model)
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if m.chatting {
+		t.Fatal("expected enter on a citation to close the chat pane")
+	}
+	item, ok := m.list.SelectedItem().(bookmarkItem)
+	if !ok || item.bookmark.ID != "b" {
+		t.Errorf("expected the cited bookmark selected, got %+v", m.list.SelectedItem())
+	}
+	if len(m.chatTurns) != 1 {
+		t.Error("expected the conversation kept for when the chat is reopened")
+	}
+}
+
+func TestUpdate_TopicDrillsIntoMembers(t *testing.T) {
+	cfg := &config.Config{DataDir: "/tmp/xhub-test"}
+	m := initialModel(cfg)
+
+	newModel, _ := m.Update(topicsMsg{topics: []db.Collection{
+		{ID: 1, Name: "Rust async", Count: 2},
+		{ID: 2, Name: "Sourdough", Count: 1, Description: "Bread baking"},
+	}})
+	m = newModel.(model)
+	if !m.browsingTopics || !strings.Contains(m.View(), "Rust async") {
+		t.Fatal("expected the topics overlay")
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	m = newModel.(model)
+	if !strings.Contains(m.View(), "Bread baking") {
+		t.Error("expected the selected topic's description")
+	}
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if m.browsingTopics || cmd == nil {
+		t.Fatal("expected enter to close the overlay and load the topic")
+	}
+
+	newModel, _ = m.Update(topicMembersMsg{
+		topic:     db.Collection{ID: 2, Name: "Sourdough"},
+		bookmarks: []db.Bookmark{{ID: "s", Source: "x", Title: "Starter tips"}},
+	})
+	m = newModel.(model)
+	if len(m.list.Items()) != 1 || !strings.Contains(m.View(), `Topic "Sourdough"`) {
+		t.Errorf("expected the topic's members with a header, got %d items", len(m.list.Items()))
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(model)
+	if m.viewHeader != "" || len(m.list.Items()) != 0 {
+		t.Error("expected esc to go back to the previous list")
+	}
+}
@@ -0,0 +1,299 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/user/xhub/internal/db"
+	"github.com/user/xhub/internal/indexer"
+)
+
+// chatChunkMsg is a piece of the answer being streamed.
+type chatChunkMsg struct {
+	text string
+}
+
+type chatDoneMsg struct {
+	answer *indexer.Answer
+	err    error
+}
+
+type chatSavedMsg struct {
+	bookmarks []*db.Bookmark
+	err       error
+}
+
+// citedMsg shows the bookmarks cited in the conversation, to jump to one that
+// isn't in the current list.
+type citedMsg struct {
+	bookmarks []db.Bookmark
+	selectID  string
+	err       error
+}
+
+func newChatInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Ask about your bookmarks..."
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	ti.CharLimit = 500
+	ti.Width = 60
+	return ti
+}
+
+func (m *model) openChat() {
+	m.chatting = true
+	m.chatInput.Width = max(m.width-12, 20)
+	m.chatInput.Focus()
+}
+
+// doAsk asks a question with the conversation so far as history. The answer
+// streams back as chatChunkMsgs over ch, followed by a chatDoneMsg.
+func (m model) doAsk(question string, ch chan tea.Msg) tea.Cmd {
+	cfg, store := m.cfg, m.store
+	opts := indexer.AskOptions{
+		History: indexer.AskHistory(m.chatTurns),
+		OnText:  func(text string) { ch <- chatChunkMsg{text: text} },
+	}
+	go func() {
+		answer, err := indexer.Ask(cfg, store, question, opts)
+		ch <- chatDoneMsg{answer: answer, err: err}
+	}()
+	return waitForChat(ch)
+}
+
+func waitForChat(ch chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+func (m model) doSaveChat() tea.Cmd {
+	store, turns := m.store, m.chatTurns
+	return func() tea.Msg {
+		saved, err := indexer.SaveConversationNote(store, turns)
+		return chatSavedMsg{bookmarks: saved, err: err}
+	}
+}
+
+func (m model) doShowCited(selectID string) tea.Cmd {
+	store, cited := m.store, m.chatCitations()
+	return func() tea.Msg {
+		var bookmarks []db.Bookmark
+		seen := make(map[string]bool)
+		for _, s := range cited {
+			if seen[s.ID] {
+				continue
+			}
+			seen[s.ID] = true
+			b, err := store.Get(s.ID)
+			if err != nil {
+				return citedMsg{err: fmt.Errorf("bookmark %s: %w", s.ID, err)}
+			}
+			bookmarks = append(bookmarks, *b)
+		}
+		return citedMsg{bookmarks: bookmarks, selectID: selectID}
+	}
+}
+
+// chatCitations lists the sources cited across the conversation, in order.
+func (m model) chatCitations() []indexer.AskSource {
+	var cited []indexer.AskSource
+	for _, a := range m.chatTurns {
+		cited = append(cited, a.CitedSources()...)
+	}
+	return cited
+}
+
+// updateChat handles keys while the chat pane is open.
+func (m model) updateChat(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.chatting = false
+		m.chatInput.Blur()
+		return m, nil
+	case "up", "down":
+		cited := m.chatCitations()
+		if len(cited) == 0 {
+			return m, nil
+		}
+		switch {
+		case msg.String() == "down":
+			m.chatSel = (m.chatSel + 1) % len(cited)
+		case m.chatSel <= 0:
+			m.chatSel = len(cited) - 1
+		default:
+			m.chatSel--
+		}
+		return m, nil
+	case "enter":
+		question := strings.TrimSpace(m.chatInput.Value())
+		if question == "" {
+			// Jump to the selected citation
+			cited := m.chatCitations()
+			if m.chatSel < 0 || m.chatSel >= len(cited) {
+				return m, nil
+			}
+			m.chatting = false
+			m.chatInput.Blur()
+			if m.selectBookmark(cited[m.chatSel].ID) {
+				return m, nil
+			}
+			return m, m.doShowCited(cited[m.chatSel].ID)
+		}
+		if m.chatPending || m.store == nil {
+			return m, nil
+		}
+		m.chatPending = true
+		m.chatQuestion = question
+		m.chatStreamed = ""
+		m.chatNote = ""
+		m.chatInput.SetValue("")
+		m.chatCh = make(chan tea.Msg)
+		return m, m.doAsk(question, m.chatCh)
+	case "ctrl+s":
+		if m.chatPending || len(m.chatTurns) == 0 {
+			return m, nil
+		}
+		if len(m.chatCitations()) == 0 {
+			m.chatNote = "No bookmarks cited yet, nothing to save the conversation to"
+			return m, nil
+		}
+		m.chatNote = "Saving..."
+		return m, m.doSaveChat()
+	case "ctrl+n":
+		if !m.chatPending {
+			m.chatTurns = nil
+			m.chatSel = -1
+			m.chatNote = ""
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.chatInput, cmd = m.chatInput.Update(msg)
+	return m, cmd
+}
+
+// selectBookmark moves the list cursor to a bookmark in the current results,
+// turning its source filter back on if needed. Reports whether it was found.
+func (m *model) selectBookmark(id string) bool {
+	for _, b := range m.allBookmarks {
+		if b.ID != id {
+			continue
+		}
+		if !m.sources[b.Source] {
+			m.sources[b.Source] = true
+			m.list.SetItems(m.bookmarksToItems(m.allBookmarks))
+		}
+		for i, item := range m.list.Items() {
+			if bi, ok := item.(bookmarkItem); ok && bi.bookmark.ID == id {
+				m.list.Select(i)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replaceBookmark swaps in an updated copy of a bookmark wherever it is shown.
+func (m *model) replaceBookmark(updated db.Bookmark) {
+	for i, b := range m.allBookmarks {
+		if b.ID == updated.ID {
+			m.allBookmarks[i] = updated
+		}
+	}
+	if m.beforeRelated != nil {
+		for i, b := range m.beforeRelated.bookmarks {
+			if b.ID == updated.ID {
+				m.beforeRelated.bookmarks[i] = updated
+			}
+		}
+	}
+	m.list.SetItems(m.bookmarksToItems(m.allBookmarks))
+}
+
+func (m model) renderChat() string {
+	width := m.width
+	if width < 40 {
+		width = 80
+	}
+	height := m.height
+	if height < 10 {
+		height = 24
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Width(width - 4).
+		Height(height - 2)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("86"))
+
+	questionStyle := lipgloss.NewStyle().Bold(true)
+	textStyle := lipgloss.NewStyle().Width(width - 8)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Bold(true)
+
+	inputStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("86")).
+		Padding(0, 1)
+
+	var transcript strings.Builder
+	n := 0
+	for _, a := range m.chatTurns {
+		transcript.WriteString(questionStyle.Render("You: "+a.Question) + "\n")
+		transcript.WriteString(textStyle.Render(strings.TrimSpace(a.Answer)) + "\n")
+		for _, s := range a.CitedSources() {
+			line := fmt.Sprintf("  [%d] %s", s.N, sanitizeLine(s.Title))
+			if n == m.chatSel {
+				transcript.WriteString(selectedStyle.Render("> "+strings.TrimPrefix(line, "  ")) + "\n")
+			} else {
+				transcript.WriteString(dimStyle.Render(line) + "\n")
+			}
+			n++
+		}
+		transcript.WriteString("\n")
+	}
+	if m.chatPending {
+		transcript.WriteString(questionStyle.Render("You: "+m.chatQuestion) + "\n")
+		transcript.WriteString(textStyle.Render(m.chatStreamed+"…") + "\n")
+	}
+
+	// Keep the latest turns in view
+	lines := strings.Split(strings.TrimRight(transcript.String(), "\n"), "\n")
+	if room := height - 12; len(lines) > room && room > 0 {
+		lines = lines[len(lines)-room:]
+	}
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Chat with your bookmarks"))
+	content.WriteString("\n\n")
+	if len(m.chatTurns) == 0 && !m.chatPending {
+		content.WriteString(dimStyle.Render("Ask a question; answers cite the bookmarks they draw on."))
+		content.WriteString("\n")
+	} else {
+		content.WriteString(strings.Join(lines, "\n"))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	if m.chatNote != "" {
+		content.WriteString(dimStyle.Render(m.chatNote))
+		content.WriteString("\n")
+	}
+	content.WriteString(inputStyle.Render(m.chatInput.View()))
+	content.WriteString("\n")
+	content.WriteString(dimStyle.Render("[Enter]ask [↑/↓]select citation, Enter on an empty line jumps to it [ctrl+s]save as note [ctrl+n]new chat [Esc]close"))
+
+	return modalStyle.Render(content.String())
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/user/xhub/internal/db"
+)
+
+// Recent releases overlay: the last month, up to releasesLimit
+const (
+	releasesDays  = 30
+	releasesLimit = 200
+)
+
+type releasesMsg struct {
+	releases []db.Release
+	err      error
+}
+
+func (m model) doListReleases() tea.Cmd {
+	store := m.store
+	return func() tea.Msg {
+		releases, err := store.RecentReleases(time.Now().AddDate(0, 0, -releasesDays), releasesLimit)
+		return releasesMsg{releases: releases, err: err}
+	}
+}
+
+// updateReleases handles keys while the releases overlay is open.
+func (m model) updateReleases(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc", "R":
+		m.browsingReleases = false
+	case "j", "down":
+		if m.releaseSel < len(m.releases)-1 {
+			m.releaseSel++
+		}
+	case "k", "up":
+		if m.releaseSel > 0 {
+			m.releaseSel--
+		}
+	case "g":
+		m.releaseSel = 0
+	case "G":
+		m.releaseSel = max(len(m.releases)-1, 0)
+	case "enter", "o":
+		if m.releaseSel < len(m.releases) {
+			openBrowser(m.releases[m.releaseSel].URL)
+		}
+	}
+	return m, nil
+}
+
+// renderReleases lists recent releases of starred repositories, newest first.
+func (m model) renderReleases() string {
+	height := m.height
+	if height < 10 {
+		height = 24
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2).
+		Width(80)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("86")).
+		MarginBottom(1)
+
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Bold(true)
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		MarginTop(1)
+
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("Recent releases"))
+	content.WriteString("\n\n")
+
+	if len(m.releases) == 0 {
+		content.WriteString(fmt.Sprintf(`No releases in the last %d days. Run "xhub releases poll" to check.`, releasesDays))
+		content.WriteString("\n")
+	}
+
+	// Scroll to keep the selection in view
+	room := max(height-14, 3)
+	start := 0
+	if m.releaseSel >= room {
+		start = m.releaseSel - room + 1
+	}
+	for i := start; i < len(m.releases) && i < start+room; i++ {
+		r := m.releases[i]
+		name := sanitizeLine(r.Repo + " " + r.Title())
+		if len(name) > 60 {
+			name = name[:60] + "..."
+		}
+		line := fmt.Sprintf("%s  %s", r.PublishedAt.Local().Format("Jan 02"), name)
+		if i == m.releaseSel {
+			content.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+	if m.releaseSel < len(m.releases) && m.releases[m.releaseSel].Body != "" {
+		body := strings.Join(strings.Fields(m.releases[m.releaseSel].Body), " ")
+		if runes := []rune(body); len(runes) > 300 {
+			body = string(runes[:300]) + "…"
+		}
+		content.WriteString("\n")
+		content.WriteString(dimStyle.Width(74).Render(body))
+		content.WriteString("\n")
+	}
+
+	content.WriteString(helpStyle.Render("[j/k]nav [Enter]open release [R/Esc]close"))
+
+	return modalStyle.Render(content.String())
+}
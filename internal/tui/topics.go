@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/user/xhub/internal/db"
+)
+
+// topicMembersLimit caps the bookmarks listed for a topic.
+const topicMembersLimit = 1000
+
+type topicsMsg struct {
+	topics []db.Collection
+	err    error
+}
+
+type topicMembersMsg struct {
+	topic     db.Collection
+	bookmarks []db.Bookmark
+	err       error
+}
+
+func (m model) doListTopics() tea.Cmd {
+	store := m.store
+	return func() tea.Msg {
+		topics, err := store.ListCollections(db.CollectionTopic)
+		return topicsMsg{topics: topics, err: err}
+	}
+}
+
+func (m model) doTopicMembers(topic db.Collection) tea.Cmd {
+	store := m.store
+	return func() tea.Msg {
+		bookmarks, err := store.CollectionBookmarks(topic.ID, topicMembersLimit)
+		return topicMembersMsg{topic: topic, bookmarks: bookmarks, err: err}
+	}
+}
+
+// updateTopics handles keys while the topics overlay is open.
+func (m model) updateTopics(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc", "t":
+		m.browsingTopics = false
+	case "j", "down":
+		if m.topicSel < len(m.topics)-1 {
+			m.topicSel++
+		}
+	case "k", "up":
+		if m.topicSel > 0 {
+			m.topicSel--
+		}
+	case "g":
+		m.topicSel = 0
+	case "G":
+		m.topicSel = max(len(m.topics)-1, 0)
+	case "enter":
+		if m.topicSel < len(m.topics) {
+			m.browsingTopics = false
+			return m, m.doTopicMembers(m.topics[m.topicSel])
+		}
+	}
+	return m, nil
+}
+
+// renderTopics lists topics with their bookmark counts.
+func (m model) renderTopics() string {
+	height := m.height
+	if height < 10 {
+		height = 24
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2).
+		Width(80)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("86")).
+		MarginBottom(1)
+
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Bold(true)
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		MarginTop(1)
+
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("Topics"))
+	content.WriteString("\n\n")
+
+	if len(m.topics) == 0 {
+		content.WriteString(`No topics yet. Run "xhub topics build" to find them.`)
+		content.WriteString("\n")
+	}
+
+	// Scroll to keep the selection in view
+	room := max(height-12, 3)
+	start := 0
+	if m.topicSel >= room {
+		start = m.topicSel - room + 1
+	}
+	for i := start; i < len(m.topics) && i < start+room; i++ {
+		t := m.topics[i]
+		name := sanitizeLine(t.Name)
+		if len(name) > 60 {
+			name = name[:60] + "..."
+		}
+		line := fmt.Sprintf("%5d  %s", t.Count, name)
+		if i == m.topicSel {
+			content.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+	if m.topicSel < len(m.topics) && m.topics[m.topicSel].Description != "" {
+		content.WriteString("\n")
+		content.WriteString(dimStyle.Width(74).Render(m.topics[m.topicSel].Description))
+		content.WriteString("\n")
+	}
+
+	content.WriteString(helpStyle.Render("[j/k]nav [Enter]show bookmarks [t/Esc]close"))
+
+	return modalStyle.Render(content.String())
+}
@@ -0,0 +1,204 @@
+// Package urlnorm reduces the URL variants a page is bookmarked under to one
+// canonical form.
+package urlnorm
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParams are query parameters that never change the page. Plain "ref"
+// isn't one: GitHub and docs sites use it to pick a branch or version, so sites
+// where it only tracks referrers need a strip_params rule.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "mc_cid": true, "mc_eid": true,
+	"igshid": true, "ref_src": true, "ref_url": true, "_hsenc": true, "_hsmi": true,
+}
+
+// hostAliases map alternate hostnames to the one a site is canonically served from.
+var hostAliases = map[string]string{
+	"twitter.com":        "x.com",
+	"mobile.twitter.com": "x.com",
+	"mobile.x.com":       "x.com",
+	"m.youtube.com":      "youtube.com",
+	"youtu.be":           "youtube.com",
+}
+
+// httpsHosts are sites served only over https, whose http URLs are upgraded.
+var httpsHosts = map[string]bool{
+	"x.com":       true,
+	"youtube.com": true,
+}
+
+// Rule adjusts canonicalization for a domain and its subdomains.
+type Rule struct {
+	Domain            string   // e.g. "reddit.com"
+	Host              string   // Replace the host, e.g. old.reddit.com -> reddit.com
+	StripParams       []string // Extra parameters to drop; "prefix*" matches a prefix
+	KeepParams        []string // When set, drop every parameter not listed
+	KeepFragment      bool     // The fragment selects the page (hash routing)
+	KeepTrailingSlash bool
+	HTTPS             bool // The site is served over https, so http URLs are upgraded
+}
+
+// Normalizer canonicalizes URLs with the built-in rules plus per-domain Rules.
+// A nil Normalizer applies only the built-in rules.
+type Normalizer struct {
+	rules []Rule
+}
+
+// New returns a Normalizer for rules. When several rules match a host the one
+// with the longest domain wins.
+func New(rules []Rule) (*Normalizer, error) {
+	n := &Normalizer{}
+	for i, r := range rules {
+		r.Domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(r.Domain)), "www.")
+		if r.Domain == "" {
+			return nil, fmt.Errorf("rule %d: domain is required", i+1)
+		}
+		r.Host = strings.ToLower(strings.TrimSpace(r.Host))
+		n.rules = append(n.rules, r)
+	}
+	sort.SliceStable(n.rules, func(i, j int) bool { return len(n.rules[i].Domain) > len(n.rules[j].Domain) })
+	return n, nil
+}
+
+// Canonical returns the canonical form of a URL using only the built-in rules.
+func Canonical(raw string) string {
+	var n *Normalizer
+	return n.Canonical(raw)
+}
+
+// Canonical returns the canonical form of a URL: lowercase host without
+// "www.", known host aliases resolved (twitter.com is x.com), no fragment,
+// tracking parameters (utm_*, fbclid, ...) removed, remaining parameters
+// sorted, and no trailing slash. The scheme is kept, since some sites are only
+// served over http, except for sites known or configured to be https.
+// A matching Rule changes what is kept. Unparseable URLs are returned trimmed.
+func (n *Normalizer) Canonical(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	host = strings.TrimPrefix(host, "www.")
+	if alias, ok := hostAliases[host]; ok {
+		// youtu.be/<id> is youtube.com/watch?v=<id>
+		if host == "youtu.be" && len(u.Path) > 1 {
+			v := "v=" + url.QueryEscape(strings.TrimPrefix(u.Path, "/"))
+			if u.RawQuery != "" {
+				v = "&" + v
+			}
+			u.RawQuery += v
+			u.Path, u.RawPath = "/watch", ""
+		}
+		host = alias
+	}
+	rule := n.match(host)
+	if rule.Host != "" {
+		host = rule.Host
+	}
+	if u.Scheme == "http" && (rule.HTTPS || httpsHosts[host]) {
+		u.Scheme = "https"
+	}
+	u.Host = host
+	u.User = nil
+	if !rule.KeepFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+
+	// Parameters are dropped from the raw query rather than decoded and
+	// re-encoded, so the ones kept (flags like ?foo, values with ;) survive as is
+	var params []queryParam
+	for _, part := range strings.Split(u.RawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		lower := strings.ToLower(key)
+		switch {
+		case strings.HasPrefix(lower, "utm_") || trackingParams[lower] || matchParam(rule.StripParams, lower):
+		case len(rule.KeepParams) > 0 && !matchParam(rule.KeepParams, lower):
+		// X share links add ?s=20&t=... to status URLs
+		case host == "x.com" && strings.Contains(u.Path, "/status/") && (key == "s" || key == "t"):
+		default:
+			params = append(params, queryParam{key, part})
+		}
+	}
+	u.RawQuery = joinSorted(params)
+
+	if !rule.KeepTrailingSlash {
+		// Trimmed alike, RawPath stays a valid encoding of Path, so escapes
+		// like %2F that change the path's meaning are kept
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+	}
+	return u.String()
+}
+
+// Key returns the canonical form of a URL without its scheme, for telling
+// whether two URLs are the same page when one was saved over http.
+func (n *Normalizer) Key(raw string) string {
+	canonical := n.Canonical(raw)
+	for _, scheme := range []string{"https://", "http://"} {
+		if rest, ok := strings.CutPrefix(canonical, scheme); ok {
+			return rest
+		}
+	}
+	return canonical
+}
+
+// match returns the most specific rule for host, or the zero Rule.
+func (n *Normalizer) match(host string) Rule {
+	if n == nil {
+		return Rule{}
+	}
+	for _, r := range n.rules {
+		if host == r.Domain || strings.HasSuffix(host, "."+r.Domain) {
+			return r
+		}
+	}
+	return Rule{}
+}
+
+// matchParam reports whether a lowercased parameter name is in patterns,
+// where a trailing "*" matches any suffix.
+func matchParam(patterns []string, key string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
+
+// queryParam is one "key=value" part of a raw query, with its decoded key.
+type queryParam struct {
+	key, raw string
+}
+
+// joinSorted joins query parts sorted by key, keeping each key's value order.
+func joinSorted(params []queryParam) string {
+	sort.SliceStable(params, func(i, j int) bool { return params[i].key < params[j].key })
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.raw
+	}
+	return strings.Join(parts, "&")
+}
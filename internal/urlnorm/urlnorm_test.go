@@ -0,0 +1,69 @@
+package urlnorm
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://example.com/post/", "https://example.com/post"},
+		{"http://www.Example.com/post?utm_source=x&utm_medium=social#comments", "http://example.com/post"},
+		{"http://twitter.com/user/status/123", "https://x.com/user/status/123"},
+		{"https://example.com/files/a%2Fb/", "https://example.com/files/a%2Fb"},
+		{"https://example.com/search?q=go&a=1&fbclid=abc", "https://example.com/search?a=1&q=go"},
+		{"https://twitter.com/user/status/123?s=20&t=abc", "https://x.com/user/status/123"},
+		{"https://mobile.twitter.com/user/status/123", "https://x.com/user/status/123"},
+		{"https://youtu.be/dQw4w9WgXcQ?t=42", "https://youtube.com/watch?t=42&v=dQw4w9WgXcQ"},
+		{"https://example.com/list?sort=new&flat&fbclid=abc", "https://example.com/list?flat&sort=new"},
+		{"https://example.com/a?b=1;c=2&utm_source=x&q=a+b%2Fc", "https://example.com/a?b=1;c=2&q=a+b%2Fc"},
+		{"https://example.com:443/", "https://example.com"},
+		{"https://example.com:8080/a", "https://example.com:8080/a"},
+		{"https://github.com/Owner/Repo", "https://github.com/Owner/Repo"},
+		{"https://github.com/Owner/Repo/blob/main/go.mod?ref=v2", "https://github.com/Owner/Repo/blob/main/go.mod?ref=v2"},
+		{"  not a url  ", "not a url"},
+	}
+	for _, tt := range tests {
+		if got := Canonical(tt.in); got != tt.want {
+			t.Errorf("Canonical(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizerRules(t *testing.T) {
+	n, err := New([]Rule{
+		{Domain: "reddit.com", Host: "reddit.com"},
+		{Domain: "Shop.example", KeepParams: []string{"id"}},
+		{Domain: "news.example", StripParams: []string{"src_*", "share"}},
+		{Domain: "app.example", KeepFragment: true, KeepTrailingSlash: true},
+		{Domain: "secure.example", HTTPS: true},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"https://old.reddit.com/r/golang/", "https://reddit.com/r/golang"},
+		{"https://shop.example/item?id=7&color=red&utm_source=x", "https://shop.example/item?id=7"},
+		{"https://news.example/a?src_feed=1&share=1&page=2", "https://news.example/a?page=2"},
+		{"https://app.example/#/settings", "https://app.example/#/settings"},
+		{"https://other.example/a/?share=1", "https://other.example/a?share=1"},
+		{"http://www.secure.example/a", "https://secure.example/a"},
+	}
+	for _, tt := range tests {
+		if got := n.Canonical(tt.in); got != tt.want {
+			t.Errorf("Canonical(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if a, b := n.Key("http://secure.example/a"), n.Key("https://secure.example/a/"); a != b {
+		t.Errorf("expected the same key, got %q and %q", a, b)
+	}
+	if a, b := n.Key("http://plain.example/a"), n.Key("https://plain.example/a"); a != b {
+		t.Errorf("expected keys to ignore the scheme, got %q and %q", a, b)
+	}
+
+	if _, err := New([]Rule{{Host: "example.com"}}); err == nil {
+		t.Error("expected a rule without a domain to be rejected")
+	}
+}